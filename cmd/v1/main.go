@@ -79,7 +79,13 @@ func gameServerStart() {
 
 	slog.Info("Contract Bridge Game", slog.String("pid", pid), slog.String("port", endPort))
 	slog.Debug("Ctrl-C中斷Server執行")
-	err := http.ListenAndServe(endPort, server)
+	// 牌局紀錄匯出, 管理服務(HTTP)與遊戲連線共用同一個port
+	mux := http.NewServeMux()
+	mux.Handle(project.HandServicePath, project.HandService)
+	mux.Handle(project.AdminServicePath, project.AdminService)
+	mux.Handle("/", server)
+
+	err := http.ListenAndServe(endPort, mux)
 	if err != nil {
 		slog.Error("server 啟動失敗", slog.String("err", err.Error()))
 	}
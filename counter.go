@@ -24,6 +24,9 @@ type (
 		allRoomsJoins map[string]*cb.LobbyTable
 		roomJoins     chan broadcastArg //chan房間名稱表玩家加入
 		roomLeaves    chan broadcastArg //chan房間名稱表玩家離開
+		roomOpens     chan string       //chan房間名稱表新增房間
+		roomCloses    chan string       //chan房間名稱表關閉房間
+		nextId        int32             //新增房間的Id
 
 		lobbyLeaves chan *skf.NSConn // 代表誰進入, joins都必須調整
 		lobbyJoins  chan *skf.NSConn //代表誰離開, joins都必須調整
@@ -31,6 +34,7 @@ type (
 		//廣播通知 , Lobby.go 收到後會進行大廳玩家廣播
 		BroadcastJoins     chan broadcastArg //當前大廳人數
 		BroadcastRoomJoins chan broadcastArg //某間房間人數
+		BroadcastRooms     chan broadcastArg //新增或關閉房間後的大廳所有房間人數資訊
 
 		//站上總人數 = 大廳人數(joiners) + 所有房間人數(roomers)
 
@@ -65,11 +69,15 @@ func NewCounterService(roomsJoins *map[string]*cb.LobbyTable) *Counter {
 		LobbyRoomsInfo: make(chan rchanr.ChanRepWithArguments[struct{}, cb.LobbyNumOfs]),
 		roomJoins:      make(chan broadcastArg),
 		roomLeaves:     make(chan broadcastArg),
+		roomOpens:      make(chan string),
+		roomCloses:     make(chan string),
+		nextId:         idxId,
 		lobbyLeaves:    make(chan *skf.NSConn),
 		lobbyJoins:     make(chan *skf.NSConn),
 
 		BroadcastJoins:     make(chan broadcastArg),
 		BroadcastRoomJoins: make(chan broadcastArg),
+		BroadcastRooms:     make(chan broadcastArg),
 		allRoomsJoins:      *roomsJoins,
 		joiners:            0,
 		roomers:            0,
@@ -122,6 +130,29 @@ func (br *Counter) chanLoop() {
 					roomName: arg.roomName,
 				}
 			}
+		case roomName := <-br.roomOpens:
+			if _, ok := br.allRoomsJoins[roomName]; !ok {
+				br.allRoomsJoins[roomName] = &cb.LobbyTable{
+					Name:   roomName,
+					Id:     br.nextId,
+					Joiner: 0,
+				}
+				br.nextId++
+			}
+			br.BroadcastRooms <- broadcastArg{lobbyNumOfs: br.lobbyNumOfs()}
+
+		case roomName := <-br.roomCloses:
+			if table, ok := br.allRoomsJoins[roomName]; ok {
+				//房間內殘留的人數一併扣除
+				if br.roomers >= table.Joiner {
+					br.roomers -= table.Joiner
+				} else {
+					br.roomers = 0
+				}
+				delete(br.allRoomsJoins, roomName)
+			}
+			br.BroadcastRooms <- broadcastArg{lobbyNumOfs: br.lobbyNumOfs()}
+
 		case nsConn := <-br.lobbyLeaves:
 
 			if br.joiners >= 1 {
@@ -147,28 +178,31 @@ func (br *Counter) chanLoop() {
 			}
 
 		case chrr := <-br.LobbyRoomsInfo:
-
-			tables := make([]*cb.LobbyTable, 0, len(br.allRoomsJoins))
-			for roomName := range br.allRoomsJoins {
-				tables = append(tables, &cb.LobbyTable{
-					Name:   roomName,
-					Id:     br.allRoomsJoins[roomName].Id,
-					Joiner: br.allRoomsJoins[roomName].Joiner,
-					Total:  br.roomers + br.joiners,
-				})
-			}
-			result := cb.LobbyNumOfs{
-				Tables: tables,                  //所有房間人數
-				Joiner: br.joiners,              //大廳人數
-				Total:  br.joiners + br.roomers, //站上總人數
-			}
-			chrr.Response <- result
+			chrr.Response <- *br.lobbyNumOfs()
 		default:
 
 		}
 	}
 }
 
+// lobbyNumOfs 大廳所有房間人數資訊, 只能在 chanLoop 中呼叫
+func (br *Counter) lobbyNumOfs() *cb.LobbyNumOfs {
+	tables := make([]*cb.LobbyTable, 0, len(br.allRoomsJoins))
+	for roomName := range br.allRoomsJoins {
+		tables = append(tables, &cb.LobbyTable{
+			Name:   roomName,
+			Id:     br.allRoomsJoins[roomName].Id,
+			Joiner: br.allRoomsJoins[roomName].Joiner,
+			Total:  br.roomers + br.joiners,
+		})
+	}
+	return &cb.LobbyNumOfs{
+		Tables: tables,                  //所有房間人數
+		Joiner: br.joiners,              //大廳人數
+		Total:  br.joiners + br.roomers, //站上總人數
+	}
+}
+
 // GetSitePlayer 取出大廳所有房間人數資訊
 func (br *Counter) GetSitePlayer() *cb.LobbyNumOfs {
 	var result cb.LobbyNumOfs
@@ -205,3 +239,13 @@ func (br *Counter) RoomSub(nsConn *skf.NSConn, roomName string) {
 		roomName: roomName,
 	}
 }
+
+// RoomOpen 新增房間, 並廣播大廳所有房間人數資訊
+func (br *Counter) RoomOpen(roomName string) {
+	br.roomOpens <- roomName
+}
+
+// RoomClose 關閉房間, 並廣播大廳所有房間人數資訊
+func (br *Counter) RoomClose(roomName string) {
+	br.roomCloses <- roomName
+}
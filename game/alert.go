@@ -0,0 +1,252 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/moszorn/pb"
+	"github.com/moszorn/pb/cb"
+)
+
+// 警示(alert): 叫者叫牌時可以警示該叫品為約定叫並附上說明, 警示與說明只讓叫者的對手看到(夥伴看不到);
+// 對手可以詢問叫者方某個叫品的意思, 詢問私下送給叫者與夥伴(夥伴收到的詢問不含叫者的說明), 叫者方的回答私下送回詢問者
+
+// alertMark 競叫紀錄(BidHistoryBoard)中警示叫品的標記
+const alertMark = "!"
+
+// alertNoAgreement 叫者方都是機器人時自動回答的說明
+const alertNoAgreement = "沒有特殊約定"
+
+type (
+	// AlertBid 叫者叫牌並警示該叫品(GamePrivateAlertBid), 一般叫牌(GamePrivateNotyBid)不會警示
+	AlertBid struct {
+		RoomRequest
+		Bid         uint8  `json:"bid"`
+		Explanation string `json:"explanation"` //說明(可空白)
+	}
+
+	// AlertNotice 叫者警示的叫品
+	AlertNotice struct {
+		Bidder      uint8  `json:"bidder"` //叫者(CbSeat)
+		Bid         uint8  `json:"bid"`
+		BidString   string `json:"bidString"`
+		Alert       bool   `json:"alert"`
+		Explanation string `json:"explanation"` //叫者叫牌時附上的說明
+	}
+
+	// AlertQuestion 對手詢問叫品的意思
+	AlertQuestion struct {
+		AlertNotice
+		Asker uint8 `json:"asker"` //詢問者(CbSeat)
+	}
+
+	// AlertAnswer 叫者方對詢問的回答
+	AlertAnswer struct {
+		AlertQuestion
+		By     uint8  `json:"by"` //回答者(CbSeat), 叫者或其夥伴
+		Answer string `json:"answer"`
+	}
+
+	// alertAsk 待回答的詢問
+	alertAsk struct {
+		AlertQuestion
+		board uint32 //詢問時的牌號(boardNumber), 換牌後詢問失效
+	}
+)
+
+// setAlert 叫者警示此叫品, explanation 為說明(可空白)
+func (b *bidItem) setAlert(explanation string) {
+	b.alert = true
+	b.explanation = explanation
+}
+
+// alertNotice 叫品的警示內容
+func (b *bidItem) alertNotice() AlertNotice {
+	return AlertNotice{
+		Bidder:      b.who(),
+		Bid:         b.bid(),
+		BidString:   b.value.String(),
+		Alert:       b.alert,
+		Explanation: b.explanation,
+	}
+}
+
+// alertVisible 觀看者(viewer)是否可以看到叫者(bidder)的警示: 只有叫者的對手看得到, 觀眾看不到
+func alertVisible(viewer, bidder uint8) bool {
+	return viewer != valueNotSet && isOpponent(viewer, bidder)
+}
+
+// hasAlert 競叫紀錄中是否有警示的叫品
+func hasAlert(items []*bidItem) bool {
+	for _, b := range items {
+		if b.alert {
+			return true
+		}
+	}
+	return false
+}
+
+// sendNotyBid 先送出競叫通知(Public)給四家, 稍後再送出(Private)給下一個叫者(bidder)並開始叫牌計時;
+// 競叫紀錄中有警示的叫品時, 南北,東西各送一包, 警示只標示給叫者的對手
+func (g *Game) sendNotyBid(notyBid *cb.NotyBid, history []*bidItem, bidder uint8) {
+	if len(history) > 0 {
+		if hasAlert(history) {
+			for _, side := range [][2]uint8{{uint8(south), uint8(north)}, {uint8(east), uint8(west)}} {
+				notyBid.BidItems = bidHistoryItemsToProto(history, side[0])
+				g.roomManager.SendPayloadToTwoPlayer(ClnRoomEvents.GameNotyBid, notyBid, side[0], side[1])
+			}
+		} else {
+			notyBid.BidItems = bidHistoryItemsToProto(history, valueNotSet)
+			g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameNotyBid, notyBid, pb.SceneType_game)
+		}
+		notyBid.BidItems = bidHistoryItemsToProto(history, bidder)
+	} else {
+		g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameNotyBid, notyBid, pb.SceneType_game)
+	}
+
+	//先Public,才Private, 因為Public前端會先更新 Bidding Table; 等待期間不持有遊戲鎖, 已有人叫牌或撤回則不送出
+	bids := len(history)
+	g.afterDeal(g.boardNumber, time.Millisecond*400, func() {
+		if g.Phase() != PhaseBidding || len(g.engine.bidHistory.h) != bids {
+			return
+		}
+		g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payloadData{
+			Player:      bidder,
+			ProtoData:   notyBid,
+			PayloadType: ProtobufType,
+		}) //私人Private
+		g.startBidTimer(bidder)
+	})
+}
+
+// sendAlert 通知叫者的兩位對手該叫品已警示
+func (g *Game) sendAlert(b *bidItem) {
+	body, err := json.Marshal(b.alertNotice())
+	if err != nil {
+		g.log.Wrn("sendAlert", slog.String(".", err.Error()))
+		return
+	}
+	for _, seat := range playerSeats {
+		if isOpponent(seat, b.who()) {
+			g.sendToSeat(seat, ClnRoomEvents.GamePrivateAlert, body)
+		}
+	}
+}
+
+// sendToSeat 私下送給座位(seat)上的玩家, 機器人或斷線時不送
+func (g *Game) sendToSeat(seat uint8, eventName string, body []byte) {
+	nsConn, _, _, _, err := g.roomManager.FindPlayer(seat)
+	if err != nil || nsConn == nil || nsConn.Conn.IsClosed() {
+		return
+	}
+	if err = g.roomManager.SendBytes(nsConn, eventName, body); err != nil {
+		g.log.Wrn("sendToSeat", slog.String(".", err.Error()))
+	}
+}
+
+// GamePrivateAlertAsk 對手詢問叫品的意思, user.PlaySeat8 為叫者座位, user.Bid8 為叫品(同一叫者有相同叫品時詢問最後一個)
+func (g *Game) GamePrivateAlertAsk(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.askAlert(user); err != nil {
+		g.log.Wrn("GamePrivateAlertAsk", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+func (g *Game) askAlert(user *RoomUser) error {
+	switch g.Phase() {
+	case PhaseBidding, PhaseOpeningLead, PhasePlaying:
+	default:
+		return fmt.Errorf("%w: %s", ErrAlertCall, g.Phase())
+	}
+	asker := viewerSeat(user.NsConn)
+	if asker == valueNotSet {
+		return ErrUserNotInPlay
+	}
+
+	var item *bidItem
+	auction := g.engine.Auction()
+	for i := len(auction) - 1; i >= 0; i-- {
+		if auction[i].who() == user.PlaySeat8 && auction[i].bid() == user.Bid8 {
+			item = auction[i]
+			break
+		}
+	}
+	if item == nil {
+		return fmt.Errorf("%w: %s %s", ErrAlertCall, CbSeat(user.PlaySeat8), CbBid(user.Bid8))
+	}
+	if !isOpponent(asker, item.who()) {
+		return ErrAlertAsk
+	}
+
+	ask := &alertAsk{
+		AlertQuestion: AlertQuestion{AlertNotice: item.alertNotice(), Asker: asker},
+		board:         g.boardNumber,
+	}
+	g.alertAsks[asker] = ask
+	slog.Info("GamePrivateAlertAsk", slog.String(g.name, fmt.Sprintf("%s 詢問 %s %s", CbSeat(asker), CbSeat(item.who()), item.value)))
+
+	//叫者方都是機器人, 以叫牌時的說明自動回答
+	partner, _ := GetPartnerByPlayerSeat(item.who())
+	if g.roomManager.Robot(item.who()) != nil && g.roomManager.Robot(partner) != nil {
+		answer := item.explanation
+		if answer == "" {
+			answer = alertNoAgreement
+		}
+		return g.answerAlert(item.who(), asker, answer)
+	}
+
+	body, err := json.Marshal(ask.AlertQuestion)
+	if err != nil {
+		return err
+	}
+	g.sendToSeat(item.who(), ClnRoomEvents.GamePrivateAlertAsk, body)
+
+	//叫者的說明不能讓夥伴知道(未經授權的資訊), 送給夥伴的詢問不含說明
+	question := ask.AlertQuestion
+	question.Explanation = ""
+	if body, err = json.Marshal(question); err != nil {
+		return err
+	}
+	g.sendToSeat(partner, ClnRoomEvents.GamePrivateAlertAsk, body)
+	return nil
+}
+
+// GamePrivateAlertAnswer 叫者方回答詢問, user.PlaySeat8 為詢問者座位, 回答以Chat傳入
+func (g *Game) GamePrivateAlertAnswer(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var answer string
+	if user.Chat != nil {
+		answer = user.Chat.Msg
+	}
+	if err := g.answerAlert(viewerSeat(user.NsConn), user.PlaySeat8, answer); err != nil {
+		g.log.Wrn("GamePrivateAlertAnswer", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// answerAlert 叫者方(seat)回答詢問者(asker)的詢問, 回答私下送回詢問者, 必須在遊戲鎖(mu)內呼叫
+func (g *Game) answerAlert(seat, asker uint8, answer string) error {
+	ask, ok := g.alertAsks[asker]
+	if !ok || ask.board != g.boardNumber {
+		delete(g.alertAsks, asker)
+		return ErrAlertNone
+	}
+	if seat == valueNotSet || isOpponent(seat, ask.Bidder) {
+		return ErrAlertAnswer
+	}
+	delete(g.alertAsks, asker)
+
+	body, err := json.Marshal(AlertAnswer{AlertQuestion: ask.AlertQuestion, By: seat, Answer: answer})
+	if err != nil {
+		return err
+	}
+	g.sendToSeat(asker, ClnRoomEvents.GamePrivateAlertAnswer, body)
+	return nil
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -20,6 +21,9 @@ type (
 
 		dbType CbSuit //叫品屬於哪類Double(只限DOUBLE, REDOUBLE,預設值ZeroSuit表未設定)
 		b      uint8  //CbSeat | CbBid
+
+		alert       bool   //叫者警示(alert)此叫品為約定叫
+		explanation string //叫者對警示叫品的說明
 	}
 
 	bidHistory struct {
@@ -142,10 +146,71 @@ func (h *bidHistory) LastBid() uint8 {
 	return uint8(Pass1)
 }
 
-// Bid 叫牌,並且存入叫牌紀錄
-func (h *bidHistory) Bid(seat, bid uint8) *bidItem {
+// lastCrucial 最後一個不是PASS的叫品(包含Double, Redouble), 沒有回傳nil
+func (h *bidHistory) lastCrucial() *bidItem {
+	for idx := len(h.h) - 1; idx >= 0; idx-- {
+		if h.h[idx].isCrucial() {
+			return h.h[idx]
+		}
+	}
+	return nil
+}
 
-	//TODO check seat , bid valid
+// isOpponent 兩座位是否為敵對方
+func isOpponent(seat1, seat2 uint8) bool {
+	partner, _ := GetPartnerByPlayerSeat(seat1)
+	return seat1 != seat2 && partner != seat2
+}
+
+// validBid 檢查叫品是否合法: 合約叫品必須大於上一個合約叫品, Double只能賭倍敵方未被賭倍的合約, Redouble只能再賭倍敵方的Double
+func (h *bidHistory) validBid(seat, bid uint8) error {
+
+	if bid < uint8(Pass1) || bid > uint8(Db7x2) {
+		return fmt.Errorf("%w: %s 不知名叫品(%d)", ErrBiddingInvalid, CbSeat(seat), bid)
+	}
+
+	if bidComplete, _ := h.IsBidFinishedOrReBid(); bidComplete {
+		return fmt.Errorf("%w: 競叫已經結束", ErrBiddingInvalid)
+	}
+
+	b := createBidItem(seat, bid)
+
+	switch {
+	case b.isPass():
+		return nil
+
+	case b.dbType == DOUBLE:
+		last := h.lastCrucial()
+		if last == nil || last.isDouble() || !isOpponent(seat, last.who()) {
+			return fmt.Errorf("%w: %s 只能賭倍敵方未被賭倍的合約", ErrBiddingInvalid, CbSeat(seat))
+		}
+		if double, _ := GetDoubleAtSameLine(last.bid()); double != bid {
+			return fmt.Errorf("%w: %s 賭倍線位(%s)與合約(%s)不符", ErrBiddingInvalid, CbSeat(seat), CbBid(bid), last.value)
+		}
+
+	case b.dbType == REDOUBLE:
+		last := h.lastCrucial()
+		if last == nil || last.dbType != DOUBLE || !isOpponent(seat, last.who()) {
+			return fmt.Errorf("%w: %s 只能再賭倍敵方的賭倍", ErrBiddingInvalid, CbSeat(seat))
+		}
+		if _, redouble := GetDoubleAtSameLine(h.LastBid()); redouble != bid {
+			return fmt.Errorf("%w: %s 再賭倍線位(%s)與合約不符", ErrBiddingInvalid, CbSeat(seat), CbBid(bid))
+		}
+
+	default: /*合約叫品*/
+		if last := h.LastBid(); last != uint8(Pass1) && bid <= last {
+			return fmt.Errorf("%w: %s 叫品(%s)必須大於 %s", ErrBiddingInvalid, CbSeat(seat), CbBid(bid), CbBid(last))
+		}
+	}
+	return nil
+}
+
+// Bid 檢查叫品合法後叫牌,並且存入叫牌紀錄,不合法的叫品不會改變叫牌紀錄
+func (h *bidHistory) Bid(seat, bid uint8) (*bidItem, error) {
+
+	if err := h.validBid(seat, bid); err != nil {
+		return nil, err
+	}
 
 	b := createBidItem(seat, bid)
 	h.h = append(h.h, b)
@@ -161,6 +226,22 @@ func (h *bidHistory) Bid(seat, bid uint8) *bidItem {
 		//slog.Debug("Bid", slog.String("FYI", fmt.Sprintf("token(%03d) [%s0x%02x | 0x%02x %-10s] %s\n", token, CbSeat(seat), seat, cbSuit, CbSuit(cbSuit), b.t.Format("2006-01-02 15:04:05.000"))))
 		h.histories[token] = b.t
 	}
+	return b, nil
+}
+
+// undo 撤回最後一個叫品, 若該叫品是叫者首次叫該花色則一併移除叫約時間; 沒有叫品回傳nil
+func (h *bidHistory) undo() *bidItem {
+	l := len(h.h)
+	if l == 0 {
+		return nil
+	}
+	b := h.h[l-1]
+	h.h = h.h[:l-1]
+
+	token := b.who() | seatBiddingMapperSuit[b.b]
+	if t, ok := h.histories[token]; ok && t.Equal(b.t) {
+		delete(h.histories, token)
+	}
 	return b
 }
 
@@ -218,7 +299,10 @@ func (h *bidHistory) GameStartPlayInfo() (lead, declarer, dummy, contractSuit ui
 			//bid不是Double就是Contract
 			if contract.isDouble() {
 				biddingResult.isDouble = contract.isDouble()
-				biddingResult.dbType = contract.dbType
+				//由後往前找,最後出現的Double才是合約的賭倍種類(避免 Redouble 被前面的 Double 覆蓋)
+				if biddingResult.dbType == ZeroSuit {
+					biddingResult.dbType = contract.dbType
+				}
 				// ..................................
 			} else {
 				//找到有效叫品, contract 合約確定
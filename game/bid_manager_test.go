@@ -0,0 +1,134 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// auction 依序叫牌, 用於建立測試的競叫紀錄
+type auction []struct {
+	seat CbSeat
+	bid  CbBid
+}
+
+func (a auction) history(t *testing.T) *bidHistory {
+	t.Helper()
+	h := createBidHistory()
+	for _, b := range a {
+		if _, err := h.Bid(uint8(b.seat), uint8(b.bid)); err != nil {
+			t.Fatalf("Bid(%s, %s) error = %v", b.seat, b.bid, err)
+		}
+	}
+	return h
+}
+
+func TestValidBid(t *testing.T) {
+	tests := []struct {
+		name    string
+		auction auction
+		seat    CbSeat
+		bid     CbBid
+		valid   bool
+	}{
+		{"首叫", nil, south, H1, true},
+		{"首叫PASS", nil, south, Pass1, true},
+		{"叫品大於上一個叫品", auction{{south, H1}}, west, S1, true},
+		{"叫品小於上一個叫品", auction{{south, H2}}, west, S1, false},
+		{"叫品與上一個叫品相同", auction{{south, H2}}, west, H2, false},
+		{"賭倍敵方的合約", auction{{south, H1}}, west, Db1, true},
+		{"賭倍夥伴的合約", auction{{south, H1}, {west, Pass1}}, north, Db1, false},
+		{"賭倍線位與合約不符", auction{{south, H2}}, west, Db1, false},
+		{"賭倍已被賭倍的合約", auction{{south, H1}, {west, Db1}, {north, Pass1}}, east, Db1, false},
+		{"沒有合約不能賭倍", nil, south, Db1, false},
+		{"再賭倍敵方的賭倍", auction{{south, H1}, {west, Db1}}, north, Db1x2, true},
+		{"PASS後再賭倍敵方的賭倍", auction{{south, H1}, {west, Db1}, {north, Pass1}, {east, Pass1}}, south, Db1x2, true},
+		{"沒有賭倍不能再賭倍", auction{{south, H1}, {west, Pass1}}, north, Db1x2, false},
+		{"再賭倍夥伴的賭倍", auction{{south, H1}, {west, Db1}}, east, Db1x2, false},
+		{"賭倍後叫更高的合約", auction{{south, H1}, {west, Db1}}, north, S1, true},
+		{"競叫結束後叫牌", auction{{south, H1}, {west, Pass1}, {north, Pass1}, {east, Pass1}}, south, S1, false},
+		{"四家PASS後叫牌", auction{{south, Pass1}, {west, Pass1}, {north, Pass1}, {east, Pass1}}, south, C1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.auction.history(t)
+			err := h.validBid(uint8(tt.seat), uint8(tt.bid))
+			if tt.valid && err != nil {
+				t.Errorf("validBid(%s, %s) error = %v", tt.seat, tt.bid, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrBiddingInvalid) {
+				t.Errorf("validBid(%s, %s) error = %v, want %v", tt.seat, tt.bid, err, ErrBiddingInvalid)
+			}
+			//不合法的叫品不改變叫牌紀錄
+			if _, err := h.Bid(uint8(tt.seat), uint8(tt.bid)); err != nil && len(h.h) != len(tt.auction) {
+				t.Errorf("不合法叫品改變了叫牌紀錄 %d, want %d", len(h.h), len(tt.auction))
+			}
+		})
+	}
+}
+
+func TestBidUndo(t *testing.T) {
+	h := auction{{south, H1}, {west, Db1}}.history(t)
+
+	if b := h.undo(); b == nil || b.value != Db1 || b.who() != uint8(west) {
+		t.Fatalf("undo() = %v, want 西家 %s", b, Db1)
+	}
+	//撤回賭倍後不能再賭倍
+	if err := h.validBid(uint8(north), uint8(Db1x2)); !errors.Is(err, ErrBiddingInvalid) {
+		t.Errorf("撤回賭倍後再賭倍 error = %v, want %v", err, ErrBiddingInvalid)
+	}
+
+	//撤回首次叫該花色的叫品, 一併移除叫約時間
+	if b := h.undo(); b == nil || b.value != H1 {
+		t.Fatalf("undo() = %v, want 南家 %s", b, H1)
+	}
+	if len(h.h) != 0 || len(h.histories) != 0 {
+		t.Errorf("撤回後叫牌紀錄 %d, 叫約時間 %d, want 0, 0", len(h.h), len(h.histories))
+	}
+	if b := h.undo(); b != nil {
+		t.Errorf("沒有叫品 undo() = %v, want nil", b)
+	}
+
+	//撤回後改由夥伴叫該花色, 夥伴成為莊家
+	for _, b := range (auction{{south, Pass1}, {west, Pass1}, {north, H1}, {east, Pass1}, {south, Pass1}, {west, Pass1}}) {
+		if _, err := h.Bid(uint8(b.seat), uint8(b.bid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, declarer, _, _, _, err := h.GameStartPlayInfo(); err != nil || declarer != uint8(north) {
+		t.Errorf("GameStartPlayInfo() 莊家 %s, %v, want %s", CbSeat(declarer), err, north)
+	}
+}
+
+func TestGameStartPlayInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		auction auction
+		dbType  CbSuit
+	}{
+		{"未賭倍", auction{{south, H1}, {west, Pass1}, {north, Pass1}, {east, Pass1}}, ZeroSuit},
+		{"賭倍", auction{{south, H1}, {west, Db1}, {north, Pass1}, {east, Pass1}, {south, Pass1}}, DOUBLE},
+		{"再賭倍", auction{{south, H1}, {west, Db1}, {north, Db1x2}, {east, Pass1}, {south, Pass1}, {west, Pass1}}, REDOUBLE},
+		{"PASS後再賭倍", auction{{south, H1}, {west, Db1}, {north, Pass1}, {east, Pass1}, {south, Db1x2}, {west, Pass1}, {north, Pass1}, {east, Pass1}}, REDOUBLE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.auction.history(t)
+			lead, declarer, dummy, suit, result, err := h.GameStartPlayInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if declarer != uint8(south) || dummy != uint8(north) || lead != uint8(west) {
+				t.Errorf("莊 %s 夢 %s 首引 %s, want 南 北 西", CbSeat(declarer), CbSeat(dummy), CbSeat(lead))
+			}
+			if CbSuit(suit) != HEART || result.contract != H1 || result.dbType != tt.dbType {
+				t.Errorf("合約 %s %s %s, want %s %s %s", CbSuit(suit), result.contract, result.dbType, HEART, H1, tt.dbType)
+			}
+		})
+	}
+
+	//四家PASS沒有合約
+	h := auction{{south, Pass1}, {west, Pass1}, {north, Pass1}, {east, Pass1}}.history(t)
+	if _, _, _, _, _, err := h.GameStartPlayInfo(); err == nil {
+		t.Error("四家PASS GameStartPlayInfo() error = nil")
+	}
+}
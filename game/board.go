@@ -0,0 +1,79 @@
+package game
+
+// 牌局編號(Board), 依標準牌套編號決定發牌者(開叫者)與身價, 每16副為一循環
+
+// Vulnerability 身價
+type Vulnerability uint8
+
+const (
+	VulNone Vulnerability = iota //雙方無身價
+	VulNS                        //南北有身價
+	VulEW                        //東西有身價
+	VulAll                       //雙方有身價
+)
+
+func (v Vulnerability) String() string {
+	switch v {
+	case VulNone:
+		return "None"
+	case VulNS:
+		return "NS"
+	case VulEW:
+		return "EW"
+	case VulAll:
+		return "All"
+	}
+	return "Vulnerability(?)"
+}
+
+// IsVulnerable 指定座位是否有身價
+func (v Vulnerability) IsVulnerable(seat CbSeat) bool {
+	switch v {
+	case VulAll:
+		return true
+	case VulNS:
+		return seat == north || seat == south
+	case VulEW:
+		return seat == east || seat == west
+	}
+	return false
+}
+
+var (
+	// 牌號1~4發牌者依序為 北,東,南,西
+	boardDealers = [4]CbSeat{north, east, south, west}
+
+	// 牌號1~16身價
+	boardVulnerabilities = [16]Vulnerability{
+		VulNone, VulNS, VulEW, VulAll,
+		VulNS, VulEW, VulAll, VulNone,
+		VulEW, VulAll, VulNone, VulNS,
+		VulAll, VulNone, VulNS, VulEW,
+	}
+)
+
+// Board 牌局編號資訊, 發牌者與身價由牌號決定
+type Board struct {
+	Number           uint32        `json:"number"`           //牌號,從1開始
+	Dealer           uint8         `json:"dealer"`           //發牌者(開叫者)
+	Vulnerable       Vulnerability `json:"vulnerable"`       //身價
+	VulnerableString string        `json:"vulnerableString"` //身價字串
+
+	seed string //發牌種子(DealFromSeed可以重新產生該副牌), 不送給前端以免洩漏手牌
+}
+
+// Seed 該副牌的發牌種子, 匯入的牌局沒有種子
+func (b Board) Seed() string {
+	return b.seed
+}
+
+// newBoard 以牌號(從1開始)產生牌局資訊
+func newBoard(number uint32) Board {
+	vul := boardVulnerabilities[(number-1)%16]
+	return Board{
+		Number:           number,
+		Dealer:           uint8(boardDealers[(number-1)%4]),
+		Vulnerable:       vul,
+		VulnerableString: vul.String(),
+	}
+}
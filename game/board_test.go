@@ -0,0 +1,75 @@
+package game
+
+import "testing"
+
+func TestNewBoard(t *testing.T) {
+	tests := []struct {
+		number uint32
+		dealer CbSeat
+		vul    Vulnerability
+	}{
+		{1, north, VulNone},
+		{2, east, VulNS},
+		{3, south, VulEW},
+		{4, west, VulAll},
+		{5, north, VulNS},
+		{8, west, VulNone},
+		{9, north, VulEW},
+		{13, north, VulAll},
+		{16, west, VulEW},
+		//每16副為一循環
+		{17, north, VulNone},
+		{20, west, VulAll},
+	}
+	for _, tt := range tests {
+		b := newBoard(tt.number)
+		if b.Number != tt.number || CbSeat(b.Dealer) != tt.dealer || b.Vulnerable != tt.vul || b.VulnerableString != tt.vul.String() {
+			t.Errorf("newBoard(%d) = %s %s, want %s %s", tt.number, CbSeat(b.Dealer), b.VulnerableString, tt.dealer, tt.vul)
+		}
+	}
+}
+
+func TestVulnerability(t *testing.T) {
+	for _, tt := range []struct {
+		vul    Vulnerability
+		ns, ew bool
+	}{{VulNone, false, false}, {VulNS, true, false}, {VulEW, false, true}, {VulAll, true, true}} {
+		for _, seat := range playerSeats {
+			want := tt.ew
+			if CbSeat(seat) == north || CbSeat(seat) == south {
+				want = tt.ns
+			}
+			if got := tt.vul.IsVulnerable(CbSeat(seat)); got != want {
+				t.Errorf("%s IsVulnerable(%s) = %t, want %t", tt.vul, CbSeat(seat), got, want)
+			}
+		}
+	}
+}
+
+func TestBoardContractVulnerable(t *testing.T) {
+	//牌號決定首叫者, 合約依莊家方身價計分
+	tests := []struct {
+		number     uint32
+		auction    auction
+		vulnerable bool
+	}{
+		{2, auction{{east, H1}, {south, Pass1}, {west, Pass1}, {north, Pass1}}, false},
+		{3, auction{{south, H1}, {west, Pass1}, {north, Pass1}, {east, Pass1}}, false},
+		{5, auction{{north, H1}, {east, Pass1}, {south, Pass1}, {west, Pass1}}, true},
+		{16, auction{{west, H1}, {north, Pass1}, {east, Pass1}, {south, Pass1}}, true},
+	}
+	for _, tt := range tests {
+		egn := newEngine()
+		if bidder := egn.StartBid(newBoard(tt.number)); bidder != uint8(tt.auction[0].seat) {
+			t.Fatalf("牌號%d StartBid() = %s, want %s", tt.number, CbSeat(bidder), tt.auction[0].seat)
+		}
+		egn.bidHistory = tt.auction.history(t)
+		_, declarer, _, _, contract, err := egn.GameStartPlayInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if declarer != uint8(tt.auction[0].seat) || contract.vulnerable != tt.vulnerable {
+			t.Errorf("牌號%d 莊家 %s 有身價 %t, want %s %t", tt.number, CbSeat(declarer), contract.vulnerable, tt.auction[0].seat, tt.vulnerable)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// 攤牌宣告(claim)與認輸(concede): 出牌中莊家宣告剩餘墩中可以吃到的墩數, 或防家認輸(宣告防家方只再吃到的墩數),
+// 宣告後暫停出牌與計時, 等待對手回覆(莊家宣告由兩位防家同意, 防家認輸由莊家同意);
+// 對手都同意時以宣告的墩數立即結算, 任一對手拒絕時繼續出牌.
+// 同時以雙明手分析剩餘的牌, 宣告墩數超過雙明手最佳打法的墩數時標示為可疑宣告, 機器人對手依此同意或拒絕
+
+// ClaimStatus 攤牌宣告狀態
+type ClaimStatus uint8
+
+const (
+	ClaimPending  ClaimStatus = iota //等待對手回覆
+	ClaimAccepted                    //對手都同意,結算
+	ClaimRejected                    //對手拒絕,繼續出牌
+)
+
+func (s ClaimStatus) String() string {
+	switch s {
+	case ClaimPending:
+		return "Pending"
+	case ClaimAccepted:
+		return "Accepted"
+	case ClaimRejected:
+		return "Rejected"
+	}
+	return fmt.Sprintf("ClaimStatus(%d)", uint8(s))
+}
+
+type (
+	// ClaimNotice 攤牌宣告與回覆狀態
+	ClaimNotice struct {
+		HandClaim
+		Status       ClaimStatus `json:"status"`
+		StatusString string      `json:"statusString"`
+		Remaining    uint8       `json:"remaining"` //宣告時剩餘墩數
+		Awaiting     []uint8     `json:"awaiting"`  //尚未同意的對手座位(CbSeat)
+		By           uint8       `json:"by"`        //這次動作的座位(宣告,同意或拒絕)
+	}
+
+	// ClaimCheck 攤牌宣告的雙明手檢查
+	ClaimCheck struct {
+		HandClaim
+		DoubleDummy uint8 `json:"doubleDummy"` //雙明手最佳打法下宣告者方在剩餘墩中可以吃到的墩數
+		Dubious     bool  `json:"dubious"`     //宣告墩數超過雙明手墩數
+	}
+
+	// pendingClaim 待回覆的攤牌宣告
+	pendingClaim struct {
+		HandClaim
+		remaining uint8
+		replies   map[uint8]bool //須回覆的對手座位->是否已同意
+	}
+)
+
+// GamePrivateClaim 莊家宣告攤牌或防家認輸, user.Play8 為宣告者方在剩餘墩中吃到的墩數
+func (g *Game) GamePrivateClaim(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.claimTricks(viewerSeat(user.NsConn), user.Play8); err != nil {
+		g.log.Wrn("GamePrivateClaim", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// claimTricks 座位(seat)宣告己方在剩餘墩中吃到的墩數(tricks), 必須在遊戲鎖(mu)內呼叫
+func (g *Game) claimTricks(seat, tricks uint8) error {
+	if err := g.inPhase(PhasePlaying); err != nil {
+		return err
+	}
+	if seat == valueNotSet {
+		return ErrUserNotInPlay
+	}
+	if seat == uint8(g.Dummy) {
+		return ErrClaimSeat
+	}
+	if g.claim.Load() != nil {
+		return ErrClaimPending
+	}
+	if g.undo.Load() != nil {
+		return ErrUndoPending
+	}
+	remaining := uint8(NumOfCardsOnePlayer) - g.engine.ledger.played()
+	if tricks > remaining {
+		return fmt.Errorf("%w: 宣告%d墩,剩餘%d墩", ErrClaimTricks, tricks, remaining)
+	}
+
+	claim := &pendingClaim{
+		HandClaim: HandClaim{Seat: seat, Tricks: tricks, Concede: seat != uint8(g.Declarer)},
+		remaining: remaining,
+		replies:   make(map[uint8]bool, 2),
+	}
+	//莊家宣告由兩位防家同意, 防家認輸由莊家同意
+	for _, opponent := range playerSeats {
+		switch opponent {
+		case uint8(g.Declarer):
+			if claim.Concede {
+				claim.replies[opponent] = false
+			}
+		case uint8(g.Dummy):
+		default:
+			if !claim.Concede {
+				claim.replies[opponent] = false
+			}
+		}
+	}
+
+	g.claim.Store(claim)
+	g.pauseTurnTimer()
+	slog.Info("GamePrivateClaim", slog.String(g.name, fmt.Sprintf("%s 宣告剩餘%d墩中吃%d墩(認輸:%t)", CbSeat(seat), remaining, claim.Tricks, claim.Concede)))
+	g.sendClaimNotice(claim, ClaimPending, seat)
+
+	//雙明手檢查需要時間, 不在遊戲鎖內進行
+	go g.checkClaim(claim, g.ddPosition())
+	return nil
+}
+
+// GamePrivateClaimAccept 對手同意攤牌宣告
+func (g *Game) GamePrivateClaimAccept(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.replyClaim(viewerSeat(user.NsConn), true); err != nil {
+		g.log.Wrn("GamePrivateClaimAccept", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// GamePrivateClaimReject 對手拒絕攤牌宣告, 繼續出牌
+func (g *Game) GamePrivateClaimReject(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.replyClaim(viewerSeat(user.NsConn), false); err != nil {
+		g.log.Wrn("GamePrivateClaimReject", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// replyClaim 對手(seat)回覆攤牌宣告, 必須在遊戲鎖(mu)內呼叫
+func (g *Game) replyClaim(seat uint8, accept bool) error {
+	claim := g.claim.Load()
+	if claim == nil {
+		return ErrClaimNone
+	}
+	if _, ok := claim.replies[seat]; !ok {
+		return ErrClaimReply
+	}
+
+	if !accept {
+		g.claim.Store(nil)
+		g.sendClaimNotice(claim, ClaimRejected, seat)
+		g.resumeTurnTimer()
+		return nil
+	}
+
+	claim.replies[seat] = true
+	for _, accepted := range claim.replies {
+		if !accepted {
+			g.sendClaimNotice(claim, ClaimPending, seat)
+			return nil
+		}
+	}
+
+	//對手都同意, 取消目前輪次並結束暫停後結算
+	g.claim.Store(nil)
+	g.stopTurnTimer()
+	g.paused.Add(-1)
+	g.sendClaimNotice(claim, ClaimAccepted, seat)
+	g.settleClaim(claim)
+	return nil
+}
+
+// settleClaim 以宣告的墩數結算
+func (g *Game) settleClaim(claim *pendingClaim) {
+	if err := g.transit(PhaseSettling); err != nil {
+		g.log.Wrn("settleClaim", slog.String(".", err.Error()))
+		return
+	}
+
+	tricks := g.engine.declarerTricks()
+	if claim.Concede {
+		tricks += claim.remaining - claim.Tricks
+	} else {
+		tricks += claim.Tricks
+	}
+	g.settle(scoring(g.engine.declarer, g.engine.contract, tricks, g.engine.contract.vulnerable), &claim.HandClaim)
+}
+
+// checkClaim 雙明手分析宣告時的局面(position), 廣播檢查結果; 機器人對手在宣告不可疑時同意,否則(或分析逾時)拒絕
+func (g *Game) checkClaim(claim *pendingClaim, position DDPosition) {
+	ctx, cancel := context.WithTimeout(g.roomManager.shutdown, claimCheckTimeout)
+	defer cancel()
+
+	var (
+		tricks uint8
+		err    error
+	)
+	select {
+	case claimCheckSlots <- struct{}{}:
+		tricks, err = position.Tricks(ctx)
+		<-claimCheckSlots
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		//無法確認宣告, 不送出檢查結果, 機器人對手拒絕
+		g.log.Wrn("checkClaim", slog.String(".", err.Error()))
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.claim.Load() != claim {
+			return
+		}
+		g.robotsReplyClaim(claim, false)
+		return
+	}
+
+	//Tricks 是輪到出牌一方的墩數, 換算成宣告者方
+	toPlay := (position.Leader + uint8(len(position.Trick))) % 4
+	if toPlay%2 != seatIndex(claim.Seat)%2 {
+		tricks = claim.remaining - tricks
+	}
+	check := ClaimCheck{
+		HandClaim:   claim.HandClaim,
+		DoubleDummy: tricks,
+		Dubious:     claim.Tricks > tricks,
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	//宣告已經回覆或已進入下一局
+	if g.claim.Load() != claim {
+		return
+	}
+
+	body, err := json.Marshal(check)
+	if err != nil {
+		g.log.Wrn("checkClaim", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameClaimCheck, g.name, body)
+
+	g.robotsReplyClaim(claim, !check.Dubious)
+}
+
+// robotsReplyClaim 機器人對手回覆攤牌宣告(accept); 必須持有遊戲鎖(mu)
+func (g *Game) robotsReplyClaim(claim *pendingClaim, accept bool) {
+	for seat := range claim.replies {
+		if g.roomManager.Robot(seat) == nil {
+			continue
+		}
+		if err := g.replyClaim(seat, accept); err != nil || g.claim.Load() != claim {
+			return
+		}
+	}
+}
+
+// clearClaim 新的一局開始時取消尚未回覆的攤牌宣告與其暫停計時
+func (g *Game) clearClaim() {
+	if g.claim.Swap(nil) != nil {
+		g.paused.Add(-1)
+	}
+}
+
+// sendClaimNotice 廣播攤牌宣告狀態給房間所有人(玩家,觀眾)
+func (g *Game) sendClaimNotice(claim *pendingClaim, status ClaimStatus, by uint8) {
+	notice := ClaimNotice{
+		HandClaim:    claim.HandClaim,
+		Status:       status,
+		StatusString: status.String(),
+		Remaining:    claim.remaining,
+		Awaiting:     make([]uint8, 0, len(claim.replies)),
+		By:           by,
+	}
+	for _, seat := range playerSeats {
+		if accepted, ok := claim.replies[seat]; ok && !accepted && status == ClaimPending {
+			notice.Awaiting = append(notice.Awaiting, seat)
+		}
+	}
+
+	body, err := json.Marshal(notice)
+	if err != nil {
+		g.log.Wrn("sendClaimNotice", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameClaim, g.name, body)
+}
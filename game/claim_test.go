@@ -0,0 +1,194 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// endGame 南家3NT(北家夢家)已打完11墩(莊家方吃8墩)的殘局, 輪到座位(lead)首打:
+// 北 ♠AK, 東 ♠T9, 南 ♥87, 西 ♥KQ; 北家首打莊家方吃2墩, 南家首打莊家方吃0墩
+func endGame(t *testing.T, lead CbSeat) *Game {
+	t.Helper()
+	g := playingGame(t)
+	g.KingSuit = TRUMP
+	g.engine.declarer, g.engine.dummy = south, north
+	g.engine.contract = record{contract: NT3, dbType: ZeroSuit}
+
+	for seat, cards := range map[CbSeat][2]uint8{north: {spadeK, spadeAce}, east: {spade9, spade10}, south: {heart7, heart8}, west: {heartQ, heartK}} {
+		hand := g.deckInPlay[uint8(seat)]
+		*hand = [NumOfCardsOnePlayer]uint8{}
+		hand[0], hand[1] = cards[0], cards[1]
+	}
+	for i := 0; i < NumOfCardsOnePlayer-2; i++ {
+		winner := south
+		if i >= 8 {
+			winner = west
+		}
+		g.engine.ledger.record(uint8(winner), uint8(winner), 0, 0, 0, 0)
+	}
+	g.countingInPlayCard = uint8(NumOfCardsOnePlayer-2) * 4
+	g.engine.currentPlay = uint8(lead)
+	return g
+}
+
+// lockedClaim 在遊戲鎖內宣告(雙明手檢查同時在背景進行)
+func lockedClaim(g *Game, seat CbSeat, tricks uint8) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.claimTricks(uint8(seat), tricks)
+}
+
+// lockedReply 在遊戲鎖內回覆攤牌宣告
+func lockedReply(g *Game, seat CbSeat, accept bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.replyClaim(uint8(seat), accept)
+}
+
+// waitClaim 等待攤牌宣告被回覆(機器人在雙明手檢查後回覆)
+func waitClaim(t *testing.T, g *Game) {
+	t.Helper()
+	for deadline := time.Now().Add(claimCheckTimeout + time.Second); ; time.Sleep(10 * time.Millisecond) {
+		g.mu.Lock()
+		done := g.claim.Load() == nil
+		g.mu.Unlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("攤牌宣告沒有被回覆")
+		}
+	}
+}
+
+// settledHand 結算後的牌局紀錄
+func settledHand(t *testing.T, g *Game) *HandRecord {
+	t.Helper()
+	hand := g.lastHand.Load()
+	if g.Phase() != PhaseSettling || hand == nil || hand.Claim == nil {
+		t.Fatalf("階段 %s 牌局紀錄 %v, want 以攤牌宣告結算", g.Phase(), hand)
+	}
+	return hand
+}
+
+func TestClaimAccepted(t *testing.T) {
+	g := endGame(t, north)
+	if err := lockedClaim(g, south, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockedReply(g, north, true); !errors.Is(err, ErrClaimReply) {
+		t.Errorf("夢家回覆 error = %v, want %v", err, ErrClaimReply)
+	}
+	if err := lockedReply(g, west, true); err != nil || g.claim.Load() == nil {
+		t.Fatalf("西家同意後 error = %v, claim %v, want 等待東家", err, g.claim.Load())
+	}
+	if err := lockedReply(g, east, true); err != nil {
+		t.Fatal(err)
+	}
+
+	//已吃8墩加上宣告的2墩, 3NT+1
+	hand := settledHand(t, g)
+	if hand.Claim.Concede || hand.Claim.Tricks != 2 || hand.Result.Tricks != 10 || hand.Result.Score != 430 {
+		t.Errorf("宣告 %+v 結果 %d墩 %d分, want 2墩, 10墩 430分", *hand.Claim, hand.Result.Tricks, hand.Result.Score)
+	}
+	if g.paused.Load() != 0 {
+		t.Errorf("結算後暫停 %d, want 0", g.paused.Load())
+	}
+}
+
+func TestClaimConcede(t *testing.T) {
+	//防家認輸只再吃0墩, 由莊家同意
+	g := endGame(t, south)
+	if err := lockedClaim(g, west, 0); err != nil {
+		t.Fatal(err)
+	}
+	if claim := g.claim.Load(); claim == nil || !claim.Concede || len(claim.replies) != 1 {
+		t.Fatalf("認輸 %v, want 等待莊家回覆", claim)
+	}
+	if err := lockedReply(g, east, true); !errors.Is(err, ErrClaimReply) {
+		t.Errorf("夥伴回覆 error = %v, want %v", err, ErrClaimReply)
+	}
+	if err := lockedReply(g, south, true); err != nil {
+		t.Fatal(err)
+	}
+	if hand := settledHand(t, g); hand.Result.Tricks != 10 {
+		t.Errorf("認輸後莊家 %d墩, want 10", hand.Result.Tricks)
+	}
+}
+
+func TestClaimRejected(t *testing.T) {
+	g := endGame(t, south)
+	if err := lockedClaim(g, south, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockedClaim(g, south, 1); !errors.Is(err, ErrClaimPending) {
+		t.Errorf("重複宣告 error = %v, want %v", err, ErrClaimPending)
+	}
+
+	//任一防家拒絕就繼續出牌
+	if err := lockedReply(g, east, false); err != nil {
+		t.Fatal(err)
+	}
+	if g.claim.Load() != nil || g.Phase() != PhasePlaying || g.paused.Load() != 0 {
+		t.Errorf("拒絕後 claim %v 階段 %s 暫停 %d, want nil, %s, 0", g.claim.Load(), g.Phase(), g.paused.Load(), PhasePlaying)
+	}
+	if err := lockedReply(g, west, true); !errors.Is(err, ErrClaimNone) {
+		t.Errorf("沒有宣告時回覆 error = %v, want %v", err, ErrClaimNone)
+	}
+}
+
+func TestClaimInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(g *Game)
+		seat   CbSeat
+		tricks uint8
+		want   error
+	}{
+		{"夢家宣告", func(g *Game) {}, north, 2, ErrClaimSeat},
+		{"不在座位上", func(g *Game) {}, CbSeat(valueNotSet), 2, ErrUserNotInPlay},
+		{"宣告墩數超過剩餘墩數", func(g *Game) {}, south, 3, ErrClaimTricks},
+		{"撤回請求等待回覆中", func(g *Game) { g.undo.Store(&pendingUndo{}) }, south, 2, ErrUndoPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := endGame(t, north)
+			tt.setup(g)
+			if err := lockedClaim(g, tt.seat, tt.tricks); !errors.Is(err, tt.want) {
+				t.Errorf("claimTricks(%s, %d) error = %v, want %v", tt.seat, tt.tricks, err, tt.want)
+			}
+			if g.claim.Load() != nil || g.paused.Load() != 0 {
+				t.Errorf("宣告失敗後 claim %v 暫停 %d, want nil, 0", g.claim.Load(), g.paused.Load())
+			}
+		})
+	}
+}
+
+func TestClaimRobotReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		lead  CbSeat
+		phase GamePhase
+	}{
+		{"雙明手可以吃到宣告墩數,同意", north, PhaseSettling},
+		{"可疑宣告,拒絕", south, PhasePlaying},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := endGame(t, tt.lead)
+			for _, seat := range []CbSeat{west, east} {
+				if err := g.roomManager.AddRobot(uint8(seat), NewRuleRobot()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := lockedClaim(g, south, 2); err != nil {
+				t.Fatal(err)
+			}
+			waitClaim(t, g)
+			if g.Phase() != tt.phase || g.paused.Load() != 0 {
+				t.Errorf("機器人回覆後階段 %s 暫停 %d, want %s, 0", g.Phase(), g.paused.Load(), tt.phase)
+			}
+		})
+	}
+}
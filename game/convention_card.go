@@ -0,0 +1,183 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// 約定卡(ConventionCard): 入座玩家為自己的配對登記制度摘要,開叫要求,1NT點力範圍,首引與信號,約定叫清單;
+// 約定卡以玩家名稱(RoomUser.Name)保存, 登記後廣播給房間所有人(對手與觀眾), 中途進入房間的使用者另外送出目前的約定卡;
+// 有人離座(或座位由機器人接手)後, 登記者已不在座位上或夥伴已換人的約定卡隨之清除.
+// 機器人(RobotView)與提示功能以 ConventionCard 讀取
+
+const (
+	conventionTextLimit  = 200 //約定卡文字欄位長度上限(字數)
+	conventionCountLimit = 50  //約定叫數量上限
+	notrumpPointsLimit   = 37  //點力上限
+)
+
+type (
+	// ConventionCard 配對的約定卡
+	ConventionCard struct {
+		Players     [2]string `json:"players"`     //登記時的配對玩家(登記者,夥伴), 由伺服器設定
+		System      string    `json:"system"`      //制度摘要, 例如 2/1, Precision
+		Opening     string    `json:"opening"`     //開叫要求
+		NotrumpMin  uint8     `json:"notrumpMin"`  //1NT開叫點力下限
+		NotrumpMax  uint8     `json:"notrumpMax"`  //1NT開叫點力上限
+		Carding     string    `json:"carding"`     //首引與信號
+		Conventions []string  `json:"conventions"` //約定叫
+	}
+
+	// ConventionCardRequest 入座玩家登記約定卡的請求(TablePrivateConventionCard), pb.PlayingUser沒有約定卡欄位所以以json送出
+	ConventionCardRequest struct {
+		RoomRequest
+		ConventionCard
+	}
+
+	// ConventionCardNotice 一方(南北或東西)的約定卡
+	ConventionCardNotice struct {
+		Side  string          `json:"side"`  //NS 或 EW
+		Seats [2]uint8        `json:"seats"` //登記者座位,夥伴座位
+		Card  *ConventionCard `json:"card"`
+	}
+)
+
+// validate 檢查約定卡內容
+func (c *ConventionCard) validate() error {
+	if c.NotrumpMin > c.NotrumpMax || c.NotrumpMax > notrumpPointsLimit {
+		return fmt.Errorf("%w: 1NT點力範圍 %d~%d", ErrConventionCard, c.NotrumpMin, c.NotrumpMax)
+	}
+	if len(c.Conventions) > conventionCountLimit {
+		return fmt.Errorf("%w: 約定叫超過%d個", ErrConventionCard, conventionCountLimit)
+	}
+	for _, text := range append([]string{c.System, c.Opening, c.Carding}, c.Conventions...) {
+		if utf8.RuneCountInString(text) > conventionTextLimit {
+			return fmt.Errorf("%w: 文字超過%d字", ErrConventionCard, conventionTextLimit)
+		}
+	}
+	return nil
+}
+
+// side 座位所屬的一方
+func side(seat uint8) string {
+	if seatIndex(seat)%2 == 1 {
+		return "NS"
+	}
+	return "EW"
+}
+
+// RegisterConventionCard 入座玩家(user)登記自己配對的約定卡(user.ConventionCard)
+func (g *Game) RegisterConventionCard(user *RoomUser) {
+	if err := g.registerConventionCard(user); err != nil {
+		g.log.Wrn("RegisterConventionCard", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+func (g *Game) registerConventionCard(user *RoomUser) error {
+	seat := viewerSeat(user.NsConn)
+	if seat == valueNotSet {
+		return ErrUserNotInPlay
+	}
+	card := user.ConventionCard
+	if card == nil {
+		return ErrConventionCard
+	}
+	if err := card.validate(); err != nil {
+		return err
+	}
+
+	partner, _ := GetPartnerByPlayerSeat(seat)
+	_, name, _, _, err := g.roomManager.FindPlayer(seat)
+	if err != nil {
+		return err
+	}
+	_, partnerName, _, _, _ := g.roomManager.FindPlayer(partner)
+	user.Name = name
+	card.Players = [2]string{name, partnerName}
+
+	g.cardsMu.Lock()
+	g.conventionCards[name] = card
+	g.cardsMu.Unlock()
+
+	slog.Info("RegisterConventionCard", slog.String(g.name, fmt.Sprintf("%s(%s) 登記約定卡 %s", name, CbSeat(seat), card.System)))
+	g.sendConventionCard(nil, seat, partner, card)
+	return nil
+}
+
+// ConventionCard 座位(seat)所屬配對的約定卡: 該座位玩家登記的約定卡, 否則為夥伴登記的約定卡, 都沒有登記回傳nil
+func (g *Game) ConventionCard(seat uint8) *ConventionCard {
+	var (
+		seated = g.roomManager.seatedNames()
+		idx    = seatIndex(seat)
+	)
+	g.cardsMu.RLock()
+	defer g.cardsMu.RUnlock()
+	for _, i := range []uint8{idx, (idx + 2) % 4} {
+		if card, ok := g.conventionCards[seated[i]]; ok && pairSeated(card, seated) {
+			return card
+		}
+	}
+	return nil
+}
+
+// pairSeated 約定卡登記的配對是否仍在座位上(seated 為東,南,西,北座位上的玩家名稱): 登記者在座位上,
+// 夥伴仍是登記時的夥伴, 登記時夥伴座位空著則不比對夥伴
+func pairSeated(card *ConventionCard, seated [4]string) bool {
+	if card.Players[0] == "" {
+		return false
+	}
+	for idx, name := range seated {
+		if name == card.Players[0] {
+			return card.Players[1] == "" || card.Players[1] == seated[(idx+2)%4]
+		}
+	}
+	return false
+}
+
+// clearConventionCards 有人離座(或座位由機器人接手)後, 清除登記者已不在座位上或夥伴已換人的約定卡
+func (g *Game) clearConventionCards() {
+	seated := g.roomManager.seatedNames()
+	g.cardsMu.Lock()
+	defer g.cardsMu.Unlock()
+	for name, card := range g.conventionCards {
+		if !pairSeated(card, seated) {
+			delete(g.conventionCards, name)
+		}
+	}
+}
+
+// sendConventionCards 送出目前雙方的約定卡給進入房間的使用者
+func (g *Game) sendConventionCards(user *RoomUser) {
+	if user.NsConn == nil || user.NsConn.Conn.IsClosed() {
+		return
+	}
+	for _, seat := range []uint8{uint8(south), uint8(east)} {
+		if card := g.ConventionCard(seat); card != nil {
+			partner, _ := GetPartnerByPlayerSeat(seat)
+			g.sendConventionCard(user, seat, partner, card)
+		}
+	}
+}
+
+// sendConventionCard 送出一方的約定卡, user為nil時廣播給房間所有人(玩家,觀眾)
+func (g *Game) sendConventionCard(user *RoomUser, seat, partner uint8, card *ConventionCard) {
+	body, err := json.Marshal(ConventionCardNotice{
+		Side:  side(seat),
+		Seats: [2]uint8{seat, partner},
+		Card:  card,
+	})
+	if err != nil {
+		g.log.Wrn("sendConventionCard", slog.String(".", err.Error()))
+		return
+	}
+	if user == nil {
+		g.roomManager.BroadcastBytes(nil, ClnRoomEvents.TableConventionCard, g.name, body)
+		return
+	}
+	if err = g.roomManager.SendBytes(user.NsConn, ClnRoomEvents.TableConventionCard, body); err != nil {
+		g.log.Wrn("sendConventionCard", slog.String(".", err.Error()))
+	}
+}
@@ -0,0 +1,45 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPairSeated(t *testing.T) {
+	seated := [4]string{"east", "south", "", "north"} //東,南,西,北
+
+	tests := []struct {
+		name    string
+		players [2]string
+		want    bool
+	}{
+		{"配對仍在座位上", [2]string{"south", "north"}, true},
+		{"夥伴登記的約定卡", [2]string{"north", "south"}, true},
+		{"登記時夥伴座位空著", [2]string{"east", ""}, true},
+		{"夥伴已離座", [2]string{"east", "west"}, false},
+		{"夥伴已換人", [2]string{"south", "someone"}, false},
+		{"登記者已離座", [2]string{"west", "east"}, false},
+		{"沒有登記者", [2]string{"", ""}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pairSeated(&ConventionCard{Players: tt.players}, seated); got != tt.want {
+				t.Errorf("pairSeated(%v) = %t, want %t", tt.players, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConventionCardRequest(t *testing.T) {
+	body := `{"name":"south","zone":64,"system":"2/1","notrumpMin":15,"notrumpMax":17,"conventions":["Stayman"]}`
+	req := new(ConventionCardRequest)
+	if err := json.Unmarshal([]byte(body), req); err != nil {
+		t.Fatal(err)
+	}
+	if u := req.PlayingUser(); u.Name != "south" || u.Zone != uint32(south) {
+		t.Errorf("PlayingUser() = %s(%d), want south(%d)", u.Name, u.Zone, south)
+	}
+	if req.System != "2/1" || req.NotrumpMin != 15 || req.NotrumpMax != 17 || len(req.Conventions) != 1 {
+		t.Errorf("ConventionCard = %+v", req.ConventionCard)
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// 牌局分析: 結算後以雙明手分析本局可以做成的合約與最佳合約, 與實際結果一起顯示;
+// 出牌中的局面分析(剩餘墩數)供攤牌宣告檢查使用
+
+const (
+	doubleDummyTimeout = 30 * time.Second //整副牌分析期限, 逾時不送出分析結果
+	claimCheckTimeout  = 5 * time.Second  //攤牌宣告檢查期限, 逾時視為無法確認
+)
+
+var (
+	// doubleDummySlots 同時進行整副牌雙明手分析的牌局數, 分析一副牌視牌型需要約1~30秒CPU時間(5個花色並行), 避免多個房間同時結算時佔滿CPU
+	doubleDummySlots = make(chan struct{}, 2)
+	// claimCheckSlots 同時進行攤牌宣告檢查的牌局數, 與結算分析分開, 宣告檢查不必等待結算分析
+	claimCheckSlots = make(chan struct{}, 2)
+)
+
+// DoubleDummyResult 該局雙明手分析結果
+type DoubleDummyResult struct {
+	Board    Board              `json:"board"`
+	Table    DDTable            `json:"table"`
+	Makeable []MakeableContract `json:"makeable"`
+	Par      *GameResult        `json:"par,omitempty"` //雙方都做不成任何合約時為nil
+}
+
+// dealtHands 本局發牌時各家的牌(東,南,西,北), g.Deck 到下一局洗牌前都不會改變
+func (g *Game) dealtHands() (hands [4][]uint8) {
+	for idx := range playerSeats {
+		for _, card := range g.Deck[&playerSeats[idx]] {
+			hands[idx] = append(hands[idx], *card)
+		}
+	}
+	return
+}
+
+// sendDoubleDummy 分析本局(hands,board), 廣播可以做成的合約與最佳合約給房間所有人(玩家,觀眾)
+func (g *Game) sendDoubleDummy(hands [4][]uint8, board Board) {
+	ctx, cancel := context.WithTimeout(g.roomManager.shutdown, doubleDummyTimeout)
+	defer cancel()
+
+	var (
+		table DDTable
+		err   = ctx.Err()
+	)
+	select {
+	case doubleDummySlots <- struct{}{}:
+		table, err = SolveDeal(ctx, hands)
+		<-doubleDummySlots
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		g.log.Wrn("sendDoubleDummy", slog.Uint64("牌號", uint64(board.Number)), slog.String(".", err.Error()))
+		return
+	}
+
+	result := DoubleDummyResult{
+		Board:    board,
+		Table:    table,
+		Makeable: table.Makeable(),
+		Par:      table.Par(board.Vulnerable),
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		g.log.Wrn("sendDoubleDummy", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameDoubleDummy, g.name, body)
+}
+
+// ddPosition 出牌中目前的雙明手局面
+func (g *Game) ddPosition() DDPosition {
+	var (
+		position = DDPosition{Strain: uint8(g.KingSuit)}
+		played   = g.countingInPlayCard % 4
+		table    = [4]uint8{g.eastCard, g.southCard, g.westCard, g.northCard}
+	)
+	for idx, seat := range playerSeats {
+		for _, card := range g.deckInPlay[seat] {
+			if card != uint8(BaseCover) {
+				position.Hands[idx] = append(position.Hands[idx], card)
+			}
+		}
+	}
+	//回合首打: 目前出牌座位往前數已出牌數
+	position.Leader = (seatIndex(g.engine.currentPlay) + 4 - played) % 4
+	for i := uint8(0); i < played; i++ {
+		position.Trick = append(position.Trick, table[(position.Leader+i)%4])
+	}
+	return position
+}
@@ -1,9 +1,12 @@
 package game
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
-	"math/rand"
 	"sort"
 	"strings"
 )
@@ -24,14 +27,6 @@ func sortHand(hand []*uint8) {
 	})
 }
 
-// 對 Game's的deck進行洗牌
-func shuffle(deck *[NumOfCardsInDeck]*uint8) {
-	//因為使用了random,所以必須設定一個rand seed否則每次洗牌結果都是一樣
-	rand.Shuffle(NumOfCardsInDeck, func(i, j int) {
-		deck[i], deck[j] = deck[j], deck[i]
-	})
-}
-
 // inPlaySync 同步各家持牌
 func inPlaySync(g *Game) {
 	for idx, s := range playerSeats {
@@ -60,9 +55,20 @@ func inPlaySync(g *Game) {
 	}
 }
 
-// Shuffle Game開始前的洗牌,並同步Game的deckInPlay
-func Shuffle(g *Game) {
-	shuffle(&g.deck)
+// Shuffle Game開始前以遊戲桌的發牌者(Dealer)發牌號(board)的牌,並同步Game的deckInPlay, 回傳該副牌的發牌種子
+func Shuffle(g *Game, board uint32) (seed string) {
+	hands, seed := g.Dealer().Deal(board)
+	DealHands(g, hands)
+	return seed
+}
+
+// DealHands 以指定的各家手牌(東,南,西,北)取代洗牌,並同步Game的deckInPlay, hands必須是完整的一副牌(參:ParsePBNDeal)
+func DealHands(g *Game, hands [4][]uint8) {
+	for idx := range hands {
+		for i, card := range hands[idx] {
+			g.deck[idx*NumOfCardsOnePlayer+i] = &deck[card-1]
+		}
+	}
 	for seatPtr := range g.Deck {
 		sortHand(g.Deck[seatPtr])
 	}
@@ -110,3 +116,123 @@ func NewDeck(g *Game) {
 			slog.String("牌", strings.Join(cards, "  ")))
 	}
 }
+
+/*============================================================================================*/
+// 發牌者(Dealer): 每副牌由32 bytes 的種子決定, 以種子(SHA-256 計數器模式產生的亂數)洗牌,
+// 種子隨牌局紀錄保存, DealFromSeed 可以重新產生完全相同的一副牌
+
+// Dealer 發牌者
+type Dealer interface {
+	// Deal 發牌號(board)的牌, 回傳各家手牌(東,南,西,北)與可以重新產生該副牌的種子
+	Deal(board uint32) (hands [4][]uint8, seed string)
+}
+
+// SetDealer 設定該房間的發牌者, 下一局開始生效
+func (g *Game) SetDealer(dealer Dealer) {
+	g.dealer.Store(&dealer)
+}
+
+// Dealer 該房間的發牌者
+func (g *Game) Dealer() Dealer {
+	return *g.dealer.Load()
+}
+
+type (
+	// seededDealer 固定種子的發牌者, 相同種子與牌號一定發出相同的牌(重現問題,複式橋牌)
+	seededDealer struct {
+		seed [8]byte
+	}
+
+	// cryptoDealer 每副牌以 crypto/rand 產生種子的發牌者(正式環境)
+	cryptoDealer struct{}
+)
+
+// NewSeededDealer 以種子(seed)產生固定發牌的發牌者, 牌號(board)的種子由 seed 與牌號決定
+func NewSeededDealer(seed uint64) Dealer {
+	d := &seededDealer{}
+	binary.BigEndian.PutUint64(d.seed[:], seed)
+	return d
+}
+
+func (d *seededDealer) Deal(board uint32) (hands [4][]uint8, seed string) {
+	var b [12]byte
+	copy(b[:], d.seed[:])
+	binary.BigEndian.PutUint32(b[8:], board)
+	key := sha256.Sum256(b[:])
+	return dealKey(key), hex.EncodeToString(key[:])
+}
+
+// NewCryptoDealer 每副牌以 crypto/rand 產生種子的發牌者
+func NewCryptoDealer() Dealer {
+	return cryptoDealer{}
+}
+
+func (cryptoDealer) Deal(board uint32) (hands [4][]uint8, seed string) {
+	var key [sha256.Size]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		//crypto/rand 不應該失敗
+		panic(err)
+	}
+	return dealKey(key), hex.EncodeToString(key[:])
+}
+
+// DealFromSeed 以發牌種子(Board.Seed)重新產生該副牌, 各家手牌(東,南,西,北)
+func DealFromSeed(seed string) (hands [4][]uint8, err error) {
+	var key [sha256.Size]byte
+	if len(seed) != hex.EncodedLen(len(key)) {
+		return hands, ErrDealSeed
+	}
+	if _, err = hex.Decode(key[:], []byte(seed)); err != nil {
+		return hands, ErrDealSeed
+	}
+	return dealKey(key), nil
+}
+
+// dealKey 以種子(key)洗一副牌(Fisher-Yates),依序每13張分給東,南,西,北, 各家手牌由小到大
+func dealKey(key [sha256.Size]byte) (hands [4][]uint8) {
+	var (
+		cards = deck
+		rnd   = dealStream{key: key}
+	)
+	for i := len(cards) - 1; i > 0; i-- {
+		j := rnd.intn(uint32(i + 1))
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	for idx := range hands {
+		hand := append([]uint8(nil), cards[idx*NumOfCardsOnePlayer:(idx+1)*NumOfCardsOnePlayer]...)
+		sort.Slice(hand, func(i, j int) bool { return hand[i] < hand[j] })
+		hands[idx] = hand
+	}
+	return
+}
+
+// dealStream 以 SHA-256(key|計數器) 產生的亂數
+type dealStream struct {
+	key     [sha256.Size]byte
+	counter uint64
+	buf     []byte
+}
+
+func (s *dealStream) uint32() uint32 {
+	if len(s.buf) < 4 {
+		var b [sha256.Size + 8]byte
+		copy(b[:], s.key[:])
+		binary.BigEndian.PutUint64(b[sha256.Size:], s.counter)
+		s.counter++
+		sum := sha256.Sum256(b[:])
+		s.buf = sum[:]
+	}
+	v := binary.BigEndian.Uint32(s.buf)
+	s.buf = s.buf[4:]
+	return v
+}
+
+// intn 0~n-1 均勻分布的亂數(拒絕取樣避免偏差)
+func (s *dealStream) intn(n uint32) uint32 {
+	limit := ^uint32(0) - ^uint32(0)%n
+	for {
+		if v := s.uint32(); v < limit {
+			return v % n
+		}
+	}
+}
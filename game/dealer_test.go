@@ -0,0 +1,82 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// checkHands 四家各13張, 由小到大, 一副牌52張都不重複
+func checkHands(t *testing.T, hands [4][]uint8) {
+	t.Helper()
+	seen := map[uint8]bool{}
+	for idx, hand := range hands {
+		if len(hand) != NumOfCardsOnePlayer || !slices.IsSorted(hand) {
+			t.Fatalf("%s 手牌 %v", CbSeat(playerSeats[idx]), hand)
+		}
+		for _, card := range hand {
+			if card < club2 || card > spadeAce || seen[card] {
+				t.Fatalf("%s 手牌 %v 有不合法或重複的牌 %s", CbSeat(playerSeats[idx]), hand, CbCard(card))
+			}
+			seen[card] = true
+		}
+	}
+}
+
+func TestSeededDealer(t *testing.T) {
+	hands, seed := NewSeededDealer(42).Deal(1)
+	checkHands(t, hands)
+
+	//相同種子與牌號發出相同的牌
+	again, againSeed := NewSeededDealer(42).Deal(1)
+	if !reflect.DeepEqual(hands, again) || seed != againSeed {
+		t.Error("相同種子與牌號發出不同的牌")
+	}
+	//不同牌號或不同種子發出不同的牌
+	if other, _ := NewSeededDealer(42).Deal(2); reflect.DeepEqual(hands, other) {
+		t.Error("不同牌號發出相同的牌")
+	}
+	if other, _ := NewSeededDealer(43).Deal(1); reflect.DeepEqual(hands, other) {
+		t.Error("不同種子發出相同的牌")
+	}
+}
+
+func TestDealFromSeed(t *testing.T) {
+	for name, dealer := range map[string]Dealer{"seeded": NewSeededDealer(7), "crypto": NewCryptoDealer()} {
+		t.Run(name, func(t *testing.T) {
+			hands, seed := dealer.Deal(3)
+			got, err := DealFromSeed(seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, hands) {
+				t.Errorf("DealFromSeed(%s) = %v, want %v", seed, got, hands)
+			}
+		})
+	}
+
+	for _, seed := range []string{"", "abc", "zz" + string(make([]byte, 62))} {
+		if _, err := DealFromSeed(seed); !errors.Is(err, ErrDealSeed) {
+			t.Errorf("DealFromSeed(%q) error = %v, want %v", seed, err, ErrDealSeed)
+		}
+	}
+}
+
+func TestBoardSeed(t *testing.T) {
+	g := testGame(t, "room")
+	g.SetDealer(NewSeededDealer(99))
+	board := newBoard(5)
+	board.seed = Shuffle(g, board.Number)
+
+	//牌局的種子能重現遊戲桌發出的四家手牌
+	hands, err := DealFromSeed(board.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, seat := range playerSeats {
+		if dealt := g.deckInPlay[seat][:]; !slices.Equal(dealt, hands[idx]) {
+			t.Errorf("%s 發出 %v, 種子重現 %v", CbSeat(seat), dealt, hands[idx])
+		}
+	}
+}
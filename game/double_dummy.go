@@ -0,0 +1,587 @@
+package game
+
+import (
+	"context"
+	"math/bits"
+	"sync"
+)
+
+// 雙明手(Double Dummy)分析: 四家牌全部攤開, 雙方都以最佳打法出牌時各方可以吃到的墩數.
+// 以零窗口(target)搜尋南北方是否能吃到 target 墩, 同一家連續的牌(中間沒有其他人還持有的牌)視為同一張, 只搜尋其中一張.
+// 每墩開始時將結果記錄到置換表, 只記錄搜尋過程中以牌序贏墩(同花色比大小)的牌以上的持牌者,
+// 其餘較小的牌只要各家張數相同就視為相同局面(Partition Search).
+// 分析整副牌視牌型需要約1~30秒CPU時間, 呼叫者以 context 設定期限, 逾時中止分析
+
+const (
+	ddStrains    = 5 //花色索引 梅花0,方塊1,紅心2,黑桃3,無王4
+	ddNoTrump    = 4
+	ddCheckNodes = 1 << 12 //每搜尋這麼多個局面檢查一次是否已取消
+)
+
+type (
+	// DDTable 雙明手分析結果, Tricks[莊家][花色] 莊家方可以吃到的墩數
+	// 莊家索引 東0,南1,西2,北3 (seatIndex); 花色索引 梅花0,方塊1,紅心2,黑桃3,無王4 (CbSuit)
+	DDTable struct {
+		Tricks [4][ddStrains]uint8 `json:"tricks"`
+	}
+
+	// DDPosition 雙明手局面, 可以是一局開始(尚未首引)或進行中的局面, 牌值與 deckInPlay 相同
+	DDPosition struct {
+		Hands  [4][]uint8 //各家剩下的牌,順序固定為東,南,西,北, BaseCover會被忽略
+		Strain uint8      //王牌花色索引, 無王為4
+		Leader uint8      //本回合首打座位索引(東0,南1,西2,北3)
+		Trick  []uint8    //本回合已經打出的牌(由首打依序)
+	}
+
+	// ddCard 搜尋中的一張牌, suit 花色索引, rank 0(2)~12(A)
+	ddCard struct {
+		suit, rank int8
+	}
+
+	// ddRanks 各花色牌的集合, bit0為2 ... bit12為A
+	ddRanks [4]uint16
+
+	// ddShape 置換表分組: 各家各花色張數(每個4bit)與首打
+	ddShape struct {
+		lengths uint64
+		leader  int8
+	}
+
+	// ddPattern 各花色剩下的牌由大到小的持牌者(每張2bit, 每個花色26bit靠左對齊), 梅花,方塊在 lo, 紅心,黑桃在 hi
+	ddPattern struct {
+		lo, hi uint64
+	}
+
+	// ddEntry 置換表項目: 各花色最大的 n 張牌(相對牌序)持牌者(owners&mask)相同的局面, 南北方可以再吃到的墩數上下界
+	ddEntry struct {
+		n            [4]int8
+		mask, owners ddPattern
+		lower, upper int8
+	}
+
+	ddSolver struct {
+		ctx      context.Context
+		nodes    int  //已搜尋的局面數
+		canceled bool //已取消(逾時), 之後的搜尋結果無效
+
+		hands  [4]ddRanks //各家各花色持牌
+		all    ddRanks    //各花色還在手上的牌
+		cards  int        //各家手上剩下的總張數
+		trump  int8
+		tt     map[ddShape][]ddEntry
+		leader int8
+		trick  [4]ddCard //本回合已經打出的牌(由首打依序)
+		played int8      //本回合已經打出的張數
+		depth  int       //已經打出的總張數,用於取用出牌緩衝
+		buf    [NumOfCardsInDeck + 4][NumOfCardsOnePlayer]ddCard
+	}
+)
+
+// toDDCard 牌值(club2~spadeAce)轉換成 ddCard
+func toDDCard(card uint8) ddCard {
+	return ddCard{suit: int8((card - 1) / 13), rank: int8((card - 1) % 13)}
+}
+
+// value ddCard 轉換回牌值
+func (c ddCard) value() uint8 {
+	return uint8(c.suit)*13 + uint8(c.rank) + 1
+}
+
+// isNS 座位索引是否為南北方
+func isNS(seat int8) bool {
+	return seat%2 == 1
+}
+
+// merge 合併另一個牌的集合
+func (r *ddRanks) merge(other ddRanks) {
+	for suit := range r {
+		r[suit] |= other[suit]
+	}
+}
+
+// topRanks 牌的集合(all)中最大的 n 張
+func topRanks(all uint16, n int8) uint16 {
+	for i := bits.OnesCount16(all) - int(n); i > 0; i-- {
+		all &= all - 1
+	}
+	return all
+}
+
+func newDDSolver(ctx context.Context, p DDPosition) *ddSolver {
+	s := &ddSolver{
+		ctx:    ctx,
+		trump:  int8(p.Strain),
+		tt:     make(map[ddShape][]ddEntry),
+		leader: int8(p.Leader),
+	}
+	for seat := range p.Hands {
+		for _, card := range p.Hands[seat] {
+			if card < club2 || card > spadeAce {
+				continue
+			}
+			c := toDDCard(card)
+			s.hands[seat][c.suit] |= 1 << c.rank
+			s.all[c.suit] |= 1 << c.rank
+			s.cards++
+		}
+	}
+	for _, card := range p.Trick {
+		s.trick[s.played] = toDDCard(card)
+		s.played++
+	}
+	return s
+}
+
+// tricksLeft 剩下的墩數(包含本回合)
+func (s *ddSolver) tricksLeft() int {
+	return (s.cards + int(s.played)) / 4
+}
+
+// toPlay 輪到出牌的座位
+func (s *ddSolver) toPlay() int8 {
+	return (s.leader + s.played) % 4
+}
+
+// shape 每墩開始時的置換表分組
+func (s *ddSolver) shape() ddShape {
+	var lengths uint64
+	for seat := range s.hands {
+		for suit := range s.hands[seat] {
+			lengths = lengths<<4 | uint64(bits.OnesCount16(s.hands[seat][suit]))
+		}
+	}
+	return ddShape{lengths: lengths, leader: s.leader}
+}
+
+// pattern 每墩開始時各花色剩下的牌由大到小的持牌者
+func (s *ddSolver) pattern() (p ddPattern) {
+	var code [4]uint64
+	for suit := range code {
+		all := s.all[suit]
+		for all != 0 {
+			rank := 15 - bits.LeadingZeros16(all)
+			all &^= 1 << rank
+			var seat uint64
+			switch bit := uint16(1) << rank; {
+			case s.hands[1][suit]&bit != 0:
+				seat = 1
+			case s.hands[2][suit]&bit != 0:
+				seat = 2
+			case s.hands[3][suit]&bit != 0:
+				seat = 3
+			}
+			code[suit] = code[suit]<<2 | seat
+		}
+		code[suit] <<= 2 * (NumOfCardsOnePlayer - bits.OnesCount16(s.all[suit]))
+	}
+	return ddPattern{lo: code[0] | code[1]<<26, hi: code[2] | code[3]<<26}
+}
+
+// patternMask 各花色最大的 n 張牌在 ddPattern 中的位置
+func patternMask(n [4]int8) ddPattern {
+	var mask [4]uint64
+	for suit := range mask {
+		mask[suit] = (uint64(1)<<(2*n[suit]) - 1) << (2 * (NumOfCardsOnePlayer - int(n[suit])))
+	}
+	return ddPattern{lo: mask[0] | mask[1]<<26, hi: mask[2] | mask[3]<<26}
+}
+
+// winning 本回合目前贏的牌(索引)
+func (s *ddSolver) winning() (best int8) {
+	for i := int8(1); i < s.played; i++ {
+		c, w := s.trick[i], s.trick[best]
+		if (c.suit == w.suit && c.rank > w.rank) || (c.suit == s.trump && w.suit != s.trump) {
+			best = i
+		}
+	}
+	return
+}
+
+// moves 座位(seat)可以打出的牌(同一家連續的牌只取一張),並依可能的好壞排序
+func (s *ddSolver) moves(seat int8) []ddCard {
+	var (
+		moves   = s.buf[s.depth][:0]
+		present = s.all //各花色還沒打出的牌(包含桌面上本回合的牌)
+		suits   = [4]int8{0, 1, 2, 3}
+		nSuits  = 4
+	)
+	for i := int8(0); i < s.played; i++ {
+		present[s.trick[i].suit] |= 1 << s.trick[i].rank
+	}
+
+	if s.played > 0 {
+		if lead := s.trick[0].suit; s.hands[seat][lead] != 0 {
+			suits[0], nSuits = lead, 1
+		}
+	}
+
+	for _, suit := range suits[:nSuits] {
+		var (
+			hold   = s.hands[seat][suit]
+			others = present[suit] &^ hold //其他人的牌(已經打出的牌不影響連續)
+			above  = uint16(1) << 13       //上一張牌以上
+		)
+		for hold != 0 {
+			rank := 15 - bits.LeadingZeros16(hold)
+			bit := uint16(1) << rank
+			hold &^= bit
+			//與上一張牌之間有其他人的牌才是新的連續
+			if others&(above-1)&^(bit<<1-1) != 0 || above == 1<<13 {
+				moves = append(moves, ddCard{suit: suit, rank: int8(rank)})
+			}
+			above = bit
+		}
+	}
+
+	// 出牌順序: 首打先試大牌; 跟牌先試最小能贏的牌,同伴已經贏或贏不了先試最小的牌
+	var (
+		score       [NumOfCardsOnePlayer]int
+		win         ddCard
+		partnerWins bool
+	)
+	if s.played > 0 {
+		best := s.winning()
+		win = s.trick[best]
+		partnerWins = s.played-best == 2
+	}
+	for i, c := range moves {
+		switch {
+		case s.played == 0:
+			score[i] = int(c.rank)
+		case partnerWins:
+			score[i] = -int(c.rank)
+		case (c.suit == win.suit && c.rank > win.rank) || (c.suit == s.trump && win.suit != s.trump):
+			score[i] = 100 - int(c.rank)
+		case c.suit == s.trump:
+			score[i] = -50 - int(c.rank)
+		default:
+			score[i] = -int(c.rank)
+		}
+	}
+	//插入排序,張數很少
+	for i := 1; i < len(moves); i++ {
+		for j := i; j > 0 && score[j] > score[j-1]; j-- {
+			score[j], score[j-1] = score[j-1], score[j]
+			moves[j], moves[j-1] = moves[j-1], moves[j]
+		}
+	}
+	return moves
+}
+
+// play 座位(seat)打出 c, 本回合第四張時結算並回傳贏墩的牌(索引), 否則-1
+func (s *ddSolver) play(seat int8, c ddCard) int8 {
+	s.hands[seat][c.suit] &^= 1 << c.rank
+	s.all[c.suit] &^= 1 << c.rank
+	s.cards--
+	s.trick[s.played] = c
+	s.played++
+	s.depth++
+	if s.played < 4 {
+		return -1
+	}
+	return s.winning()
+}
+
+// unplay 收回座位(seat)打出的 c
+func (s *ddSolver) unplay(seat int8, c ddCard) {
+	s.played--
+	s.depth--
+	s.hands[seat][c.suit] |= 1 << c.rank
+	s.all[c.suit] |= 1 << c.rank
+	s.cards++
+}
+
+// nsAtLeast 南北方從目前局面起(包含本回合)是否可以再吃到 target 墩, 以及影響結果的牌(relevant)
+func (s *ddSolver) nsAtLeast(target int) (bool, ddRanks) {
+	if s.played > 0 {
+		return s.search(target)
+	}
+
+	left := s.tricksLeft()
+	if target <= 0 {
+		return true, ddRanks{}
+	}
+	if target > left {
+		return false, ddRanks{}
+	}
+
+	//首打方可以連續兌現的贏墩
+	if quick, relevant := s.quickTricks(); isNS(s.leader) && target <= quick {
+		return true, relevant
+	} else if !isNS(s.leader) && target > left-quick {
+		return false, relevant
+	}
+	//首打方的對手一定可以吃到的王牌
+	if sure, relevant := s.trumpTricks(); isNS(s.leader) && target > left-sure {
+		return false, relevant
+	} else if !isNS(s.leader) && target <= sure {
+		return true, relevant
+	}
+
+	var (
+		shape   = s.shape()
+		pattern = s.pattern()
+		entries = s.tt[shape]
+	)
+	for i := range entries {
+		e := &entries[i]
+		if pattern.lo&e.mask.lo != e.owners.lo || pattern.hi&e.mask.hi != e.owners.hi {
+			continue
+		}
+		if target <= int(e.lower) {
+			return true, e.relevant(s.all)
+		}
+		if target > int(e.upper) {
+			return false, e.relevant(s.all)
+		}
+	}
+
+	result, relevant := s.search(target)
+
+	//影響結果的牌以上(相對牌序)都要相同才是相同局面
+	entry := ddEntry{lower: 0, upper: int8(left)}
+	for suit, all := range s.all {
+		if r := relevant[suit] & all; r != 0 {
+			entry.n[suit] = int8(bits.OnesCount16(all &^ (r&-r - 1)))
+		}
+	}
+	entry.mask = patternMask(entry.n)
+	entry.owners = ddPattern{lo: pattern.lo & entry.mask.lo, hi: pattern.hi & entry.mask.hi}
+	if result {
+		entry.lower = int8(target)
+	} else {
+		entry.upper = int8(target - 1)
+	}
+	for i := range entries {
+		if entries[i].mask == entry.mask && entries[i].owners == entry.owners {
+			entries[i].lower = max(entries[i].lower, entry.lower)
+			entries[i].upper = min(entries[i].upper, entry.upper)
+			return result, relevant
+		}
+	}
+	s.tt[shape] = append(entries, entry)
+	return result, relevant
+}
+
+// relevant 置換表項目在局面(剩下的牌 all)中影響結果的牌
+func (e *ddEntry) relevant(all ddRanks) (relevant ddRanks) {
+	for suit := range all {
+		relevant[suit] = topRanks(all[suit], e.n[suit])
+	}
+	return
+}
+
+// quickTricks 首打方可以連續兌現不失去出牌權的墩數, 以及這些贏張(relevant)
+//
+//	首打者自己手上的贏張, 或首打者打小牌到同伴的贏張後, 同伴手上的贏張
+func (s *ddSolver) quickTricks() (quick int, relevant ddRanks) {
+	quick, relevant, _ = s.cashTricks(s.leader)
+	n, r, suits := s.cashTricks((s.leader + 2) % 4)
+	if n <= quick {
+		return
+	}
+	for suit := range s.hands[s.leader] {
+		//首打者有同伴贏張花色的牌可以進入同伴手上
+		if suits&(1<<suit) != 0 && s.hands[s.leader][suit] != 0 {
+			return n, r
+		}
+	}
+	return
+}
+
+// cashTricks 座位(seat)手上由最大牌開始連續的贏張, 取得出牌權後可以連續兌現的墩數, 這些贏張(relevant)與有贏張的花色(suits)
+//
+//	副牌只計到對手兩家都還能跟牌的張數(避免被王吃), 先兌現副牌再兌現王牌
+func (s *ddSolver) cashTricks(seat int8) (quick int, relevant ddRanks, suits uint8) {
+	var (
+		hand = s.hands[seat]
+		lho  = s.hands[(seat+1)%4]
+		rho  = s.hands[(seat+3)%4]
+		pd   = s.hands[(seat+2)%4]
+	)
+	oppTrumps := s.trump < ddNoTrump && lho[s.trump]|rho[s.trump] != 0
+	for suit := int8(0); suit < 4; suit++ {
+		run := hand[suit]
+		if others := lho[suit] | rho[suit] | pd[suit]; others != 0 {
+			run &^= uint16(1)<<(16-bits.LeadingZeros16(others)) - 1
+		}
+		if run == 0 {
+			continue
+		}
+		top := bits.OnesCount16(run)
+		relevant[suit] = run
+		if oppTrumps && suit != s.trump {
+			top = min(top, bits.OnesCount16(lho[suit]), bits.OnesCount16(rho[suit]))
+		}
+		if top > 0 {
+			quick += top
+			suits |= 1 << suit
+		}
+	}
+	return
+}
+
+// trumpTricks 首打方對手其中一家手上由最大王牌開始連續的王牌, 每一張打出時都會贏墩
+func (s *ddSolver) trumpTricks() (sure int, relevant ddRanks) {
+	if s.trump >= ddNoTrump {
+		return
+	}
+	for _, opp := range [2]int8{(s.leader + 1) % 4, (s.leader + 3) % 4} {
+		run := s.hands[opp][s.trump]
+		if others := s.all[s.trump] &^ run; others != 0 {
+			run &^= uint16(1)<<(16-bits.LeadingZeros16(others)) - 1
+		}
+		if run != 0 {
+			relevant[s.trump] = run
+			return bits.OnesCount16(run), relevant
+		}
+	}
+	return
+}
+
+// stopped 分析是否已取消, 每搜尋 ddCheckNodes 個局面檢查一次 context
+func (s *ddSolver) stopped() bool {
+	if s.canceled {
+		return true
+	}
+	if s.nodes++; s.nodes%ddCheckNodes == 0 && s.ctx.Err() != nil {
+		s.canceled = true
+	}
+	return s.canceled
+}
+
+// search 輪到出牌的座位逐一試出牌, 南北方只要有一張可以達成,東西方則每一張都必須達成
+func (s *ddSolver) search(target int) (bool, ddRanks) {
+	if s.stopped() {
+		return false, ddRanks{}
+	}
+	var (
+		seat     = s.toPlay()
+		ns       = isNS(seat)
+		relevant ddRanks
+	)
+	for _, c := range s.moves(seat) {
+		result, r := s.afterPlay(seat, c, target)
+		if result == ns {
+			//找到達成的出牌,只需要這張出牌之後影響結果的牌
+			return ns, r
+		}
+		relevant.merge(r)
+	}
+	return !ns, relevant
+}
+
+// afterPlay 座位(seat)打出 c 之後,南北方是否仍可以吃到 target 墩, 以及影響結果的牌
+func (s *ddSolver) afterPlay(seat int8, c ddCard, target int) (result bool, relevant ddRanks) {
+	best := s.play(seat, c)
+	if best < 0 {
+		result, relevant = s.nsAtLeast(target)
+		s.unplay(seat, c)
+		return
+	}
+
+	//回合結束,贏墩者首打下一回合
+	var (
+		trick  = s.trick
+		leader = s.leader
+		winner = (s.leader + best) % 4
+	)
+	s.leader, s.played = winner, 0
+	if isNS(winner) {
+		target--
+	}
+	result, relevant = s.nsAtLeast(target)
+	s.leader, s.played, s.trick = leader, 4, trick
+	s.unplay(seat, c)
+
+	//贏墩的牌是與同花色的牌比大小贏的
+	w := trick[best]
+	for i, t := range trick {
+		if int8(i) != best && t.suit == w.suit {
+			relevant[w.suit] |= 1 << w.rank
+			break
+		}
+	}
+	return
+}
+
+// nsTricks 南北方從目前局面起(包含本回合)可以吃到的墩數
+func (s *ddSolver) nsTricks() int {
+	return s.nsTricksFrom(s.tricksLeft() / 2)
+}
+
+// nsTricksFrom 以猜測值(guess)開始逐墩逼近南北方可以吃到的墩數, 猜測值接近時比二分搜尋快
+func (s *ddSolver) nsTricksFrom(guess int) int {
+	left := s.tricksLeft()
+	guess = max(0, min(guess, left))
+	if ok, _ := s.nsAtLeast(guess); ok {
+		for guess < left {
+			if ok, _ = s.nsAtLeast(guess + 1); !ok {
+				break
+			}
+			guess++
+		}
+		return guess
+	}
+	for guess--; guess > 0; guess-- {
+		if ok, _ := s.nsAtLeast(guess); ok {
+			break
+		}
+	}
+	return guess
+}
+
+// sideTricks 座位(seat)方從目前局面起(包含本回合)可以吃到的墩數
+func (s *ddSolver) sideTricks(seat int8) int {
+	ns := s.nsTricks()
+	if isNS(seat) {
+		return ns
+	}
+	return s.tricksLeft() - ns
+}
+
+// Tricks 輪到出牌的一方, 雙方最佳打法下可以吃到的墩數(包含本回合), ctx 取消或逾時時回傳 ctx.Err()
+func (p DDPosition) Tricks(ctx context.Context) (uint8, error) {
+	s := newDDSolver(ctx, p)
+	tricks := s.sideTricks(s.toPlay())
+	if s.canceled {
+		return 0, ctx.Err()
+	}
+	return uint8(tricks), nil
+}
+
+// SolveDeal 雙明手分析一副牌(hands 順序固定為東,南,西,北,各13張), 各家做莊在各花色可以吃到的墩數,
+// ctx 取消或逾時時中止分析並回傳 ctx.Err()
+func SolveDeal(ctx context.Context, hands [4][]uint8) (table DDTable, err error) {
+	var (
+		wg       sync.WaitGroup
+		canceled [ddStrains]bool
+	)
+	for strain := uint8(0); strain < ddStrains; strain++ {
+		wg.Add(1)
+		go func(strain uint8) {
+			defer wg.Done()
+			//同一花色共用置換表, 首打為莊家下家
+			s := newDDSolver(ctx, DDPosition{Hands: hands, Strain: strain})
+			ns := NumOfCardsOnePlayer / 2
+			for leader := int8(0); leader < 4 && !s.canceled; leader++ {
+				s.leader = leader
+				ns = s.nsTricksFrom(ns)
+				declarer := (leader + 3) % 4
+				if isNS(declarer) {
+					table.Tricks[declarer][strain] = uint8(ns)
+				} else {
+					table.Tricks[declarer][strain] = uint8(NumOfCardsOnePlayer - ns)
+				}
+			}
+			canceled[strain] = s.canceled
+		}(strain)
+	}
+	wg.Wait()
+	for _, c := range canceled {
+		if c {
+			return DDTable{}, ctx.Err()
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDDPositionTricks(t *testing.T) {
+	tests := []struct {
+		name     string
+		position DDPosition
+		want     uint8
+	}{
+		{
+			name: "北家首打黑桃A",
+			position: DDPosition{
+				Hands:  [4][]uint8{{spadeK}, {spadeQ}, {spadeJ}, {spadeAce}},
+				Strain: ddNoTrump,
+				Leader: 3,
+			},
+			want: 1,
+		},
+		{
+			name: "東家首打黑桃K被北家A吃",
+			position: DDPosition{
+				Hands:  [4][]uint8{{spadeK}, {spadeQ}, {spadeJ}, {spadeAce}},
+				Strain: ddNoTrump,
+				Leader: 0,
+			},
+			want: 0,
+		},
+		{
+			name: "偷牌成功(K在西家)",
+			position: DDPosition{
+				Hands:  [4][]uint8{{spade5, spade4}, {spade3, spade2}, {spadeK, spade6}, {spadeAce, spadeQ}},
+				Strain: ddNoTrump,
+				Leader: 1,
+			},
+			want: 2,
+		},
+		{
+			name: "偷牌失敗(K在東家)",
+			position: DDPosition{
+				Hands:  [4][]uint8{{spadeK, spade6}, {spade3, spade2}, {spade5, spade4}, {spadeAce, spadeQ}},
+				Strain: ddNoTrump,
+				Leader: 1,
+			},
+			want: 1,
+		},
+		{
+			name: "王吃首打的贏張",
+			position: DDPosition{
+				Hands:  [4][]uint8{{clubAce, club2}, {diamond3, diamond2}, {clubK, club3}, {heart2, heart3}},
+				Strain: 2, //紅心
+				Leader: 0,
+			},
+			want: 0,
+		},
+		{
+			name: "回合中輪到西家跟牌",
+			position: DDPosition{
+				Hands:  [4][]uint8{{spade4}, {spade3}, {spadeAce, spade2}, {spadeK}},
+				Strain: ddNoTrump,
+				Leader: 3,
+				Trick:  []uint8{spadeQ, spade5, spade6},
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.position.Tricks(context.Background())
+			if err != nil {
+				t.Fatalf("Tricks() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Tricks() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveDeal(t *testing.T) {
+	//北黑桃, 東紅心, 南方塊, 西梅花: 持有王牌花色的一方吃到全部13墩, 無王時首引方兌現整門花色
+	hands, err := ParsePBNDeal("N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := SolveDeal(context.Background(), hands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, declarer := range []string{"東", "南", "西", "北"} {
+		t.Run(declarer, func(t *testing.T) {
+			if got, want := table.Tricks[idx], suitTable.Tricks[idx]; got != want {
+				t.Errorf("Tricks[%s] = %v, want %v", declarer, got, want)
+			}
+		})
+	}
+}
+
+func TestSolveDealCanceled(t *testing.T) {
+	hands, _ := NewSeededDealer(1).Deal(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := SolveDeal(ctx, hands); !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveDeal() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,464 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// 複式橋牌(Duplicate): 一個賽程(DuplicateSession)包含多個遊戲桌, 每桌依序打相同的牌(相同種子的發牌者),
+// 遊戲桌準備好開始新的一局時由賽程給下一副牌, 每副牌的結果先由賽程保留, 所有遊戲桌都打完該副牌才計分並廣播各桌結果比較,
+// 成績排名推送到大廳, 賽程結束時推送最終排名
+
+type (
+	// DuplicateSession 複式賽程
+	DuplicateSession struct {
+		name   string
+		dealer Dealer
+
+		mu        sync.Mutex
+		tables    []*Game
+		queue     map[*Game][]uint32                //各桌待打的牌號
+		current   map[*Game]uint32                  //各桌目前(尚未回報結果)的牌號, 0表示沒有
+		expected  map[uint32]int                    //牌號->應打桌數
+		results   map[uint32]map[*Game]*TableResult //牌號->各桌結果
+		scoring   SessionScoring
+		standings map[string]*SessionStanding //配對->成績
+		scored    int                         //已計分牌數
+		sealed    bool                        //不再排入新的牌, 排入的牌都打完時賽程結束
+		idle      chan *Game                  //遊戲桌打完排入的牌時通知賽程安排者(Tournament), nil表示不通知
+		notifier  LobbyNotifier
+		onBoard   func(board DuplicateBoard) //每副牌計分後呼叫(TeamMatch), nil表示不呼叫
+		done      chan struct{}              //賽程結束時關閉
+	}
+
+	// LobbyNotifier 推送訊息給大廳所有人
+	LobbyNotifier interface {
+		NotifyLobby(eventName string, body []byte)
+	}
+
+	// TableResult 一桌一副牌的結果
+	TableResult struct {
+		Room     string      `json:"room"`
+		NSPair   string      `json:"nsPair"`           //南北配對
+		EWPair   string      `json:"ewPair"`           //東西配對
+		Result   *GameResult `json:"result,omitempty"` //四家PASS時為nil
+		NS       int32       `json:"ns"`
+		EW       int32       `json:"ew"`
+		NSPoints float64     `json:"nsPoints"` //南北比分或IMP, 所有遊戲桌打完該副牌才計分
+		EWPoints float64     `json:"ewPoints"` //東西比分或IMP
+	}
+
+	// DuplicateBoard 所有遊戲桌都打完一副牌後的各桌結果, 依南北得分由高到低
+	DuplicateBoard struct {
+		Session string        `json:"session"`
+		Board   Board         `json:"board"`
+		Results []TableResult `json:"results"`
+	}
+)
+
+// NewDuplicateSession 建立複式賽程, 遊戲桌(tables)以種子(seed)依序打牌號1~boards, 遊戲桌不能同時在其他賽程
+func NewDuplicateSession(name string, seed uint64, boards uint32, tables ...*Game) (*DuplicateSession, error) {
+	if boards == 0 || len(tables) < 2 {
+		return nil, fmt.Errorf("%w: 至少兩桌與一副牌", ErrSession)
+	}
+	s, err := newDuplicateSession(name, seed, tables)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range tables {
+		for number := uint32(1); number <= boards; number++ {
+			s.schedule(g, number)
+		}
+	}
+	s.sealed = true
+	slog.Info("DuplicateSession", slog.String("賽程", name), slog.Int("桌數", len(tables)), slog.Uint64("牌數", uint64(boards)))
+	return s, nil
+}
+
+// newDuplicateSession 建立尚未排入任何牌的賽程, 遊戲桌加入賽程
+func newDuplicateSession(name string, seed uint64, tables []*Game) (*DuplicateSession, error) {
+	s := &DuplicateSession{
+		name:      name,
+		scoring:   tables[0].Scoring(),
+		dealer:    NewSeededDealer(seed),
+		tables:    tables,
+		queue:     make(map[*Game][]uint32, len(tables)),
+		current:   make(map[*Game]uint32, len(tables)),
+		expected:  make(map[uint32]int),
+		results:   make(map[uint32]map[*Game]*TableResult),
+		standings: make(map[string]*SessionStanding),
+		done:      make(chan struct{}),
+	}
+
+	for i, g := range tables {
+		if !g.session.CompareAndSwap(nil, s) {
+			for _, joined := range tables[:i] {
+				joined.session.Store(nil)
+			}
+			return nil, fmt.Errorf("%w: %s已在其他賽程", ErrSession, g.name)
+		}
+	}
+	return s, nil
+}
+
+// Name 賽程名稱
+func (s *DuplicateSession) Name() string {
+	return s.name
+}
+
+// SetScoring 設定計分方式(預設為第一桌房間設定的計分方式), 必須在第一副牌計分前設定
+func (s *DuplicateSession) SetScoring(scoring SessionScoring) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scoring = scoring
+}
+
+// SetLobbyNotifier 設定成績排名推送到大廳, nil表示不推送
+func (s *DuplicateSession) SetLobbyNotifier(notifier LobbyNotifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = notifier
+}
+
+// Done 賽程結束(所有遊戲桌打完所有牌,或Close)時關閉
+func (s *DuplicateSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close 結束賽程, 推送最終排名, 遊戲桌恢復一般發牌
+func (s *DuplicateSession) Close() {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return
+	default:
+		close(s.done)
+	}
+	for _, g := range s.tables {
+		g.session.CompareAndSwap(s, nil)
+	}
+	var (
+		ranking  = s.leaderboard(true)
+		tables   = append([]*Game(nil), s.tables...)
+		notifier = s.notifier
+	)
+	s.mu.Unlock()
+
+	slog.Info("DuplicateSession", slog.String("賽程", s.name), slog.String(".", "賽程結束"), slog.Int("計分牌數", ranking.Boards))
+	s.broadcast(tables, ClnRoomEvents.GameSessionRanking, ranking)
+	s.notify(notifier, ClnLobbyEvents.SessionRanking, ranking)
+	for _, g := range tables {
+		g.resume()
+	}
+}
+
+// Results 該副牌目前已回報的各桌結果, complete表示所有排入該副牌的遊戲桌都打完
+func (s *DuplicateSession) Results(board uint32) (results []TableResult, complete bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.boardResults(board), s.complete(board)
+}
+
+// boardResults 依南北得分由高到低的各桌結果
+func (s *DuplicateSession) boardResults(board uint32) []TableResult {
+	results := make([]TableResult, 0, len(s.results[board]))
+	for _, r := range s.results[board] {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].NS != results[j].NS {
+			return results[i].NS > results[j].NS
+		}
+		return results[i].Room < results[j].Room
+	})
+	return results
+}
+
+// board 賽程牌號(number)的牌局
+func (s *DuplicateSession) board(number uint32) PresetDeal {
+	hands, seed := s.dealer.Deal(number)
+	board := newBoard(number)
+	board.seed = seed
+	return PresetDeal{Board: board, Hands: hands}
+}
+
+// schedule 遊戲桌(g)排入要打的牌號(boards)
+func (s *DuplicateSession) schedule(g *Game, boards ...uint32) {
+	s.queue[g] = append(s.queue[g], boards...)
+	for _, number := range boards {
+		s.expected[number]++
+	}
+}
+
+// plan 預先登記各牌號應打桌數(配對賽整個移位表), 避免牌在前面回合只有部分遊戲桌打完就計分; 之後以assign排入各回合的牌
+func (s *DuplicateSession) plan(expected map[uint32]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for number, tables := range expected {
+		s.expected[number] += tables
+	}
+}
+
+// assign 遊戲桌(g)排入已預先登記(plan)的牌
+func (s *DuplicateSession) assign(g *Game, boards []uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue[g] = append(s.queue[g], boards...)
+}
+
+// pending 遊戲桌(g)還有待打或打到一半的牌
+func (s *DuplicateSession) pending(g *Game) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if number := s.current[g]; number != 0 && s.results[number][g] == nil {
+		return true
+	}
+	return len(s.queue[g]) > 0
+}
+
+// seal 不再排入新的牌, 排入的牌都已打完時結束賽程
+func (s *DuplicateSession) seal() {
+	s.mu.Lock()
+	s.sealed = true
+	finished := s.finished()
+	s.mu.Unlock()
+	if finished {
+		s.Close()
+	}
+}
+
+// complete 排入該副牌的遊戲桌都打完
+func (s *DuplicateSession) complete(number uint32) bool {
+	return s.expected[number] > 0 && len(s.results[number]) >= s.expected[number]
+}
+
+// released 牌號(number)的牌局是否可以公開: 不是賽程排入的牌, 或排入這副牌的遊戲桌都已打完
+func (s *DuplicateSession) released(number uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expected[number] == 0 || s.complete(number)
+}
+
+// nextDeal 遊戲桌(g)準備開始新的一局, 回傳該桌下一副牌; 目前這副牌未回報結果(中斷)時重打同一副牌, 該桌沒有待打的牌時ok為false
+func (s *DuplicateSession) nextDeal(g *Game) (deal PresetDeal, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number := s.current[g]
+	if number == 0 || s.results[number][g] != nil {
+		if len(s.queue[g]) == 0 {
+			s.current[g] = 0
+			return deal, false
+		}
+		number, s.queue[g] = s.queue[g][0], s.queue[g][1:]
+		s.current[g] = number
+	}
+	return s.board(number), true
+}
+
+// report 遊戲桌(g)回報目前這副牌的結果(result)與各家玩家(players, 東,南,西,北), 四家PASS時result為nil;
+// 排入該副牌的遊戲桌都打完時計分,廣播各桌結果並推送成績排名到大廳
+func (s *DuplicateSession) report(g *Game, board Board, players [4]string, result *GameResult) {
+	s.mu.Lock()
+
+	number := s.current[g]
+	if number == 0 || number != board.Number || s.results[number][g] != nil {
+		s.mu.Unlock()
+		return
+	}
+	if s.results[number] == nil {
+		s.results[number] = make(map[*Game]*TableResult, s.expected[number])
+	}
+	r := &TableResult{
+		Room:   g.name,
+		NSPair: pairName(players[seatIndex(uint8(north))], players[seatIndex(uint8(south))]),
+		EWPair: pairName(players[seatIndex(uint8(east))], players[seatIndex(uint8(west))]),
+		Result: result,
+	}
+	if result != nil {
+		r.NS, r.EW = result.NS, result.EW
+	}
+	s.results[number][g] = r
+
+	var compared []DuplicateBoard
+	if s.complete(number) {
+		compared = append(compared, s.scoreComplete(number))
+	}
+	idle := len(s.queue[g]) == 0
+	s.mu.Unlock()
+
+	s.publish(compared)
+	if idle && s.idle != nil {
+		select {
+		case s.idle <- g:
+		default:
+		}
+	}
+}
+
+// cancel 取消遊戲桌(g)尚未打完的牌(回合時間到), 回傳取消的牌數; 取消後其他遊戲桌都打完的牌立即計分
+func (s *DuplicateSession) cancel(g *Game) (canceled int) {
+	s.mu.Lock()
+
+	boards := s.queue[g]
+	if number := s.current[g]; number != 0 && s.results[number][g] == nil {
+		boards = append(boards, number)
+	}
+	s.queue[g], s.current[g] = nil, 0
+	compared := s.drop(boards)
+	s.mu.Unlock()
+
+	s.publish(compared)
+	return len(boards)
+}
+
+// withdraw 取消預先登記(plan)但還沒排入的牌(配對賽中止), 其他遊戲桌都打完的牌立即計分
+func (s *DuplicateSession) withdraw(boards []uint32) {
+	s.mu.Lock()
+	compared := s.drop(boards)
+	s.mu.Unlock()
+
+	s.publish(compared)
+}
+
+// drop 牌號(boards)各少一桌要打, 回傳因此打完的牌各桌結果
+func (s *DuplicateSession) drop(boards []uint32) (compared []DuplicateBoard) {
+	for _, number := range boards {
+		if s.expected[number] == 0 || s.complete(number) {
+			continue
+		}
+		s.expected[number]--
+		if s.complete(number) {
+			compared = append(compared, s.scoreComplete(number))
+		}
+	}
+	return
+}
+
+// scoreComplete 計分打完的牌(number), 回傳各桌結果
+func (s *DuplicateSession) scoreComplete(number uint32) DuplicateBoard {
+	//依遊戲桌順序計分
+	results := make([]*TableResult, 0, len(s.results[number]))
+	for _, table := range s.tables {
+		if r := s.results[number][table]; r != nil {
+			results = append(results, r)
+		}
+	}
+	s.scoreBoard(results)
+	return DuplicateBoard{Session: s.name, Board: newBoard(number), Results: s.boardResults(number)}
+}
+
+// publish 廣播打完的牌各桌結果, 推送成績排名到大廳, 所有排入的牌都打完時結束賽程
+func (s *DuplicateSession) publish(compared []DuplicateBoard) {
+	if len(compared) == 0 {
+		return
+	}
+	s.mu.Lock()
+	var (
+		finished    = s.finished()
+		leaderboard = s.leaderboard(false)
+		tables      = append([]*Game(nil), s.tables...)
+		notifier    = s.notifier
+		onBoard     = s.onBoard
+	)
+	s.mu.Unlock()
+
+	for _, board := range compared {
+		s.broadcast(tables, ClnRoomEvents.GameDuplicateBoard, board)
+		if onBoard != nil {
+			onBoard(board)
+		}
+	}
+	if finished {
+		s.Close()
+		return
+	}
+	s.notify(notifier, ClnLobbyEvents.SessionLeaderboard, leaderboard)
+}
+
+// finished 不再排入新的牌, 並且排入的牌都打完(或取消)
+func (s *DuplicateSession) finished() bool {
+	if !s.sealed {
+		return false
+	}
+	for number, expected := range s.expected {
+		if expected > 0 && !s.complete(number) {
+			return false
+		}
+	}
+	return true
+}
+
+// pairName 配對名稱, 兩位玩家名稱依字母順序, 同一配對坐南北或東西都相同
+func pairName(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return a + "/" + b
+}
+
+// broadcast 廣播給賽程所有遊戲桌(玩家,觀眾)
+func (s *DuplicateSession) broadcast(tables []*Game, eventName string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Warn("DuplicateSession", slog.String(".", err.Error()))
+		return
+	}
+	for _, g := range tables {
+		g.roomManager.BroadcastBytes(nil, eventName, g.name, body)
+	}
+}
+
+// notify 推送給大廳所有人
+func (s *DuplicateSession) notify(notifier LobbyNotifier, eventName string, payload any) {
+	if notifier == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Warn("DuplicateSession", slog.String(".", err.Error()))
+		return
+	}
+	notifier.NotifyLobby(eventName, body)
+}
+
+// Session 該房間所在的複式賽程, 不在賽程中回傳nil
+func (g *Game) Session() *DuplicateSession {
+	return g.session.Load()
+}
+
+// HandReleased 牌號(board)的牌局是否可以公開(匯出,LIN), 複式賽程中要等所有遊戲桌都打完這副牌
+func (g *Game) HandReleased(board uint32) bool {
+	s := g.Session()
+	return s == nil || s.released(board)
+}
+
+// waitForBoards 複式賽程中該桌沒有待打的牌(回合之間, 或已打完排入的牌), 不發牌等待排入新的牌或賽程結束
+func (g *Game) waitForBoards() {
+	if err := g.transit(PhaseWaitingForPlayers); err != nil {
+		g.log.Wrn("waitForBoards", slog.String(".", err.Error()))
+		return
+	}
+	slog.Info("DuplicateSession", slog.String(g.name, "沒有待打的牌, 等待排入新的牌"))
+	g.sendPhase()
+}
+
+// resume 排入新的牌或賽程結束後, 等待中且四家都已入座的遊戲桌開始下一局
+func (g *Game) resume() {
+	if g.Phase() == PhaseWaitingForPlayers && g.roomManager.isGameStart() {
+		go g.roomManager.SendGameStart()
+	}
+}
+
+// sessionReport 複式賽程中回報本局結果與各家玩家, 四家PASS時result為nil
+func (g *Game) sessionReport(players [4]string, result *GameResult) {
+	if s := g.Session(); s != nil {
+		s.report(g, g.engine.board, players, result)
+	}
+}
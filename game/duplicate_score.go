@@ -0,0 +1,218 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// 複式比賽計分: 同一副牌多桌結果的比較計分, 配對賽以比分(Matchpoints), 以及IMP換算(隊制賽, Butler計分)
+
+// SessionScoring 複式賽程計分方式
+type SessionScoring uint8
+
+const (
+	ScoringMatchpoints SessionScoring = iota //比分(配對賽)
+	ScoringButler                            //Butler: 與平均分(datum)比較換算IMP
+	ScoringIMP                               //與其他每一桌比較換算IMP後平均(Cross-IMP), 兩桌時即直接比較(隊制賽)
+)
+
+func (s SessionScoring) String() string {
+	switch s {
+	case ScoringMatchpoints:
+		return "Matchpoints"
+	case ScoringButler:
+		return "Butler"
+	case ScoringIMP:
+		return "IMP"
+	}
+	return "SessionScoring(?)"
+}
+
+// impTable 標準IMP換算表, 分差小於等於 impTable[i] 時得 i IMP, 超過最後一格得24 IMP
+var impTable = [24]int32{10, 40, 80, 120, 160, 210, 260, 310, 360, 420, 490, 590, 740, 890, 1090, 1290, 1490, 1740, 1990, 2240, 2490, 2990, 3490, 3990}
+
+// IMP 分差(diff)換算IMP, 負分差回傳負IMP
+func IMP(diff int32) int32 {
+	sign := int32(1)
+	if diff < 0 {
+		sign, diff = -1, -diff
+	}
+	imp := int32(sort.Search(len(impTable), func(i int) bool { return diff <= impTable[i] }))
+	return sign * imp
+}
+
+// Matchpoints 同一副牌南北得分(scores)的比分, 每贏一桌得1分,平手得0.5分, top為滿分(桌數-1); 東西比分為 top-南北比分
+func Matchpoints(scores []int32) (mp []float64, top float64) {
+	mp = make([]float64, len(scores))
+	for i, a := range scores {
+		for j, b := range scores {
+			switch {
+			case i == j:
+			case a > b:
+				mp[i] += 1
+			case a == b:
+				mp[i] += 0.5
+			}
+		}
+	}
+	if len(scores) > 0 {
+		top = float64(len(scores) - 1)
+	}
+	return
+}
+
+// CrossIMP 同一副牌南北得分(scores)與其他每一桌比較換算IMP後取平均, 兩桌時即兩桌直接比較; 只有一桌時為0
+func CrossIMP(scores []int32) (imps []float64) {
+	imps = make([]float64, len(scores))
+	if len(scores) < 2 {
+		return imps
+	}
+	for i, a := range scores {
+		var sum int32
+		for j, b := range scores {
+			if i != j {
+				sum += IMP(a - b)
+			}
+		}
+		imps[i] = math.Round(float64(sum)/float64(len(scores)-1)*100) / 100
+	}
+	return imps
+}
+
+// Butler 同一副牌南北得分(scores)與平均分(datum)比較換算的IMP, 四桌以上時平均分去掉最高與最低分, 平均分取整到10分
+func Butler(scores []int32) (imps []int32, datum int32) {
+	if len(scores) == 0 {
+		return nil, 0
+	}
+	sorted := append([]int32(nil), scores...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if len(sorted) >= 4 {
+		sorted = sorted[1 : len(sorted)-1]
+	}
+	var sum int64
+	for _, score := range sorted {
+		sum += int64(score)
+	}
+	datum = int32(math.Round(float64(sum)/float64(len(sorted))/10) * 10)
+
+	imps = make([]int32, len(scores))
+	for i, score := range scores {
+		imps[i] = IMP(score - datum)
+	}
+	return imps, datum
+}
+
+type (
+	// SessionStanding 賽程中一組配對的成績
+	SessionStanding struct {
+		Rank    int     `json:"rank"`
+		Pair    string  `json:"pair"`
+		Boards  int     `json:"boards"`  //已計分牌數
+		Points  float64 `json:"points"`  //比分或IMP總分
+		Percent float64 `json:"percent"` //比分百分比(Butler計分為0)
+		top     float64 //比分滿分總和
+	}
+
+	// Leaderboard 賽程成績排名, Final表示賽程結束的最終排名
+	Leaderboard struct {
+		Session   string            `json:"session"`
+		Scoring   string            `json:"scoring"`
+		Boards    int               `json:"boards"` //所有遊戲桌都打完的牌數
+		Standings []SessionStanding `json:"standings"`
+		Final     bool              `json:"final"`
+	}
+)
+
+// scoreBoard 所有遊戲桌打完一副牌後依計分方式計算各桌南北,東西配對的得分
+func (s *DuplicateSession) scoreBoard(results []*TableResult) {
+	scores := make([]int32, len(results))
+	for i, r := range results {
+		scores[i] = r.NS
+	}
+
+	switch s.scoring {
+	case ScoringIMP:
+		imps := CrossIMP(scores)
+		for i, r := range results {
+			r.NSPoints, r.EWPoints = imps[i], -imps[i]
+			s.addStanding(r.NSPair, r.NSPoints, 0)
+			s.addStanding(r.EWPair, r.EWPoints, 0)
+		}
+	case ScoringButler:
+		imps, _ := Butler(scores)
+		for i, r := range results {
+			r.NSPoints, r.EWPoints = float64(imps[i]), float64(-imps[i])
+			s.addStanding(r.NSPair, r.NSPoints, 0)
+			s.addStanding(r.EWPair, r.EWPoints, 0)
+		}
+	default:
+		mp, top := Matchpoints(scores)
+		for i, r := range results {
+			r.NSPoints, r.EWPoints = mp[i], top-mp[i]
+			s.addStanding(r.NSPair, r.NSPoints, top)
+			s.addStanding(r.EWPair, r.EWPoints, top)
+		}
+	}
+	s.scored++
+}
+
+// addStanding 累計配對(pair)的得分
+func (s *DuplicateSession) addStanding(pair string, points, top float64) {
+	standing := s.standings[pair]
+	if standing == nil {
+		standing = &SessionStanding{Pair: pair}
+		s.standings[pair] = standing
+	}
+	standing.Boards++
+	standing.Points += points
+	standing.top += top
+	if standing.top > 0 {
+		standing.Percent = math.Round(standing.Points/standing.top*10000) / 100
+	}
+}
+
+// leaderboard 目前成績排名, 比分以百分比排名, Butler與IMP以IMP總分排名
+func (s *DuplicateSession) leaderboard(final bool) Leaderboard {
+	board := Leaderboard{
+		Session:   s.name,
+		Scoring:   s.scoring.String(),
+		Boards:    s.scored,
+		Standings: make([]SessionStanding, 0, len(s.standings)),
+		Final:     final,
+	}
+	for _, standing := range s.standings {
+		board.Standings = append(board.Standings, *standing)
+	}
+	value := func(st SessionStanding) float64 {
+		if s.scoring == ScoringMatchpoints {
+			return st.Percent
+		}
+		return st.Points
+	}
+	sort.Slice(board.Standings, func(i, j int) bool {
+		a, b := board.Standings[i], board.Standings[j]
+		if value(a) != value(b) {
+			return value(a) > value(b)
+		}
+		return a.Pair < b.Pair
+	})
+	for i := range board.Standings {
+		board.Standings[i].Rank = i + 1
+		if i > 0 && value(board.Standings[i]) == value(board.Standings[i-1]) {
+			board.Standings[i].Rank = board.Standings[i-1].Rank
+		}
+	}
+	return board
+}
+
+// Leaderboard 賽程目前成績排名, 賽程結束後為最終排名
+func (s *DuplicateSession) Leaderboard() Leaderboard {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return s.leaderboard(true)
+	default:
+		return s.leaderboard(false)
+	}
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIMP(t *testing.T) {
+	tests := []struct {
+		diff int32
+		want int32
+	}{
+		{0, 0},
+		{10, 0},
+		{20, 1},
+		{40, 1},
+		{50, 2},
+		{420, 9},
+		{430, 10},
+		{620, 12},
+		{1430, 16},
+		{3990, 23},
+		{4000, 24},
+		{7600, 24},
+		{-50, -2},
+		{-620, -12},
+	}
+	for _, tt := range tests {
+		if got := IMP(tt.diff); got != tt.want {
+			t.Errorf("IMP(%d) = %d, want %d", tt.diff, got, tt.want)
+		}
+	}
+}
+
+func TestCrossIMP(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int32
+		want   []float64
+	}{
+		{"沒有結果", nil, []float64{}},
+		{"只有一桌", []int32{420}, []float64{0}},
+		{"兩桌直接比較", []int32{420, -50}, []float64{10, -10}},
+		{"三桌", []int32{420, 420, -50}, []float64{5, 5, -10}},
+		{"四桌平均取到小數兩位", []int32{620, 170, 140, -100}, []float64{10.67, -0.67, -1.67, -8.33}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CrossIMP(tt.scores); !slices.Equal(got, tt.want) {
+				t.Errorf("CrossIMP(%v) = %v, want %v", tt.scores, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestButler(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int32
+		imps   []int32
+		datum  int32
+	}{
+		{"兩桌", []int32{420, -50}, []int32{6, -6}, 190},
+		{"四桌去掉最高與最低分", []int32{620, 170, 140, -100}, []int32{10, 0, -1, -6}, 160},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imps, datum := Butler(tt.scores)
+			if datum != tt.datum || !slices.Equal(imps, tt.imps) {
+				t.Errorf("Butler(%v) = %v, %d, want %v, %d", tt.scores, imps, datum, tt.imps, tt.datum)
+			}
+		})
+	}
+}
+
+func TestMatchpoints(t *testing.T) {
+	mp, top := Matchpoints([]int32{420, 420, -50, 100})
+	if want := []float64{2.5, 2.5, 0, 1}; top != 3 || !slices.Equal(mp, want) {
+		t.Errorf("Matchpoints() = %v, %v, want %v, 3", mp, top, want)
+	}
+}
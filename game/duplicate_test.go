@@ -0,0 +1,143 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testSession 兩桌以種子1打boards副牌的複式賽程
+func testSession(t *testing.T, boards uint32) (*DuplicateSession, *Game, *Game) {
+	t.Helper()
+	a, b := testGame(t, "table1"), testGame(t, "table2")
+	s, err := NewDuplicateSession("session", 1, boards, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, a, b
+}
+
+// startHands 遊戲桌(g)開始新的一局, 回傳發出的四家手牌(東,南,西,北)
+func startHands(t *testing.T, g *Game) (hands [4][]uint8) {
+	t.Helper()
+	if _, ok := g.start(); !ok {
+		t.Fatalf("%s start() 沒有發牌", g.name)
+	}
+	for idx, seat := range playerSeats {
+		hands[idx] = append([]uint8(nil), g.deckInPlay[seat][:]...)
+	}
+	return
+}
+
+func TestNewDuplicateSession(t *testing.T) {
+	a, b := testGame(t, "table1"), testGame(t, "table2")
+	if _, err := NewDuplicateSession("session", 1, 2, a); !errors.Is(err, ErrSession) {
+		t.Errorf("一桌 error = %v, want %v", err, ErrSession)
+	}
+	if _, err := NewDuplicateSession("session", 1, 0, a, b); !errors.Is(err, ErrSession) {
+		t.Errorf("沒有牌 error = %v, want %v", err, ErrSession)
+	}
+
+	s, err := NewDuplicateSession("session", 1, 2, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//遊戲桌不能同時在其他賽程, 失敗時已加入的遊戲桌退出
+	c := testGame(t, "table3")
+	if _, err := NewDuplicateSession("other", 1, 2, c, b); !errors.Is(err, ErrSession) {
+		t.Errorf("遊戲桌已在其他賽程 error = %v, want %v", err, ErrSession)
+	}
+	if c.Session() != nil || b.Session() != s {
+		t.Errorf("加入失敗後 table3 賽程 %v, table2 賽程 %v", c.Session(), b.Session())
+	}
+
+	s.Close()
+	if a.Session() != nil || b.Session() != nil {
+		t.Error("賽程結束後遊戲桌仍在賽程中")
+	}
+}
+
+func TestDuplicateSessionDeals(t *testing.T) {
+	_, a, b := testSession(t, 2)
+
+	//兩桌打相同的牌
+	first := startHands(t, a)
+	if !reflect.DeepEqual(first, startHands(t, b)) {
+		t.Error("兩桌第1副牌不同")
+	}
+	if a.Board().Number != 1 || a.Board().Seed() == "" || a.Board() != b.Board() {
+		t.Errorf("牌號 %+v, %+v, want 1", a.Board(), b.Board())
+	}
+
+	//沒有回報結果(中斷)時重打同一副牌
+	if !reflect.DeepEqual(first, startHands(t, a)) || a.Board().Number != 1 {
+		t.Errorf("中斷後重打第%d副牌, want 1", a.Board().Number)
+	}
+
+	a.sessionReport([4]string{"e", "s", "w", "n"}, &GameResult{NS: 420, EW: -420})
+	second := startHands(t, a)
+	if a.Board().Number != 2 || reflect.DeepEqual(first, second) {
+		t.Errorf("回報後打第%d副牌, want 2", a.Board().Number)
+	}
+
+	//打完排入的牌, 不發牌
+	a.sessionReport([4]string{"e", "s", "w", "n"}, nil)
+	if _, ok := a.start(); ok {
+		t.Error("打完所有牌後 start() 仍發牌")
+	}
+}
+
+func TestDuplicateSessionReport(t *testing.T) {
+	s, a, b := testSession(t, 1)
+	startHands(t, a)
+	startHands(t, b)
+
+	a.sessionReport([4]string{"e1", "s1", "w1", "n1"}, &GameResult{NS: 420, EW: -420})
+	//重複回報與不是目前這副牌的回報不算
+	a.sessionReport([4]string{"e1", "s1", "w1", "n1"}, &GameResult{NS: -50, EW: 50})
+	b.engine.board = newBoard(2)
+	b.sessionReport([4]string{"e2", "s2", "w2", "n2"}, &GameResult{NS: -50, EW: 50})
+	b.engine.board = newBoard(1)
+
+	//另一桌還沒打完, 結果不計分, 牌局不能公開
+	if results, complete := s.Results(1); complete || len(results) != 1 || results[0].NS != 420 {
+		t.Fatalf("Results(1) = %+v, %t, want 一桌, 未完成", results, complete)
+	}
+	if a.HandReleased(1) {
+		t.Error("另一桌還沒打完, 牌局已公開")
+	}
+
+	b.sessionReport([4]string{"e2", "s2", "w2", "n2"}, &GameResult{NS: 400, EW: -400})
+	results, complete := s.Results(1)
+	if !complete || len(results) != 2 || results[0].Room != "table1" || results[1].Room != "table2" {
+		t.Fatalf("Results(1) = %+v, %t, want 兩桌依南北得分排序", results, complete)
+	}
+	if results[0].NSPoints <= results[1].NSPoints || results[0].NSPair != pairName("n1", "s1") {
+		t.Errorf("計分 %+v", results)
+	}
+
+	//所有牌打完, 賽程結束, 遊戲桌恢復一般發牌
+	select {
+	case <-s.Done():
+	default:
+		t.Fatal("所有牌打完後賽程沒有結束")
+	}
+	if a.Session() != nil || !a.HandReleased(1) {
+		t.Errorf("賽程結束後 賽程 %v 公開 %t", a.Session(), a.HandReleased(1))
+	}
+}
+
+func TestDuplicateSessionWaitForBoards(t *testing.T) {
+	//該桌沒有待打的牌時不發牌, 回到等待
+	s, a, _ := testSession(t, 1)
+	startHands(t, a)
+	a.sessionReport([4]string{"e", "s", "w", "n"}, nil)
+
+	a.mu.Lock()
+	a.roomManager.sendGameStart()
+	a.mu.Unlock()
+	if a.Phase() != PhaseWaitingForPlayers || a.Session() != s {
+		t.Errorf("沒有待打的牌 階段 %s, want %s", a.Phase(), PhaseWaitingForPlayers)
+	}
+}
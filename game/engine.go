@@ -3,14 +3,8 @@ package game
 import (
 	"fmt"
 	"log/slog"
-	"math/rand"
 )
 
-// 隨機開叫產出 TODO:
-func randomSeat() uint8 {
-	return playerSeats[rand.Int31n(4)]
-}
-
 // isPassBid 叫品是否是PASS叫品
 func isZeroBidOrPassBid(value8 uint8) bool {
 	// is Zero Bid
@@ -27,12 +21,20 @@ type Engine struct {
 	bidHistory *bidHistory
 	bidOrder   *[4]uint32
 
+	//本局牌號,發牌者與身價, StartBid 設定
+	board Board
+
 	//底下三個在競叫底定,遊戲開始前 SetGamePlayInfo 設定
-	trumpRange CardRange //王張區間,首引
-	declarer   CbSeat    //本局莊家,計算GameResult會用到
-	dummy      CbSeat    //本局夢家,計算GameResult會用到
+	trumpRange CardRange  //王張區間,首引
+	declarer   CbSeat     //本局莊家,計算GameResult會用到
+	dummy      CbSeat     //本局夢家,計算GameResult會用到
+	contract   record     //本局合約,計算GameResult會用到
+	auction    []*bidItem //本局合約確定時的競叫紀錄(bidHistory會被清除,所以另存一份)
 	//.................................................
 
+	//吃墩帳本,記錄每一墩首打,贏家,四家出牌, 計算GameResult會用到
+	ledger *trickLedger
+
 	//表示當前叫牌玩家,或出牌玩家座位
 	currentPlay uint8
 }
@@ -45,6 +47,7 @@ func newEngine() *Engine {
 		trumpRange:  CardRange{},
 		declarer:    seatYet,
 		dummy:       seatYet,
+		ledger:      newTrickLedger(),
 		currentPlay: valueNotSet,
 	}
 
@@ -60,7 +63,21 @@ func (egn *Engine) SetCurrentSeat(seat uint8) {
 }
 
 // ClearGameState Engine 狀態還原
-func (egn *Engine) ClearGameState() {}
+func (egn *Engine) ClearGameState() {
+	egn.declarer = seatYet
+	egn.dummy = seatYet
+	egn.contract = record{dbType: ZeroSuit}
+	egn.auction = nil
+	egn.ledger.clear()
+}
+
+// Auction 本局競叫紀錄, 競叫中回傳目前紀錄, 合約確定後回傳合約確定時的紀錄
+func (egn *Engine) Auction() []*bidItem {
+	if len(egn.bidHistory.h) > 0 {
+		return egn.bidHistory.h
+	}
+	return egn.auction
+}
 
 // ClearBiddingState 競叫底定,四家PASS 或準備重新競叫前執行清除競叫紀錄
 // memo DONE
@@ -82,19 +99,30 @@ func (egn *Engine) GameStartPlayInfo() (lead, declarer, dummy, suit uint8, contr
 	egn.currentPlay = lead
 	egn.declarer = CbSeat(declarer)
 	egn.dummy = CbSeat(dummy)
+	contract.vulnerable = egn.board.Vulnerable.IsVulnerable(egn.declarer)
+	egn.contract = contract
+	egn.auction = append(egn.auction[:0], egn.bidHistory.h...)
 
 	return lead, declarer, dummy, suit, contract, nil
 }
 
+// passBid 與目前最新叫品同線位的PASS叫品
+func (egn *Engine) passBid() uint8 {
+	return uint8(Pass1) + (biddingLine(CbBid(egn.bidHistory.LastBid()))-1)*8
+}
+
 func (egn *Engine) IsBidFinishedOrReBid() (bidComplete bool, needReBid bool) {
 	return egn.bidHistory.IsBidFinishedOrReBid()
 }
 
-// StartBid 初始競叫開始, 設定遊戲開叫順位(bidOrder),回傳首叫與開叫訊號(bidValueLimit)
-func (egn *Engine) StartBid() (nextBidder uint8) {
+// StartBid 初始競叫開始, 依牌局(board)發牌者設定遊戲開叫順位(bidOrder),回傳首叫
+func (egn *Engine) StartBid(board Board) (nextBidder uint8) {
 
-	// 重要  叫品首開叫, 重要: 前端以zeroBid來判斷是不是首叫開始
-	nextBidder = randomSeat()
+	egn.board = board
+
+	// 重要  叫品首開叫(牌號決定的發牌者), 重要: 前端以zeroBid來判斷是不是首叫開始
+	nextBidder = board.Dealer
+	egn.currentPlay = nextBidder
 
 	//重要 競叫歷史紀錄設定 - 首叫
 	egn.bidOrder[0] = uint32(nextBidder)
@@ -121,9 +149,18 @@ func (egn *Engine) StartBid() (nextBidder uint8) {
 	return
 }
 
-func (egn *Engine) GetNextBid(seat, bid uint8) (history []*bidItem, nextBiddingLimit uint8, db DoubleButton, db2 DoubleButton) {
+// GetNextBid 競叫,叫者必須是當前叫牌者(currentPlay)且叫品合法,否則回傳 ErrBiddingInvalid 且不改變競叫狀態
+func (egn *Engine) GetNextBid(seat, bid uint8) (history []*bidItem, nextBiddingLimit uint8, db DoubleButton, db2 DoubleButton, err error) {
 
-	bidding := egn.bidHistory.Bid(seat, bid)
+	if seat != egn.currentPlay {
+		err = fmt.Errorf("%w: 輪到 %s 叫牌, 但 %s 叫牌", ErrBiddingInvalid, CbSeat(egn.currentPlay), CbSeat(seat))
+		return
+	}
+
+	bidding, err := egn.bidHistory.Bid(seat, bid)
+	if err != nil {
+		return
+	}
 
 	nextBiddingLimit = egn.bidHistory.LastBid()
 	db = DoubleButton{}
@@ -158,6 +195,34 @@ func (egn *Engine) GetNextBid(seat, bid uint8) (history []*bidItem, nextBiddingL
 	return
 }
 
+// UndoBid 撤回最後一個叫品, 叫者成為當前叫牌者; 回傳撤回的叫品, 以及撤回後(同 GetNextBid)的競叫紀錄,禁叫品項與Double按鈕狀態
+func (egn *Engine) UndoBid() (undone *bidItem, history []*bidItem, nextBiddingLimit uint8, db DoubleButton, db2 DoubleButton) {
+	if undone = egn.bidHistory.undo(); undone == nil {
+		return
+	}
+	egn.currentPlay = undone.who()
+
+	nextBiddingLimit = egn.bidHistory.LastBid()
+	history = egn.bidHistory.h
+	if len(history) == 0 {
+		return
+	}
+
+	//依撤回後最後一個叫品還原下一個叫者的Double按鈕
+	last := history[len(history)-1]
+	if !last.isCrucial() {
+		return
+	}
+	db.value, db2.value = GetDoubleAtSameLine(last.bid())
+	switch {
+	case !last.isDouble():
+		db.isOn = true
+	case last.dbType == DOUBLE:
+		db2.isOn = true
+	}
+	return
+}
+
 /* ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ ♣️♦️♥️♠️ */
 
 // playOrder 從四家的出牌,找出第一個出牌者,及另外三家出牌
@@ -333,6 +398,9 @@ func (egn *Engine) GetPlayResult(eastCard, southCard, westCard, northCard uint8,
 
 	slog.Warn("回合結果", slog.String(".", fmt.Sprint("FYI", fmt.Sprintf("王牌:%s  東: %s 南: %s  西: %s  北: %s  , 最後誰贏:%s", gameSuit, CbCard(eastCard), CbCard(southCard), CbCard(westCard), CbCard(northCard), CbSeat(winner)))))
 
+	//記錄該墩,首打是最後出牌者(currentPlay)的下一家
+	egn.ledger.record(playerSeats[(seatIndex(egn.currentPlay)+1)%4], winner, eastCard, southCard, westCard, northCard)
+
 	// winner為下一輪首打者
 	//egn.locker.Lock()
 	egn.currentPlay = winner
@@ -354,8 +422,23 @@ func (egn *Engine) GetCardSound(card uint8) string {
 	return "card-play"
 }
 
-// GetGameResult 本局遊戲結果
-func (egn *Engine) GetGameResult() {
-	//TODO implement me
-	panic("implement me")
+// TrickTally 目前南北,東西吃墩數,已完成墩數與最後一墩贏家
+func (egn *Engine) TrickTally() (tally TrickTally) {
+	tally.NS, tally.EW = egn.ledger.sideTricks()
+	tally.Tricks = egn.ledger.played()
+	tally.Winner = valueNotSet
+	if last := egn.ledger.last(); last != nil {
+		tally.Winner = uint8(last.winner)
+	}
+	return
+}
+
+// declarerTricks 莊家方(莊,夢)吃到的墩數
+func (egn *Engine) declarerTricks() uint8 {
+	return egn.ledger.seatTricks(uint8(egn.declarer)) + egn.ledger.seatTricks(uint8(egn.dummy))
+}
+
+// GetGameResult 本局遊戲結果,以合約與莊家方吃到的墩數計分
+func (egn *Engine) GetGameResult() *GameResult {
+	return scoring(egn.declarer, egn.contract, egn.declarerTricks(), egn.contract.vulnerable)
 }
@@ -25,13 +25,19 @@ const (
 	KeyZone string = "ZONE"
 	// KeyGame 用於記錄(檢驗)使用者是否不正常斷線, KeyGame若存在應該會與KeyZone同值 表示玩家是否在遊戲中 (PlayerJoin設定),(PlayerLeave取消)
 	KeyGame string = "GAME_SEAT"
+	// KeyMember 房間成員通行碼 (UserJoin設定),(UserLeave取消並撤銷)
+	KeyMember string = "MEMBER_TOKEN"
+	// KeyCredential 使用者出示的房間憑證(RoomCredential) (UserPrivateCredential設定),(UserLeave取消)
+	KeyCredential string = "CREDENTIAL"
 	// KeyPlayRole 儲存/移除遊戲中各家的角色用於 Connection Store
 	KeyPlayRole string = "ROLE"
 )
 
 const (
-	// GamePlayCountDown 遊戲中,玩家叫/出牌時間, 未來(從DB撈取)依附在RoomUser中
+	// GamePlayCountDown 遊戲中,玩家叫/出牌時間預設值, 各房間可透過 Game.SetCountDown 設定
 	GamePlayCountDown uint32 = 30
+	// GameReconnectGrace 遊戲中玩家斷線,保留座位等待重新連線的時間(秒)預設值, 各房間可透過 Game.SetReconnectGrace 設定
+	GameReconnectGrace uint32 = 60
 )
 
 type SeatStatusAndGameStart uint8
@@ -300,6 +306,11 @@ const (
 	_GetZoneUsers                      //請求撈出Zone中的觀眾使用者,也包含四家玩者
 	_FindPlayer                        //請求找尋指定玩家連線
 	_GetTableInfo                      //請求取得房間觀眾,空位起點依序的玩家座位
+	_ReserveSeat                       //玩家斷線,保留座位給該玩家(以座位憑證識別)
+	_ReleaseSeat                       //保留座位逾時,釋放座位
+	_AddRobot                          //機器人入座空位
+	_RemoveRobot                       //機器人離座
+	_TakeoverSeat                      //保留座位逾時,由機器人接手
 )
 
 // GetPartnerByPlayerSeat 以玩家座位,取得夥伴座位
@@ -335,11 +346,33 @@ type (
 		// 莊家打出夢家的牌, Zone8=(莊家), PlaySeat8=(夢家)
 		PlaySeat8      uint8
 		IsClientBroken bool //是否不正常離線(在KickOutBrokenConnection 設定)
+
+		// ConventionCard 玩家登記的約定卡(TablePrivateConventionCard), pb.PlayingUser沒有約定卡欄位
+		ConventionCard *ConventionCard
+		// Credential 使用者出示的房間憑證(UserPrivateCredential), pb.PlayingUser沒有憑證欄位
+		Credential RoomCredential
+		// Alert 叫者警示此叫品, Explanation 為說明 (GamePrivateAlertBid), pb.PlayingUser沒有警示欄位
+		Alert       bool
+		Explanation string
+
+		auto  bool  //伺服器計時到期,代替玩家自動叫牌/出牌
+		robot Robot //機器人玩家(沒有NsConn), nil表示真人
 	}
 
 	Audiences []*RoomUser //代表非玩家的旁賽者
+
+	// RoomRequest 以json送出的請求(pb.PlayingUser沒有對應欄位的事件)共用的使用者欄位
+	RoomRequest struct {
+		Name string `json:"name"`
+		Zone uint8  `json:"zone"`
+	}
 )
 
+// PlayingUser 請求的使用者欄位轉成 pb.PlayingUser
+func (r RoomRequest) PlayingUser() *pb.PlayingUser {
+	return &pb.PlayingUser{Name: r.Name, Zone: uint32(r.Zone)}
+}
+
 func (ru *RoomUser) Ticket() {
 	ru.TicketTime = pb.LocalTimestamp(time.Now())
 }
@@ -8,22 +8,78 @@ import (
 var (
 	ErrRoomFull         = errors.New("房間人數上限,無法再進入")
 	ErrUserInRoom       = errors.New("玩家已經在房間")
+	ErrRoomClosed       = errors.New("房間已關閉")
+	ErrRoomPassword     = errors.New("私人房間密碼錯誤")
+	ErrNoSpectators     = errors.New("房間不開放觀戰,座位已滿")
+	ErrRoomConfig       = errors.New("房間設定錯誤")
+	ErrRoomExists       = errors.New("房間已存在")
+	ErrRoomBusy         = errors.New("房間還有使用者或在賽程中,無法關閉")
 	ErrUserInPlay       = errors.New("玩家正在遊戲中")
 	ErrUserNotFound     = errors.New("玩家不存在")
 	ErrUserNotInPlay    = errors.New("玩家已離開遊戲,或不在遊戲中")
 	ErrPlayMultipleGame = errors.New("不能同時多局遊戲")
 	ErrGameSeatFull     = errors.New("遊戲桌已滿,你晚了一步")
 	ErrGameStart        = errors.New("遊戲已經開始")
+	ErrGamePhase        = errors.New("遊戲階段不符")
+	ErrRobotSeat        = errors.New("座位不是空位,機器人無法入座")
+	ErrRobotNotFound    = errors.New("座位上沒有機器人")
 
 	ErrUnknownBid = errors.New("不知名叫品")
 	ErrUnContract = errors.New("合約尚未確定")
 
+	ErrHandNotFound    = errors.New("牌局紀錄不存在")
+	ErrHandNotReleased = errors.New("賽程中其他遊戲桌尚未打完這副牌")
+	ErrNotRoomMember   = errors.New("不是房間成員")
+	ErrDealSeed        = errors.New("發牌種子不合法")
+	ErrSession         = errors.New("複式賽程設定錯誤")
+	ErrMovement        = errors.New("移位設定錯誤")
+)
+
+// PBN 格式不合法
+var (
+	ErrPBNCard = errors.New("PBN牌不合法")
+	ErrPBNSeat = errors.New("PBN座位不合法")
+	ErrPBNBid  = errors.New("PBN叫品不合法")
+	ErrPBNDeal = errors.New("PBN牌局不合法")
+
 	ErrClientBrokenOrRefresh = errors.New("client connection had broken, or browser refresh")
 	ErrConn                  = errors.New("(Server or 網路)連線問題無法送出")
 )
 
 var (
 	ErrBiddingInvalid = errors.New("叫品不合法")
+	ErrSeatMismatch   = errors.New("請求的座位不是連線入座的座位")
+)
+
+// 出牌不合法
+var (
+	ErrPlaySeat      = errors.New("出牌者不是該座位玩家")
+	ErrPlayOutOfTurn = errors.New("尚未輪到出牌")
+	ErrPlayNotHeld   = errors.New("手上沒有這張牌")
+	ErrPlayRevoke    = errors.New("有首打花色必須跟牌")
+	ErrPlayDummy     = errors.New("夢家的牌只能由莊家打出")
+)
+
+// 攤牌宣告不合法
+var (
+	ErrClaimSeat    = errors.New("只有莊家與防家可以宣告攤牌")
+	ErrClaimTricks  = errors.New("宣告墩數超過剩餘墩數")
+	ErrClaimPending = errors.New("攤牌宣告等待回覆中")
+	ErrClaimNone    = errors.New("沒有待回覆的攤牌宣告")
+	ErrClaimReply   = errors.New("只有宣告者的對手可以回覆攤牌宣告")
+
+	ErrUndoDisabled    = errors.New("房間不允許撤回")
+	ErrUndoNone        = errors.New("沒有可以撤回的叫品或出牌")
+	ErrUndoOpeningLead = errors.New("首引後夢家已攤牌, 首引不能撤回")
+	ErrUndoPending     = errors.New("撤回請求等待回覆中")
+	ErrUndoReply       = errors.New("只有撤回者的對手可以回覆撤回請求")
+
+	ErrAlertCall   = errors.New("找不到詢問的叫品")
+	ErrAlertAsk    = errors.New("只能詢問對手的叫品")
+	ErrAlertNone   = errors.New("沒有待回答的詢問")
+	ErrAlertAnswer = errors.New("只有叫者方可以回答詢問")
+
+	ErrConventionCard = errors.New("約定卡內容不正確")
 )
 
 /*============================================================================================*/
@@ -2,9 +2,12 @@ package game
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/moszorn/pb"
@@ -73,6 +76,55 @@ type (
 		//首引產生以及每回合首打產生時會計算(SetRoundAvailableRange)該回合可出牌區間最大值,最小值
 		roundMax uint8
 		roundMin uint8
+
+		// 牌號計數器,每次發牌(含四家PASS重發)加一,決定發牌者與身價
+		boardNumber uint32
+
+		// mu 序列化玩家叫牌,出牌與伺服器計時自動動作
+		mu sync.Mutex
+
+		// 玩家叫/出牌時間(秒), 輪次計時序號(每次開始或取消計時加一,過期的計時不執行)
+		countDown atomic.Uint32
+		turnSeq   atomic.Uint64
+		lastTurn  atomic.Pointer[pendingTurn] //目前計時中的輪次
+		paused    atomic.Int32                //暫停計時數(斷線保留中的座位數)
+
+		// 斷線保留座位時間(秒), 0表示不保留
+		reconnectGrace atomic.Uint32
+		robotTakeover  atomic.Bool //斷線保留座位逾時後,由機器人接手
+
+		// 遊戲目前階段(GamePhase), 只能透過 transit 轉換
+		phase atomic.Uint32
+
+		// 牌局紀錄儲存, nil表示不儲存
+		handStore atomic.Pointer[HandStore]
+		lastHand  atomic.Pointer[HandRecord] //最近一局結算的牌局紀錄
+
+		// 待發的匯入牌局(PresetDeals), 有待發牌局時以匯入的牌發牌取代洗牌
+		presets presetDeals
+		// 發牌者, 預設 NewCryptoDealer
+		dealer atomic.Pointer[Dealer]
+		// 所在的複式賽程, nil表示不在賽程中
+		session atomic.Pointer[DuplicateSession]
+		// 所在的隊制賽, nil表示不在隊制賽中
+		teamMatch atomic.Pointer[TeamMatch]
+		// 指定座位的玩家(隊制賽), nil表示任何人都可以入座任何空位
+		seatPlan atomic.Pointer[SeatPlan]
+		// 待回覆的攤牌宣告(認輸), nil表示沒有
+		claim atomic.Pointer[pendingClaim]
+		// 待回答的叫品詢問(詢問者座位為Key), 只能在遊戲鎖(mu)內存取
+		alertAsks map[uint8]*alertAsk
+		// 待回覆的撤回請求, nil表示沒有
+		undo         atomic.Pointer[pendingUndo]
+		undoDisabled atomic.Bool //房間不允許撤回(比賽用)
+		// 房間設定(不開放觀戰,私人房間密碼,計分方式), nil表示預設
+		config atomic.Pointer[RoomConfig]
+		closed atomic.Bool //房間已關閉
+		// 房間成員通行碼(token)對應姓名, 進入房間時核發, 離開房間時撤銷
+		members sync.Map
+		// 配對登記的約定卡(登記玩家名稱為Key)
+		cardsMu         sync.RWMutex
+		conventionCards map[string]*ConventionCard
 	}
 )
 
@@ -93,7 +145,14 @@ func CreateCBGame(log *utilog.MyLog, pid context.Context, counter UserCounter, t
 
 		roundMax: spadeAce,
 		roundMin: club2,
+
+		alertAsks:       make(map[uint8]*alertAsk, 2),
+		conventionCards: make(map[string]*ConventionCard, 4),
 	}
+	g.countDown.Store(GamePlayCountDown)
+	g.reconnectGrace.Store(GameReconnectGrace)
+	g.SetDealer(NewCryptoDealer())
+
 	//新的一副牌
 	NewDeck(g)
 
@@ -117,9 +176,14 @@ func (g *Game) Start() {
 
 // Close 關閉關閉, 同時關閉RoomManager
 func (g *Game) Close() {
+	g.closed.Store(true)
+
 	//關閉RoomManager資源
 	g.Shutdown()
 
+	//取消輪次計時
+	g.stopTurnTimer()
+
 	//TODO 釋放與Game有關的資源
 	// ... goes here
 }
@@ -138,11 +202,40 @@ func (g *Game) SeatShift(seat uint8) (nextSeat uint8) {
 }
 
 // start 開始遊戲,這個method會進行洗牌,並引擎記錄該局叫牌順序, bidder競叫者,zeroBidding競叫初始值
-func (g *Game) start() (currentPlayer uint8) {
-	//洗牌
-	Shuffle(g)
+func (g *Game) start() (currentPlayer uint8, ok bool) {
+	//下一副牌號,決定開叫者與身價
+	board := newBoard(g.boardNumber + 1)
+
+	//洗牌, 複式賽程中依賽程發牌(該桌沒有待打的牌時不發牌), 有待發的匯入牌局時依匯入的牌發牌
+	if s := g.Session(); s != nil {
+		deal, ok := s.nextDeal(g)
+		if !ok {
+			return valueNotSet, false
+		}
+		DealHands(g, deal.Hands)
+		board = deal.Board
+	} else if deal, ok := g.nextPresetDeal(); ok {
+		DealHands(g, deal.Hands)
+		board = deal.board(board)
+	} else {
+		board.seed = Shuffle(g, board.Number)
+	}
+	g.boardNumber++
+
+	//清除上一局(或中斷)的競叫,合約,吃墩與桌面出牌紀錄
+	g.engine.ClearBiddingState()
+	g.engine.ClearGameState()
+	g.resetPlayCardRecord()
+	g.countingInPlayCard = 0
+	g.clearClaim()
+	g.clearUndo()
 
-	return g.engine.StartBid()
+	return g.engine.StartBid(board), true
+}
+
+// Board 本局牌號,發牌者與身價
+func (g *Game) Board() Board {
+	return g.engine.board
 }
 
 // GetBidOrder 執行GetBidOrder,必須是遊戲第一次開叫之後,也就是 engine的 StartBid已經被呼叫之後
@@ -157,8 +250,8 @@ func (g *Game) KickOutBrokenConnection(ns *skf.NSConn) {
 
 // UserJoin 使用者進入房間,參數user必須有*skf.NSConn, userName, userZone,底層會送出 TableInfo
 func (g *Game) UserJoin(user *RoomUser) {
-	//TODO: 需要從engine取出當前遊戲狀態,並一併傳入roomManager.UserJoin回送給User
 	// 回送給加入者訊息是RoomInfo (UserPrivateTableInfo)詢問房間人數,桌面狀態,座位狀態 (何時執行:剛進入房間時)
+	// 當前遊戲狀態(TableSnapshot)於 UserJoinTableInfo 一併送出
 	go g.roomManager.UserJoin(user)
 }
 
@@ -218,7 +311,8 @@ func (g *Game) SetGamePlayInfo(declarer, dummy, firstLead, kingSuit uint8) {
 	}
 }
 
-func bidHistoryItemsToProto(items []*bidItem) *cb.BidHistoryBoard {
+// bidHistoryItemsToProto 競叫紀錄轉成 proto, 觀看者(viewer)是叫者的對手時, 警示的叫品加上 alertMark
+func bidHistoryItemsToProto(items []*bidItem, viewer uint8) *cb.BidHistoryBoard {
 
 	fmt.Printf("bidHistoryToProto there are have %d bid item \n", len(items))
 
@@ -262,6 +356,9 @@ func bidHistoryItemsToProto(items []*bidItem) *cb.BidHistoryBoard {
 		}
 
 		suit = fmt.Sprintf("%s", items[idx].value)
+		if items[idx].alert && alertVisible(viewer, items[idx].who()) {
+			suit += alertMark
+		}
 
 		board.Rows[line].Columns = append(board.Rows[line].Columns, &cb.BidHistoryItem{
 			Line:       byPassLineindicator(items[idx].value, items[idx].line),
@@ -282,15 +379,54 @@ func bidHistoryItemsToProto(items []*bidItem) *cb.BidHistoryBoard {
 */
 //
 func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.notyBid(currentBidder)
+}
+
+func (g *Game) notyBid(currentBidder *RoomUser) {
+
+	//座位以連線為準, 錯誤只回覆請求者, 不送給被冒用的座位
+	if err := verifySeat(currentBidder); err != nil {
+		g.log.Wrn("GamePrivateNotyBid", slog.String(".", err.Error()))
+		g.sendUserError(currentBidder, err)
+		return
+	}
+
+	if err := g.inPhase(PhaseBidding); err != nil {
+		g.log.Wrn("GamePrivateNotyBid", slog.String(".", err.Error()))
+		g.sendGameError(currentBidder.Zone8, err)
+		return
+	}
+
+	//撤回請求等待對手回覆中,暫停叫牌
+	if g.undo.Load() != nil {
+		g.sendGameError(currentBidder.Zone8, ErrUndoPending)
+		return
+	}
+
+	bidHistories, nextLimitBidding, db1, db2, err := g.engine.GetNextBid(currentBidder.Zone8, currentBidder.Bid8)
+	if err != nil {
+		//不合法叫品,回覆叫牌者錯誤,遊戲狀態不變
+		g.log.Wrn("GamePrivateNotyBid", slog.String(".", err.Error()))
+		g.sendGameError(currentBidder.Zone8, err)
+		return
+	}
+
+	//叫者警示此叫品(GamePrivateAlertBid), 通知叫者的對手
+	if currentBidder.Alert {
+		item := bidHistories[len(bidHistories)-1]
+		item.setAlert(currentBidder.Explanation)
+		g.sendAlert(item)
+	}
+	g.stopTurnTimer()
 
 	//一被點擊,就停止四家正在執行的gauge
-	err := g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_gauge_stop}, pb.SceneType_game)
+	err = g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_gauge_stop}, pb.SceneType_game)
 	if err != nil {
 		g.log.Wrn(fmt.Sprintf("斷線:%s", err.Error()))
 	}
 
-	bidHistories, nextLimitBidding, db1, db2 := g.engine.GetNextBid(currentBidder.Zone8, currentBidder.Bid8)
-
 	complete, needReBid := g.engine.IsBidFinishedOrReBid()
 
 	var payload = payloadData{PayloadType: ProtobufType}
@@ -311,7 +447,6 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 		//第四個參數: 上一次叫品
 
 		notyBid := cb.NotyBid{
-			BidItems:       bidHistoryItemsToProto(bidHistories),
 			Bidder:         uint32(next),
 			BidStart:       uint32(nextLimitBidding),
 			LastBidderName: fmt.Sprintf("%s-%s", CbSeat(currentBidder.Zone8), currentBidder.Name),
@@ -330,8 +465,6 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 			notyBid.Btn = cb.NotyBid_disable_all
 		}
 
-		payload.ProtoData = &notyBid
-
 		/*TODO 修改:
 		1)送出Public (GameNotyBid)
 		2)送出Private (GamePrivateNotyBid)..................................................
@@ -340,11 +473,7 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 
 		 TODO: 另一種狀況是,玩家離開遊戲桌,也必須告知前端有人離桌,並清空桌面,
 		*/
-		g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameNotyBid, &notyBid, pb.SceneType_game) //廣播Public
-		time.Sleep(time.Millisecond * 400)
-
-		payload.Player = next                                                        //指定傳送給 bidder 開叫
-		g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payload) //私人Private
+		g.sendNotyBid(&notyBid, bidHistories, next) //廣播Public, 再指定傳送給 bidder 開叫(Private)並開始計時
 
 	case true: //競叫完成
 		switch needReBid {
@@ -354,8 +483,20 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 			// moszorn 重要: 一並清除 bidHistories
 			g.engine.ClearBiddingState()
 
+			//複式賽程中四家PASS也是該副牌的結果
+			g.sessionReport(g.seatNames(), nil)
+
+			if err := g.transit(PhaseDealing); err != nil {
+				g.log.Wrn("GamePrivateNotyBid[重新洗牌,重新競叫]", slog.String(".", err.Error()))
+				return
+			}
+
 			// StartOpenBid會更換新一局,因此玩家順序也做了更動
-			bidder := g.start()
+			bidder, ok := g.start()
+			if !ok {
+				g.waitForBoards()
+				return
+			}
 			g.SeatShift(bidder)
 			g.setEnginePlayer(bidder)
 
@@ -381,14 +522,31 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 				g.engine.ClearBiddingState()
 			}
 
-			time.Sleep(time.Second * 1)
-			g.roomManager.SendShowPlayersCardsOut() //四家攤牌
+			g.sendBoard() //新牌號,發牌者與身價
 
-			time.Sleep(time.Second * 3) //三秒後重新發新牌
-			g.roomManager.SendDeal()    //重發牌
-
-			payload.Player = bidder
-			g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payload) //Private 指定傳送給 bidder 開叫
+			//一秒後四家攤牌, 再三秒後重發牌; 延遲期間不持有遊戲鎖, 遊戲中斷則不繼續
+			dealt := g.boardNumber
+			g.afterDeal(dealt, time.Second*1, func() {
+				if g.Phase() != PhaseDealing {
+					return
+				}
+				g.roomManager.SendShowPlayersCardsOut() //四家攤牌
+
+				g.afterDeal(dealt, time.Second*3, func() {
+					if g.Phase() != PhaseDealing {
+						return
+					}
+					g.roomManager.SendDeal() //重發牌
+					if err := g.transit(PhaseBidding); err != nil {
+						g.log.Wrn("GamePrivateNotyBid[重新洗牌,重新競叫]", slog.String(".", err.Error()))
+						return
+					}
+
+					payload.Player = bidder
+					g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payload) //Private 指定傳送給 bidder 開叫
+					g.startBidTimer(bidder)
+				})
+			})
 
 		case false: //競叫完成,遊戲開始
 
@@ -412,6 +570,10 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 				}
 			}
 			g.engine.ClearBiddingState()
+			if err := g.transit(PhaseOpeningLead); err != nil {
+				g.log.Wrn("GamePrivateNotyBid[競叫完成,遊戲開始]", slog.String(".", err.Error()))
+				return
+			}
 
 			// 向前端發送清除Bidding UI, 並停止(terminate)四家gauge, 並補上競叫歷史紀錄最後一個PASS
 			var clearScene = pb.OP{
@@ -426,8 +588,9 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 			//以首引生成 RoundSuit keep
 			//g.roundSuitKeeper = NewRoundSuitKeep(lead)
 
-			//移動環形,並校準座位
-			g.setEnginePlayer(g.SeatShift(lead))
+			//移動環形,並校準座位, 首引為當前出牌者
+			g.SeatShift(lead)
+			g.setEnginePlayer(lead)
 
 			//送出首引封包
 			// 封包位元依序為:首引, 莊家, 夢家, 合約王牌,王牌字串, 合約線位, 線位字串
@@ -449,6 +612,7 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 			//payload.ProtoData = &contractLeading
 			//g.roomManager.SendPayloadTo3PlayersByExclude(ClnRoomEvents.GameFirstLead, payload, lead)
 			g.roomManager.SendPayloadTo3PlayersByExclude(ClnRoomEvents.GameFirstLead, &contractLeading, lead)
+			g.sendBoard() //合約確定,再次告知牌號與身價
 
 			//向夢家亮莊家牌
 			payload.ProtoData = &cb.PlayersCards{
@@ -460,16 +624,21 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 			payload.Player = dummy
 			g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateShowHandToSeat /*向夢家亮莊家的牌*/, payload) //私人Private
 
-			time.Sleep(time.Millisecond * 400)
-
 			//通知首引為下一個出牌者,並開啟其首引gauge與call back
 			leadNotice := new(cb.PlayNotice)
 			leadNotice.Seat = uint32(lead)
 			leadNotice.CardMinValue, leadNotice.CardMaxValue, leadNotice.TimeoutCardValue, _ = g.AvailablePlayerPlayRange(lead, true)
-			leadNotice.NumOfCardPlayHitting = uint32(1) // 首引為第一次點擊
-			payload.ProtoData = leadNotice
-			payload.Player = lead                                                          //傳給首引玩家                                                      //指定傳送給 bidder 開叫
-			g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateFirstLead, payload) //私人Private
+			leadNotice.NumOfCardPlayHitting = uint32(1)                                                // 首引為第一次點擊
+			leadPayload := payloadData{PayloadType: ProtobufType, ProtoData: leadNotice, Player: lead} //傳給首引玩家
+
+			//亮牌給夢家後稍等再通知首引, 等待期間不持有遊戲鎖, 已首引或撤回叫牌則不送出
+			g.afterDeal(g.boardNumber, time.Millisecond*400, func() {
+				if g.Phase() != PhaseOpeningLead {
+					return
+				}
+				g.roomManager.SendPayloadToPlayer(ClnRoomEvents.GamePrivateFirstLead, leadPayload) //私人Private
+				g.startPlayTimer(leadNotice)
+			})
 		}
 	}
 }
@@ -487,17 +656,49 @@ func (g *Game) GamePrivateNotyBid(currentBidder *RoomUser) {
 		2) 若找不到,則從deckInPlay第一張打出
 */
 func (g *Game) GamePrivateFirstLead(leadPlayer *RoomUser) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.firstLead(leadPlayer)
+}
+
+func (g *Game) firstLead(leadPlayer *RoomUser) error {
+	//座位以連線為準, 錯誤只回覆請求者, 不送給被冒用的座位
+	if err := verifySeat(leadPlayer); err != nil {
+		g.log.Wrn("GamePrivateFirstLead", slog.String(".", err.Error()))
+		g.sendUserError(leadPlayer, err)
+		return err
+	}
+	if err := g.inPhase(PhaseOpeningLead); err != nil {
+		g.log.Wrn("GamePrivateFirstLead", slog.String(".", err.Error()))
+		g.sendGameError(leadPlayer.Zone8, err)
+		return err
+	}
 	if leadPlayer.Zone8 != uint8(g.Lead) {
-		slog.Warn("首引出牌", slog.String("FYI", fmt.Sprintf("首引應為%s, 但引牌方為%s", g.Lead, CbSeat(leadPlayer.Zone8))))
-		return nil //by pass
+		err := fmt.Errorf("%w: 首引應為%s, 但引牌方為%s", ErrPlayOutOfTurn, g.Lead, CbSeat(leadPlayer.Zone8))
+		g.log.Wrn("GamePrivateFirstLead", slog.String(".", err.Error()))
+		g.sendGameError(leadPlayer.Zone8, err)
+		return err
+	}
+	//首引一定是打自己的牌
+	leadPlayer.PlaySeat8 = leadPlayer.Zone8
+	if err := g.validPlay(leadPlayer); err != nil {
+		g.log.Wrn("GamePrivateFirstLead", slog.String(".", err.Error()))
+		g.sendGameError(leadPlayer.Zone8, err)
+		return err
 	}
+	if err := g.transit(PhasePlaying); err != nil {
+		g.log.Wrn("GamePrivateFirstLead", slog.String(".", err.Error()))
+		g.sendGameError(leadPlayer.Zone8, err)
+		return err
+	}
+	g.stopTurnTimer()
 	slog.Debug("首引遊戲資訊", slog.String("Declarer", fmt.Sprintf("%s", CbSeat(uint8(g.Declarer)))), slog.String("Dummy", fmt.Sprintf("%s", CbSeat(uint8(g.Dummy)))), slog.String("Lead", fmt.Sprintf("%s", CbSeat(uint8(g.Lead)))), slog.String("Defender", fmt.Sprintf("%s", CbSeat(uint8(g.Defender)))), slog.String("result", fmt.Sprintf("首引%s 打出 %s , leadPlayer.NumOfCardPlayHitting: %d", CbSeat(leadPlayer.Zone8), CbCard(leadPlayer.Play8), leadPlayer.NumOfCardPlayHitting)))
 
-	firstPlayHitting := leadPlayer.NumOfCardPlayHitting
-	if firstPlayHitting != uint32(1) {
-		//TODO: 記log或回復錯誤
-		slog.Warn("首引出牌點擊數錯誤", slog.Int("點擊數應為1,但收到", int(leadPlayer.NumOfCardPlayHitting)))
-		panic("首引點數錯誤")
+	//首引一定是該局第一張出牌,以伺服器計數為準,前端送來的點擊數僅供比對
+	g.countingInPlayCard = 1
+	firstPlayHitting := uint32(g.countingInPlayCard)
+	if leadPlayer.NumOfCardPlayHitting != firstPlayHitting {
+		slog.Warn("首引出牌點擊數不符", slog.Int("點擊數應為1,但收到", int(leadPlayer.NumOfCardPlayHitting)))
 	}
 
 	var (
@@ -588,6 +789,82 @@ func (g *Game) playTurn(player uint8) (uint8, bool) {
 	}
 }
 
+// verifySeat 真人玩家請求的座位(Zone8)必須是伺服器記錄的連線入座座位(KeyGame), 觀眾或冒用其他座位都不合法;
+// 伺服器計時自動動作與機器人沒有連線, 座位由伺服器設定所以不檢查
+func verifySeat(user *RoomUser) error {
+	if user.auto || user.NsConn == nil {
+		return nil
+	}
+	if seat := viewerSeat(user.NsConn); seat == valueNotSet || seat != user.Zone8 {
+		return fmt.Errorf("%w: 請求%s, 連線入座%s", ErrSeatMismatch, CbSeat(user.Zone8), CbSeat(seat))
+	}
+	return nil
+}
+
+// validPlay 檢查出牌是否合法
+//
+//  1. 出牌者連線必須是該座位(Zone8)上的玩家(伺服器計時自動出牌除外)
+//  2. 只有莊家可以打夢家的牌, 其他人只能打自己的牌
+//  3. 必須輪到被打出牌的座位(PlaySeat8)
+//  4. 打出的牌必須在手上
+//  5. 非回合首打時,手上有首打花色必須跟牌
+func (g *Game) validPlay(player *RoomUser) error {
+	var (
+		seat = player.Zone8
+		play = player.PlaySeat8
+		card = player.Play8
+	)
+
+	//伺服器計時自動出牌不檢查連線
+	if !player.auto {
+		if nsConn, _, _, _, err := g.roomManager.FindPlayer(seat); err != nil || nsConn != player.NsConn {
+			return fmt.Errorf("%w: %s(%s)", ErrPlaySeat, CbSeat(seat), player.Name)
+		}
+	}
+
+	switch {
+	case play == uint8(g.Dummy) && seat != uint8(g.Declarer):
+		return fmt.Errorf("%w: %s 打出夢家(%s)的牌", ErrPlayDummy, CbSeat(seat), CbSeat(play))
+	case play != uint8(g.Dummy) && play != seat:
+		return fmt.Errorf("%w: %s 打出 %s 的牌", ErrPlaySeat, CbSeat(seat), CbSeat(play))
+	}
+
+	if play != g.engine.currentPlay {
+		return fmt.Errorf("%w: 輪到 %s 出牌, 但 %s 出牌", ErrPlayOutOfTurn, CbSeat(g.engine.currentPlay), CbSeat(play))
+	}
+
+	hand, ok := g.deckInPlay[play]
+	if !ok || card < club2 || card > spadeAce {
+		return fmt.Errorf("%w: %s %s", ErrPlayNotHeld, CbSeat(play), CbCard(card))
+	}
+
+	var (
+		isHeld    bool
+		hasFollow bool //手上有首打花色
+	)
+	for _, c := range hand {
+		if c == uint8(BaseCover) {
+			continue
+		}
+		if c == card {
+			isHeld = true
+		}
+		if g.roundMin <= c && c <= g.roundMax {
+			hasFollow = true
+		}
+	}
+	if !isHeld {
+		return fmt.Errorf("%w: %s %s", ErrPlayNotHeld, CbSeat(play), CbCard(card))
+	}
+
+	//回合首打(該回合尚未有人出牌)可出任何牌
+	isRoundStart := g.countingInPlayCard%4 == 0
+	if !isRoundStart && hasFollow && (card < g.roundMin || card > g.roundMax) {
+		return fmt.Errorf("%w: %s 打出 %s", ErrPlayRevoke, CbSeat(play), CbCard(card))
+	}
+	return nil
+}
+
 // SetRoundAvailableRange 設定回合可出牌範圍(roundMin, roundMax)
 // 傳入參數 firstPlay表示首打出的牌
 func (g *Game) SetRoundAvailableRange(firstPlay uint8) {
@@ -724,6 +1001,35 @@ func (g *Game) GamePrivateCardHover(cardAction *cb.CardAction) error {
 	🥎 )回覆打出的牌,一併回覆下一家Gauge PASS牌,與下一家限制可出的牌,並停止打出牌者的Gauge 停止OP
 */
 func (g *Game) GamePrivateCardPlayClick(clickPlayer *RoomUser) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cardPlayClick(clickPlayer)
+}
+
+func (g *Game) cardPlayClick(clickPlayer *RoomUser) error {
+	//座位以連線為準, 錯誤只回覆請求者, 不送給被冒用的座位
+	if err := verifySeat(clickPlayer); err != nil {
+		g.log.Wrn("GamePrivateCardPlayClick", slog.String(".", err.Error()))
+		g.sendUserError(clickPlayer, err)
+		return err
+	}
+	if err := g.inPhase(PhasePlaying); err != nil {
+		g.log.Wrn("GamePrivateCardPlayClick", slog.String(".", err.Error()))
+		g.sendGameError(clickPlayer.Zone8, err)
+		return err
+	}
+
+	//攤牌宣告等待對手回覆中,暫停出牌
+	if g.claim.Load() != nil {
+		g.sendGameError(clickPlayer.Zone8, ErrClaimPending)
+		return ErrClaimPending
+	}
+
+	//撤回請求等待對手回覆中,暫停出牌
+	if g.undo.Load() != nil {
+		g.sendGameError(clickPlayer.Zone8, ErrUndoPending)
+		return ErrUndoPending
+	}
 
 	slog.Debug("出牌",
 		slog.String("FYI",
@@ -735,18 +1041,26 @@ func (g *Game) GamePrivateCardPlayClick(clickPlayer *RoomUser) error {
 				CbSeat(clickPlayer.PlaySeat8),
 				clickPlayer.NumOfCardPlayHitting)))
 
+	//出牌合法性檢查(座位,輪次,持牌,跟牌,夢家),不合法回覆出牌者錯誤,遊戲狀態不變
+	if err := g.validPlay(clickPlayer); err != nil {
+		g.log.Wrn("GamePrivateCardPlayClick", slog.String(".", err.Error()))
+		g.sendGameError(clickPlayer.Zone8, err)
+		return err
+	}
+	g.stopTurnTimer()
+
+	//該局第幾張出牌以伺服器計數為準, 前端送來的 NumOfCardPlayHitting 僅供比對
+	g.countingInPlayCard++
+	if clickPlayer.NumOfCardPlayHitting != uint32(g.countingInPlayCard) {
+		slog.Warn("出牌點擊數不符", slog.String("FYI", fmt.Sprintf("伺服器計數%d, 但收到%d", g.countingInPlayCard, clickPlayer.NumOfCardPlayHitting)))
+	}
+
 	//這輪play第幾張出牌, hitting=> 0(表示四人已經牌以打出), 1(表示1人出牌), 2(表示2人出牌), 3(表示三人出牌)
 	var (
-		cardPlayHitting uint32  = clickPlayer.NumOfCardPlayHitting % uint32(4) //至少從2開始參考GamePrivateFirstLead
-		refresh         []uint8                                                //(出牌者)出牌後的refresh
+		cardPlayHitting uint32  = uint32(g.countingInPlayCard) % uint32(4)
+		refresh         []uint8 //(出牌者)出牌後的refresh
 	)
 
-	// hitting=> 0(表示四人已經牌以打出), 1(表示1人出牌), 2(表示2人出牌), 3(表示三人出牌)
-	if cardPlayHitting < uint32(0) || cardPlayHitting > uint32(3) {
-		slog.Warn("出牌點擊問題", slog.String("FYI", fmt.Sprintf("出牌點擊數至少要大於2且小於4,實際為%d", cardPlayHitting)))
-		panic("出牌點擊問題")
-	}
-
 	//一被點擊,就停止四家正在執行的gauge
 	err := g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_gauge_stop}, pb.SceneType_game)
 	if err != nil {
@@ -770,7 +1084,7 @@ func (g *Game) GamePrivateCardPlayClick(clickPlayer *RoomUser) error {
 		nextRealPlaySeat uint8                  //實際上下一個出牌者
 		// 發送給判斷 isLastPlay後的 nextRealPlaySeat
 		nextPlayNotice = &cb.PlayNotice{
-			NumOfCardPlayHitting: clickPlayer.NumOfCardPlayHitting + uint32(1),
+			NumOfCardPlayHitting: uint32(g.countingInPlayCard) + uint32(1),
 			IsPlayAgent:          false, /*下面playTurn判斷式判斷後設定*/
 		}
 	)
@@ -946,7 +1260,6 @@ func (g *Game) GamePrivateCardPlayClick(clickPlayer *RoomUser) error {
 
 	switch isLastPlay /*回合結算*/ {
 	case false:
-		fmt.Printf("非回合結算 sendPayloadsFuncsByIsLastPlay: %v\n", sendPayloadsFuncsByIsLastPlay)
 		//注意：同時送出四家  payload
 		for idx := range sendPayloadsFuncsByIsLastPlay {
 			sendPayloadsFuncsByIsLastPlay[idx]()
@@ -957,37 +1270,49 @@ func (g *Game) GamePrivateCardPlayClick(clickPlayer *RoomUser) error {
 
 	case true:
 		//遊戲結束
-		if clickPlayer.NumOfCardPlayHitting == 52 {
+		if int(g.countingInPlayCard) == NumOfCardsInDeck {
 
 			//注意：同時送出四家  payload
 			for idx := range sendPayloadsFuncsByIsLastPlay {
 				sendPayloadsFuncsByIsLastPlay[idx]()
 			}
+			g.sendTrickTally()
 
 			//遊戲結束
 			g.GameSettle(clickPlayer)
 
 		} else { //表示回合結束
 
-			fmt.Printf("回合結算 sendPayloadsFuncsByIsLastPlay: %v\n", sendPayloadsFuncsByIsLastPlay)
 			//注意：同時送出四家  payload
 			for idx := range sendPayloadsFuncsByIsLastPlay {
 				sendPayloadsFuncsByIsLastPlay[idx]()
 			}
-			time.Sleep(time.Millisecond * 700)
+			g.sendTrickTally()
 
-			//TODO: 送出清除桌面打出的牌,準備下一輪開始
-			err := g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_round_clear}, pb.SceneType_game)
-			if err != nil {
-				//TODO: log goes here這裡絕不能出錯
-				panic(err)
-				//廣播有人GG
+			//等待期間不持有遊戲鎖, 已有人出牌,撤回或攤牌則不送出
+			played := g.countingInPlayCard
+			inTrick := func() bool {
+				return g.Phase() == PhasePlaying && g.countingInPlayCard == played
 			}
 
+			//TODO: 送出清除桌面打出的牌,準備下一輪開始
+			// 斷線或保留中的座位送不到, 只記錄不中斷遊戲
+			g.afterDeal(g.boardNumber, time.Millisecond*700, func() {
+				if !inTrick() {
+					return
+				}
+				if err := g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_round_clear}, pb.SceneType_game); err != nil {
+					g.log.Wrn("cardPlayClick[回合結算]", slog.String(".", err.Error()))
+				}
+			})
+
 			//避免玩家快速再次點擊下一張出牌,導致前端螢幕還沒開始清除上一回合桌面,發生不必要的頁面問題
 			//下一輪首打通知
-			time.Sleep(time.Millisecond * 500) // 重要 的延遲時間,到時候上時還要再加上網路傳輸的延遲
-			g.nextPlayNotification(nextPlayNotice, nextRealPlaySeat)
+			g.afterDeal(g.boardNumber, time.Millisecond*1200, func() { // 重要 的延遲時間,到時候上時還要再加上網路傳輸的延遲
+				if inTrick() {
+					g.nextPlayNotification(nextPlayNotice, nextRealPlaySeat)
+				}
+			})
 		}
 	}
 	return nil
@@ -1001,32 +1326,108 @@ func (g *Game) nextPlayNotification(nxtNotice *cb.PlayNotice, nextRealPlayer uin
 		ProtoData:   nxtNotice,
 		PayloadType: ProtobufType,
 	}) //私人Private
+	g.startPlayTimer(nxtNotice)
 
 }
 
 // GameSettle 遊戲已出滿52張牌,進行遊戲結算, lastPlayer最後一個出牌玩家
 func (g *Game) GameSettle(lastPlayer *RoomUser) {
 
+	if err := g.transit(PhaseSettling); err != nil {
+		g.log.Wrn("GameSettle", slog.String(".", err.Error()))
+		return
+	}
+
 	//   Step0. 儲存出牌紀錄
 	g.savePlayerCardRecord(lastPlayer)
 
-	// TODO: 現在還不知道如何計算結果,需要加入橋牌社了解
-	//   Step1. 回合結束,結算遊戲,告知前端,計算該局遊戲結果
+	//   Step1. 回合結束,結算遊戲,計算該局遊戲結果
+	g.settle(g.engine.GetGameResult(), nil)
+}
+
+// settle 結算該局結果(result), 攤牌宣告(claim)提前結束時claim不為nil; 必須已轉換到 PhaseSettling, 在遊戲鎖(mu)內執行
+func (g *Game) settle(result *GameResult, claim *HandClaim) {
+	slog.Debug("GameSettle",
+		slog.String("結果", fmt.Sprintf("莊:%s 合約:%s%s 吃墩:%d(%+d) 南北:%d 東西:%d", CbSeat(result.Declarer), result.ContractString, result.DoubleString, result.Tricks, result.Result, result.NS, result.EW)))
+
+	//   Step2. 雙明手分析本局可以做成的合約與最佳合約(分析需要數秒,不等待), 手牌在下一局洗牌前取出
+	go g.sendDoubleDummy(g.dealtHands(), g.engine.board)
+
+	//   Step3. 儲存牌局紀錄(不等待寫入), 紀錄在下一局洗牌前取出
+	hand := g.handRecord(result)
+	hand.Claim = claim
+	g.lastHand.Store(hand)
+	go g.saveHand(hand)
+
+	//   Step4. 複式賽程中回報本局結果, 所有遊戲桌都打完這副牌時廣播各桌結果
+	g.sessionReport(hand.Players, result)
+
+	//   Step5. 兩秒後清除桌面打出的牌並廣播該局結果(四家玩家與觀眾), 再兩秒後重新競叫開始; 延遲期間不持有遊戲鎖
+	dealt := g.boardNumber
+	g.afterDeal(dealt, time.Second*2, func() {
+		g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_round_clear}, pb.SceneType_game)
+		g.sendGameResult(result)
+
+		g.afterDeal(dealt, time.Second*2, func() {
+			//TODO: 底下已經有OP sceneType了
+			//g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_result_clear}, pb.SceneType_game)
+
+			//遊戲中斷(有玩家離桌)時, 再度滿四人才開局
+			if g.Phase() != PhaseSettling {
+				return
+			}
+			g.roomManager.sendGameStart()
+		})
+	})
+}
+
+// sendGameError 回覆玩家(player)遊戲操作錯誤
+func (g *Game) sendGameError(player uint8, err error) {
+	g.roomManager.SendPayloadToPlayer(ClnRoomEvents.ErrorGame, payloadData{
+		Player:      player,
+		PayloadType: ProtobufType,
+		ProtoData: &pb.ErrMessage{
+			Msg:   err.Error(),
+			Alert: false,
+			Seat:  uint32(player),
+			Scene: pb.SceneType_game,
+		},
+	})
+}
 
-	//   Step2. 送出清除桌面打出的牌,準備下一輪開始
-	time.Sleep(time.Second * 2)
-	g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_round_clear}, pb.SceneType_game)
+// sendBoard 廣播本局牌號,發牌者與身價給房間所有人(玩家,觀眾)
+func (g *Game) sendBoard() {
+	body, err := json.Marshal(g.engine.board)
+	if err != nil {
+		g.log.Wrn("sendBoard", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameBoard, g.name, body)
+}
 
-	// TODO:
-	//    Step3. 廣播該局結果
+// TrickTally 目前南北,東西吃墩數
+func (g *Game) TrickTally() TrickTally {
+	return g.engine.TrickTally()
+}
 
-	//   Step4. 清空該局結果UI,清空桌面
-	time.Sleep(time.Second * 2)
-	//TODO: 底下已經有OP sceneType了
-	//g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_result_clear}, pb.SceneType_game)
+// sendTrickTally 回合結束後,廣播目前南北/東西吃墩數給房間所有人(玩家,觀眾)
+func (g *Game) sendTrickTally() {
+	body, err := json.Marshal(g.engine.TrickTally())
+	if err != nil {
+		g.log.Wrn("sendTrickTally", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameTrickTally, g.name, body)
+}
 
-	//   Step5 重新競叫開始
-	g.roomManager.SendGameStart()
+// sendGameResult 廣播該局結算結果給房間所有人(玩家,觀眾)
+func (g *Game) sendGameResult(result *GameResult) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		g.log.Wrn("sendGameResult", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameResult, g.name, body)
 }
 
 // PlayOutHandRefresh 打出牌後,修改手頭上剩下的牌組,並回傳修正後的clone牌組給前端進行牌重整,以及打出這張牌在牌組中的索引.
@@ -1081,6 +1482,21 @@ func (g *Game) savePlayCardRecord(player, card uint8) {
 	}
 }
 
+// playCardRecord 座位(player)本回合打出的牌, 尚未出牌為 BaseCover
+func (g *Game) playCardRecord(player uint8) uint8 {
+	switch player {
+	case uint8(east):
+		return g.eastCard
+	case uint8(south):
+		return g.southCard
+	case uint8(west):
+		return g.westCard
+	case uint8(north):
+		return g.northCard
+	}
+	return uint8(BaseCover)
+}
+
 func (g *Game) resetPlayCardRecord() {
 	g.eastCard = uint8(BaseCover)
 	g.westCard = uint8(BaseCover)
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// GamePhase 遊戲階段
+//
+//	WaitingForPlayers → Dealing → Bidding → OpeningLead → Playing → Settling → Dealing(下一副牌)
+//	Bidding → Dealing (四家PASS重新發牌)
+//	Dealing → WaitingForPlayers (複式賽程中該桌沒有待打的牌, 等待排入新的牌)
+//	任何階段 → Aborted (遊戲中有玩家離桌) → WaitingForPlayers (座位空出, 等待再度滿四人)
+type GamePhase uint8
+
+const (
+	PhaseWaitingForPlayers GamePhase = iota //等待玩家入座
+	PhaseDealing                            //洗牌,發牌
+	PhaseBidding                            //競叫
+	PhaseOpeningLead                        //合約確定,等待首引
+	PhasePlaying                            //出牌
+	PhaseSettling                           //結算
+	PhaseAborted                            //遊戲中斷
+)
+
+func (p GamePhase) String() string {
+	switch p {
+	case PhaseWaitingForPlayers:
+		return "WaitingForPlayers"
+	case PhaseDealing:
+		return "Dealing"
+	case PhaseBidding:
+		return "Bidding"
+	case PhaseOpeningLead:
+		return "OpeningLead"
+	case PhasePlaying:
+		return "Playing"
+	case PhaseSettling:
+		return "Settling"
+	case PhaseAborted:
+		return "Aborted"
+	}
+	return fmt.Sprintf("GamePhase(%d)", uint8(p))
+}
+
+// phaseTransitions 合法的階段轉換, Key:目前階段 Value:可轉換的下一階段
+var phaseTransitions = map[GamePhase][]GamePhase{
+	PhaseWaitingForPlayers: {PhaseDealing},
+	PhaseDealing:           {PhaseBidding, PhaseWaitingForPlayers},
+	PhaseBidding:           {PhaseDealing, PhaseOpeningLead},
+	PhaseOpeningLead:       {PhasePlaying},
+	PhasePlaying:           {PhaseSettling},
+	PhaseSettling:          {PhaseDealing, PhaseWaitingForPlayers},
+	PhaseAborted:           {PhaseDealing, PhaseWaitingForPlayers},
+}
+
+// canTransit 是否可由p轉換到next, 任何階段都可以轉換到 Aborted
+func (p GamePhase) canTransit(next GamePhase) bool {
+	if next == PhaseAborted {
+		return true
+	}
+	for _, to := range phaseTransitions[p] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PhaseInfo 遊戲桌目前階段, 新進房間的使用者以此得知牌桌進行到哪裡
+type PhaseInfo struct {
+	Phase       GamePhase `json:"phase"`
+	PhaseString string    `json:"phaseString"`
+	Board       uint32    `json:"board"` //目前牌號, 0表示尚未發牌
+}
+
+// Phase 遊戲目前階段
+func (g *Game) Phase() GamePhase {
+	return GamePhase(g.phase.Load())
+}
+
+// PhaseInfo 遊戲目前階段與牌號
+func (g *Game) PhaseInfo() PhaseInfo {
+	phase := g.Phase()
+	return PhaseInfo{
+		Phase:       phase,
+		PhaseString: phase.String(),
+		Board:       g.engine.board.Number,
+	}
+}
+
+// transit 由目前階段轉換到下一階段(next), 不合法的轉換回傳 ErrGamePhase且階段不變
+func (g *Game) transit(next GamePhase) error {
+	for {
+		current := g.Phase()
+		if !current.canTransit(next) {
+			return fmt.Errorf("%w: %s 無法轉換到 %s", ErrGamePhase, current, next)
+		}
+		if g.phase.CompareAndSwap(uint32(current), uint32(next)) {
+			slog.Debug("GamePhase", slog.String(g.name, fmt.Sprintf("%s → %s", current, next)))
+			return nil
+		}
+	}
+}
+
+// sendPhase 廣播遊戲桌目前階段給房間所有人(玩家,觀眾)
+func (g *Game) sendPhase() {
+	body, err := json.Marshal(g.PhaseInfo())
+	if err != nil {
+		g.log.Wrn("sendPhase", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.TablePhase, g.name, body)
+}
+
+// inPhase 事件只能在指定階段(expect)處理, 否則回傳 ErrGamePhase
+func (g *Game) inPhase(expect GamePhase) error {
+	if current := g.Phase(); current != expect {
+		return fmt.Errorf("%w: 目前階段為 %s, 此動作只能在 %s", ErrGamePhase, current, expect)
+	}
+	return nil
+}
+
+// abort 遊戲進行中有玩家離桌(座位空出),中斷遊戲並取消輪次計時, 轉換到 WaitingForPlayers 等待玩家入座; 必須持有遊戲鎖(mu)
+func (g *Game) abort() {
+	if g.Phase() == PhaseWaitingForPlayers {
+		return
+	}
+	g.stopTurnTimer()
+	if err := g.transit(PhaseAborted); err != nil {
+		g.log.Wrn("abort", slog.String(".", err.Error()))
+		return
+	}
+	if err := g.transit(PhaseWaitingForPlayers); err != nil {
+		g.log.Wrn("abort", slog.String(".", err.Error()))
+		return
+	}
+	g.sendPhase()
+}
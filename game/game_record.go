@@ -7,8 +7,8 @@ type record struct {
 	//Double 屬於哪一種類Double (只限DOUBLE, REDOUBLE, ZeroSuit表未設定)
 	dbType CbSuit
 
-	//身價方
-	//TODO
+	//莊家方是否有身價
+	vulnerable bool
 
 	//遊戲最終叫品種類與線位 (C1 ~ Db7x2, BidYet 表未設定)
 	contract CbBid
@@ -0,0 +1,182 @@
+package game
+
+// 複式橋牌(Duplicate)計分, 參考 Laws of Duplicate Bridge - Law 77 計分表
+
+const (
+	partScoreBonus int32 = 50  //部分合約獎分
+	gameBonusNV    int32 = 300 //無身價成局獎分
+	gameBonusV     int32 = 500 //有身價成局獎分
+	smallSlamNV    int32 = 500 //無身價小滿貫
+	smallSlamV     int32 = 750 //有身價小滿貫
+	grandSlamNV    int32 = 1000
+	grandSlamV     int32 = 1500
+	insultDouble   int32 = 50  //賭倍做成獎分
+	insultRedouble int32 = 100 //再賭倍做成獎分
+
+	// 莊家需要吃到的基本墩數(book), 合約線位 + 6 才是合約要求墩數
+	bookTricks uint8 = 6
+)
+
+type (
+	// GameResult 一局遊戲結算結果,分數皆以莊家方為正向
+	GameResult struct {
+		Declarer       uint8  `json:"declarer"`       //莊家
+		Contract       uint8  `json:"contract"`       //合約(CbBid)
+		ContractString string `json:"contractString"` //合約字串
+		DoubleString   string `json:"doubleString"`   //賭倍字串
+		Vulnerable     bool   `json:"vulnerable"`     //莊家方是否有身價
+		Tricks         uint8  `json:"tricks"`         //莊家方吃到的墩數
+		Result         int8   `json:"result"`         //超墩(正),倒墩(負),0表示剛好做成
+
+		TrickScore  int32 `json:"trickScore"`  //合約墩分
+		OverTricks  int32 `json:"overTricks"`  //超墩分
+		UnderTricks int32 `json:"underTricks"` //倒墩罰分(負值)
+		GameBonus   int32 `json:"gameBonus"`   //成局或部分合約獎分
+		SlamBonus   int32 `json:"slamBonus"`   //滿貫獎分
+		InsultBonus int32 `json:"insultBonus"` //賭倍(再賭倍)做成獎分
+
+		Score int32 `json:"score"` //莊家方總分,負值表示防家得分
+		NS    int32 `json:"ns"`    //南北得分
+		EW    int32 `json:"ew"`    //東西得分
+	}
+)
+
+// 合約王牌花色每墩墩分, 無王首墩40其餘30
+func trickValue(suit CbSuit, nth uint8) int32 {
+	switch suit {
+	case CLUB, DIAMOND:
+		return 20
+	case HEART, SPADE:
+		return 30
+	case TRUMP:
+		if nth == 1 {
+			return 40
+		}
+		return 30
+	}
+	return 0
+}
+
+// 倒墩罰分, downs 倒墩數(正值)
+func undertrickPenalty(downs int32, dbType CbSuit, vulnerable bool) (penalty int32) {
+	switch dbType {
+	case DOUBLE, REDOUBLE:
+		for i := int32(1); i <= downs; i++ {
+			switch {
+			case vulnerable && i == 1:
+				penalty += 200
+			case vulnerable:
+				penalty += 300
+			case i == 1:
+				penalty += 100
+			case i <= 3:
+				penalty += 200
+			default:
+				penalty += 300
+			}
+		}
+		if dbType == REDOUBLE {
+			penalty *= 2
+		}
+	default:
+		if vulnerable {
+			return downs * 100
+		}
+		return downs * 50
+	}
+	return
+}
+
+// scoring 依合約(contract),莊家方吃到的墩數(tricks),莊家方身價(vulnerable)計算複式計分
+func scoring(declarer CbSeat, contract record, tricks uint8, vulnerable bool) *GameResult {
+
+	var (
+		level           = biddingLine(contract.contract)
+		suit            = CbSuit(seatBiddingMapperSuit[uint8(contract.contract)])
+		required        = level + bookTricks
+		multiple  int32 = 1
+		oddTricks int32
+	)
+
+	result := &GameResult{
+		Declarer:       uint8(declarer),
+		Contract:       uint8(contract.contract),
+		ContractString: contract.contract.String(),
+		DoubleString:   contract.dbType.String(),
+		Vulnerable:     vulnerable,
+		Tricks:         tricks,
+		Result:         int8(tricks) - int8(required),
+	}
+
+	switch contract.dbType {
+	case DOUBLE:
+		multiple = 2
+	case REDOUBLE:
+		multiple = 4
+	}
+
+	switch {
+	case result.Result < 0: /*合約失敗*/
+		result.UnderTricks = -undertrickPenalty(int32(-result.Result), contract.dbType, vulnerable)
+
+	default: /*合約做成*/
+		for nth := uint8(1); nth <= level; nth++ {
+			oddTricks += trickValue(suit, nth)
+		}
+		result.TrickScore = oddTricks * multiple
+
+		//超墩
+		over := int32(result.Result)
+		switch contract.dbType {
+		case DOUBLE, REDOUBLE:
+			perTrick := int32(100)
+			if vulnerable {
+				perTrick = 200
+			}
+			result.OverTricks = over * perTrick * (multiple / 2)
+		default:
+			result.OverTricks = over * trickValue(suit, 2)
+		}
+
+		//成局或部分合約
+		switch {
+		case result.TrickScore >= 100 && vulnerable:
+			result.GameBonus = gameBonusV
+		case result.TrickScore >= 100:
+			result.GameBonus = gameBonusNV
+		default:
+			result.GameBonus = partScoreBonus
+		}
+
+		//滿貫
+		switch level {
+		case 6:
+			result.SlamBonus = smallSlamNV
+			if vulnerable {
+				result.SlamBonus = smallSlamV
+			}
+		case 7:
+			result.SlamBonus = grandSlamNV
+			if vulnerable {
+				result.SlamBonus = grandSlamV
+			}
+		}
+
+		switch contract.dbType {
+		case DOUBLE:
+			result.InsultBonus = insultDouble
+		case REDOUBLE:
+			result.InsultBonus = insultRedouble
+		}
+	}
+
+	result.Score = result.TrickScore + result.OverTricks + result.UnderTricks + result.GameBonus + result.SlamBonus + result.InsultBonus
+
+	switch declarer {
+	case north, south:
+		result.NS, result.EW = result.Score, -result.Score
+	default:
+		result.NS, result.EW = -result.Score, result.Score
+	}
+	return result
+}
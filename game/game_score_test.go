@@ -0,0 +1,83 @@
+package game
+
+import "testing"
+
+func TestScoring(t *testing.T) {
+	tests := []struct {
+		name       string
+		declarer   CbSeat
+		contract   CbBid
+		dbType     CbSuit
+		vulnerable bool
+		tricks     uint8
+		score      int32
+	}{
+		//常見結果
+		{"4♠= 無身價", south, S4, ZeroSuit, false, 10, 420},
+		{"3NT+1 有身價", north, NT3, ZeroSuit, true, 10, 630},
+		{"1♣XX= 無身價", south, C1, REDOUBLE, false, 7, 230},
+		{"7NT-1 有身價賭倍", south, NT7, DOUBLE, true, 12, -200},
+		{"4♥X-3 無身價", south, H4, DOUBLE, false, 7, -500},
+
+		//部分合約與成局
+		{"2♠= 部分合約", south, S2, ZeroSuit, false, 8, 110},
+		{"2NT+1 部分合約超墩", south, NT2, ZeroSuit, true, 9, 150},
+		{"2♥X= 賭倍成局", south, H2, DOUBLE, false, 8, 470},
+
+		//賭倍,再賭倍超墩
+		{"2♥X+1 無身價", south, H2, DOUBLE, false, 9, 570},
+		{"1NTXX+1 有身價", south, NT1, REDOUBLE, true, 8, 1160},
+		{"4♠X+1 有身價", south, S4, DOUBLE, true, 11, 990},
+
+		//倒墩依身價
+		{"3NT-2 無身價", south, NT3, ZeroSuit, false, 7, -100},
+		{"3NT-2 有身價", south, NT3, ZeroSuit, true, 7, -200},
+		{"4♠X-4 無身價", south, S4, DOUBLE, false, 6, -800},
+		{"4♠X-2 有身價", south, S4, DOUBLE, true, 8, -500},
+		{"3NTXX-1 無身價", south, NT3, REDOUBLE, false, 8, -200},
+
+		//滿貫
+		{"6♠= 有身價", south, S6, ZeroSuit, true, 12, 1430},
+		{"6NT= 無身價", south, NT6, ZeroSuit, false, 12, 990},
+		{"7♣= 有身價", south, C7, ZeroSuit, true, 13, 2140},
+		{"7NT= 無身價", south, NT7, ZeroSuit, false, 13, 1520},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := scoring(tt.declarer, record{contract: tt.contract, dbType: tt.dbType}, tt.tricks, tt.vulnerable)
+			if result.Score != tt.score {
+				t.Errorf("Score = %d, want %d (%+v)", result.Score, tt.score, result)
+			}
+			if result.NS != tt.score || result.EW != -tt.score {
+				t.Errorf("NS, EW = %d, %d, want %d, %d", result.NS, result.EW, tt.score, -tt.score)
+			}
+		})
+	}
+}
+
+func TestScoringInsultBonus(t *testing.T) {
+	for _, tt := range []struct {
+		dbType CbSuit
+		want   int32
+	}{{ZeroSuit, 0}, {DOUBLE, insultDouble}, {REDOUBLE, insultRedouble}} {
+		if got := scoring(south, record{contract: S4, dbType: tt.dbType}, 10, false).InsultBonus; got != tt.want {
+			t.Errorf("%s InsultBonus = %d, want %d", tt.dbType, got, tt.want)
+		}
+		//合約失敗沒有侮辱獎分
+		if got := scoring(south, record{contract: S4, dbType: tt.dbType}, 9, false).InsultBonus; got != 0 {
+			t.Errorf("%s 失敗 InsultBonus = %d, want 0", tt.dbType, got)
+		}
+	}
+}
+
+func TestScoringDefenders(t *testing.T) {
+	//東西做莊, 南北得分為負
+	result := scoring(east, record{contract: S4, dbType: ZeroSuit}, 10, false)
+	if result.EW != 420 || result.NS != -420 {
+		t.Errorf("NS, EW = %d, %d, want -420, 420", result.NS, result.EW)
+	}
+	result = scoring(west, record{contract: NT3, dbType: ZeroSuit}, 8, true)
+	if result.Result != -1 || result.EW != -100 || result.NS != 100 {
+		t.Errorf("Result, NS, EW = %d, %d, %d, want -1, 100, -100", result.Result, result.NS, result.EW)
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moszorn/pb"
+)
+
+// playDeal 測試用牌局: 北 ♠AKQJ ♥AKQ ♦AKQ ♣AKQ, 東 ♠T987 ♥JT9 ♦JT9 ♣JT9, 南 ♠6543 ♥876 ♦876 ♣876, 西 ♠2 ♥5432 ♦5432 ♣5432
+const playDeal = "N:AKQJ.AKQ.AKQ.AKQ T987.JT9.JT9.JT9 6543.876.876.876 2.5432.5432.5432"
+
+// playingGame 南家做莊(北家夢家)的牌局, 西家首引
+func playingGame(t *testing.T) *Game {
+	t.Helper()
+	hands, err := ParsePBNDeal(playDeal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := testGame(t, "room")
+	DealHands(g, hands)
+	g.Declarer, g.Dummy, g.Lead, g.Defender = south, north, west, east
+	g.engine.currentPlay = uint8(west)
+	g.phase.Store(uint32(PhasePlaying))
+	return g
+}
+
+// autoPlay 座位(seat)打出座位(playSeat)的牌(card), 以伺服器自動出牌送出(不檢查連線)
+func autoPlay(seat, playSeat CbSeat, card uint8) *RoomUser {
+	return &RoomUser{
+		auto:        true,
+		PlayingUser: &pb.PlayingUser{Name: seat.String(), Zone: uint32(seat), PlaySeat: uint32(playSeat), Play: uint32(card)},
+		Zone8:       uint8(seat),
+		PlaySeat8:   uint8(playSeat),
+		Play8:       card,
+	}
+}
+
+func TestValidPlay(t *testing.T) {
+	tests := []struct {
+		name string
+		lead uint8  //該墩首打的牌, 0表示回合首打
+		turn CbSeat //輪到出牌的座位
+		user *RoomUser
+		want error
+	}{
+		{"首引任何一張手上的牌", 0, west, autoPlay(west, west, heart5), nil},
+		{"打出不在手上的牌", 0, west, autoPlay(west, west, spadeAce), ErrPlayNotHeld},
+		{"打出不存在的牌", 0, west, autoPlay(west, west, uint8(BaseCover)), ErrPlayNotHeld},
+		{"沒輪到就出牌", 0, west, autoPlay(east, east, heartJ), ErrPlayOutOfTurn},
+		{"打出別人的牌", 0, west, autoPlay(west, east, heartJ), ErrPlaySeat},
+		{"莊家打夢家的牌", spade2, north, autoPlay(south, north, spadeAce), nil},
+		{"防家打夢家的牌", spade2, north, autoPlay(east, north, spadeAce), ErrPlayDummy},
+		{"有首打花色卻不跟牌", spade2, north, autoPlay(south, north, heartAce), ErrPlayRevoke},
+		{"跟牌", heart5, east, autoPlay(east, east, heartJ), nil},
+		{"有首打花色卻墊牌", heart5, east, autoPlay(east, east, spade7), ErrPlayRevoke},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := playingGame(t)
+			g.engine.currentPlay = uint8(tt.turn)
+			if tt.lead != 0 {
+				g.countingInPlayCard = 1
+				g.SetRoundAvailableRange(tt.lead)
+			}
+			if err := g.validPlay(tt.user); !errors.Is(err, tt.want) {
+				t.Errorf("validPlay(%s 打出 %s 的 %s) error = %v, want %v", CbSeat(tt.user.Zone8), CbSeat(tt.user.PlaySeat8), CbCard(tt.user.Play8), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidPlayDiscard(t *testing.T) {
+	//西家只有一張黑桃, 打出後缺門可以墊任何牌
+	g := playingGame(t)
+	hand := g.deckInPlay[uint8(west)]
+	for i := range hand {
+		if hand[i] == spade2 {
+			hand[i] = uint8(BaseCover)
+		}
+	}
+	g.countingInPlayCard = 1
+	g.SetRoundAvailableRange(spadeAce)
+	if err := g.validPlay(autoPlay(west, west, club5)); err != nil {
+		t.Errorf("缺門墊牌 validPlay() error = %v", err)
+	}
+}
+
+func TestCardPlayClickRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		user *RoomUser
+		want error
+	}{
+		{"沒輪到就出牌", autoPlay(east, east, heartJ), ErrPlayOutOfTurn},
+		{"打出不在手上的牌", autoPlay(west, west, heartAce), ErrPlayNotHeld},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := playingGame(t)
+			hand := *g.deckInPlay[uint8(west)]
+			if err := g.cardPlayClick(tt.user); !errors.Is(err, tt.want) {
+				t.Errorf("cardPlayClick() error = %v, want %v", err, tt.want)
+			}
+			//不合法出牌不改變遊戲狀態
+			if g.countingInPlayCard != 0 || g.engine.currentPlay != uint8(west) || *g.deckInPlay[uint8(west)] != hand {
+				t.Errorf("不合法出牌後 出牌數 %d 輪到 %s", g.countingInPlayCard, CbSeat(g.engine.currentPlay))
+			}
+		})
+	}
+
+	//墩中不跟牌
+	g := playingGame(t)
+	g.engine.currentPlay = uint8(north)
+	g.countingInPlayCard = 1
+	g.SetRoundAvailableRange(spade2)
+	if err := g.cardPlayClick(autoPlay(south, north, heartAce)); !errors.Is(err, ErrPlayRevoke) {
+		t.Errorf("cardPlayClick() error = %v, want %v", err, ErrPlayRevoke)
+	}
+	if g.countingInPlayCard != 1 || g.engine.currentPlay != uint8(north) {
+		t.Errorf("不跟牌後 出牌數 %d 輪到 %s, want 1, %s", g.countingInPlayCard, CbSeat(g.engine.currentPlay), north)
+	}
+}
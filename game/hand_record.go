@@ -0,0 +1,222 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 牌局紀錄: 每局結算(GameSettle)時將牌號,原始手牌,競叫,每一墩出牌,結果與各家玩家寫入 HandStore,
+// 本地以檔案(FileHandStore)儲存, 正式環境可另外實作 HandStore (ex: DynamoDB)
+
+type (
+	// HandRecord 一局完整的牌局紀錄
+	HandRecord struct {
+		Room    string       `json:"room"`
+		Board   Board        `json:"board"`
+		Seed    string       `json:"seed,omitempty"` //發牌種子(DealFromSeed), 匯入的牌局沒有種子
+		Hands   [4][]uint8   `json:"hands"`          //發牌時各家的牌,順序固定為東,南,西,北
+		Players [4]string    `json:"players"`        //各家玩家名稱,順序固定為東,南,西,北
+		Auction []AuctionBid `json:"auction"`
+		Tricks  []TrickPlay  `json:"tricks"`
+		Claim   *HandClaim   `json:"claim,omitempty"` //打完全部牌時為nil
+		Result  *GameResult  `json:"result"`
+		Time    time.Time    `json:"time"` //結算時間
+	}
+
+	// AuctionBid 一個競叫
+	AuctionBid struct {
+		Seat      uint8     `json:"seat"`      //競叫者(CbSeat)
+		Bid       uint8     `json:"bid"`       //叫品(CbBid)
+		BidString string    `json:"bidString"` //叫品字串
+		Time      time.Time `json:"time"`      //叫約時間
+
+		Alert       bool   `json:"alert,omitempty"`       //叫者警示
+		Explanation string `json:"explanation,omitempty"` //警示說明
+	}
+
+	// TrickPlay 一墩的出牌
+	TrickPlay struct {
+		Lead   uint8    `json:"lead"`   //首打(CbSeat)
+		Winner uint8    `json:"winner"` //贏家(CbSeat)
+		Cards  [4]uint8 `json:"cards"`  //四家打出的牌,順序固定為東,南,西,北
+	}
+
+	// HandClaim 出牌中攤牌宣告(claim)或認輸(concede)剩餘墩數
+	HandClaim struct {
+		Seat    uint8 `json:"seat"`    //宣告者(CbSeat)
+		Tricks  uint8 `json:"tricks"`  //宣告者方在剩餘墩中吃到的墩數
+		Concede bool  `json:"concede"` //防家認輸
+	}
+
+	// HandStore 牌局紀錄儲存
+	HandStore interface {
+		// SaveHand 寫入一局牌局紀錄
+		SaveHand(ctx context.Context, hand *HandRecord) error
+		// LoadHand 房間(room)牌號(board)最近一次的牌局紀錄, 不存在時回傳 ErrHandNotFound
+		LoadHand(ctx context.Context, room string, board uint32) (*HandRecord, error)
+		// Hands 房間(room)所有牌局紀錄, 依寫入順序
+		Hands(ctx context.Context, room string) ([]*HandRecord, error)
+	}
+)
+
+// SetHandStore 設定該房間牌局紀錄儲存, nil表示不儲存
+func (g *Game) SetHandStore(store HandStore) {
+	if store == nil {
+		g.handStore.Store(nil)
+		return
+	}
+	g.handStore.Store(&store)
+}
+
+// HandStore 該房間牌局紀錄儲存, 未設定時回傳nil
+func (g *Game) HandStore() HandStore {
+	if store := g.handStore.Load(); store != nil {
+		return *store
+	}
+	return nil
+}
+
+// handRecord 本局牌局紀錄, 必須在下一局洗牌(SendGameStart)前呼叫
+func (g *Game) handRecord(result *GameResult) *HandRecord {
+	hand := &HandRecord{
+		Room:   g.name,
+		Board:  g.engine.board,
+		Seed:   g.engine.board.Seed(),
+		Hands:  g.dealtHands(),
+		Result: result,
+		Time:   time.Now(),
+	}
+
+	hand.Players = g.seatNames()
+
+	for _, b := range g.engine.Auction() {
+		hand.Auction = append(hand.Auction, AuctionBid{
+			Seat:        b.who(),
+			Bid:         uint8(b.value),
+			BidString:   b.value.String(),
+			Time:        b.t,
+			Alert:       b.alert,
+			Explanation: b.explanation,
+		})
+	}
+
+	for _, t := range g.engine.ledger.tricks {
+		hand.Tricks = append(hand.Tricks, TrickPlay{
+			Lead:   uint8(t.lead),
+			Winner: uint8(t.winner),
+			Cards:  t.cards,
+		})
+	}
+	return hand
+}
+
+// seatNames 各家玩家名稱(東,南,西,北), 斷線保留中的座位仍有玩家名稱
+func (g *Game) seatNames() (names [4]string) {
+	for idx, seat := range playerSeats {
+		_, names[idx], _, _, _ = g.roomManager.FindPlayer(seat)
+	}
+	return
+}
+
+// saveHand 寫入牌局紀錄, 失敗只記錄不影響遊戲
+func (g *Game) saveHand(hand *HandRecord) {
+	store := g.HandStore()
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.SaveHand(ctx, hand); err != nil {
+		g.log.Wrn("saveHand", slog.String(".", err.Error()), slog.Uint64("board", uint64(hand.Board.Number)))
+	}
+}
+
+// FileHandStore 以本地檔案儲存牌局紀錄, 每個房間一個檔案(dir/房間.jsonl), 每行一局
+type FileHandStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileHandStore 牌局紀錄儲存於目錄(dir), 目錄不存在時自動建立
+func NewFileHandStore(dir string) (*FileHandStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileHandStore{dir: dir}, nil
+}
+
+func (s *FileHandStore) path(room string) string {
+	return filepath.Join(s.dir, filepath.Base(room)+".jsonl")
+}
+
+func (s *FileHandStore) SaveHand(ctx context.Context, hand *HandRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(hand)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(hand.Room), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *FileHandStore) LoadHand(ctx context.Context, room string, board uint32) (*HandRecord, error) {
+	hands, err := s.Hands(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+	//同一牌號可能有多局(伺服器重啟牌號重新計數), 取最近一局
+	for i := len(hands) - 1; i >= 0; i-- {
+		if hands[i].Board.Number == board {
+			return hands[i], nil
+		}
+	}
+	return nil, ErrHandNotFound
+}
+
+func (s *FileHandStore) Hands(ctx context.Context, room string) (hands []*HandRecord, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path(room))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		hand := new(HandRecord)
+		if err = json.Unmarshal(scanner.Bytes(), hand); err != nil {
+			return nil, fmt.Errorf("%s 第%d行: %w", s.path(room), line, err)
+		}
+		hands = append(hands, hand)
+	}
+	return hands, scanner.Err()
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileHandStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileHandStore(filepath.Join(dir, "hands"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//沒有紀錄的房間
+	if hands, err := store.Hands(ctx, "room"); err != nil || hands != nil {
+		t.Errorf("Hands() = %v, %v, want nil, nil", hands, err)
+	}
+	if _, err := store.LoadHand(ctx, "room", 1); !errors.Is(err, ErrHandNotFound) {
+		t.Errorf("LoadHand() error = %v, want %v", err, ErrHandNotFound)
+	}
+
+	saved := []*HandRecord{
+		{Room: "room", Board: newBoard(1), Seed: "first", Result: &GameResult{Score: 420}},
+		{Room: "room", Board: newBoard(2), Seed: "second"},
+		{Room: "other", Board: newBoard(1), Seed: "other"},
+		//伺服器重啟後牌號重新計數
+		{Room: "room", Board: newBoard(1), Seed: "again", Claim: &HandClaim{Seat: uint8(south), Tricks: 2}},
+	}
+	for _, hand := range saved {
+		if err := store.SaveHand(ctx, hand); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hands, err := store.Hands(ctx, "room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hands) != 3 || hands[0].Seed != "first" || hands[0].Result.Score != 420 || hands[1].Board.Dealer != uint8(east) {
+		t.Fatalf("Hands() 依寫入順序 %d局, want 3局", len(hands))
+	}
+	//同一牌號取最近一局
+	hand, err := store.LoadHand(ctx, "room", 1)
+	if err != nil || hand.Seed != "again" || hand.Claim == nil || hand.Claim.Tricks != 2 {
+		t.Errorf("LoadHand(1) = %+v, %v, want 最近一局", hand, err)
+	}
+	if _, err := store.LoadHand(ctx, "room", 3); !errors.Is(err, ErrHandNotFound) {
+		t.Errorf("LoadHand(3) error = %v, want %v", err, ErrHandNotFound)
+	}
+
+	//房間名稱不能寫到目錄外
+	if err := store.SaveHand(ctx, &HandRecord{Room: "../escape", Board: newBoard(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("牌局紀錄寫到目錄外 %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := store.SaveHand(canceled, saved[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveHand() 已取消 error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSettleSavesHand(t *testing.T) {
+	store, err := NewFileHandStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := endGame(t, north)
+	g.SetHandStore(store)
+	if err := lockedClaim(g, south, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, seat := range []CbSeat{west, east} {
+		if err := lockedReply(g, seat, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//結算時寫入(不等待), 包含發牌時的手牌與每一墩
+	var hands []*HandRecord
+	for deadline := time.Now().Add(time.Second); len(hands) == 0; time.Sleep(10 * time.Millisecond) {
+		if hands, err = store.Hands(context.Background(), "room"); err != nil {
+			t.Fatal(err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("結算後沒有寫入牌局紀錄")
+		}
+	}
+	hand := hands[0]
+	if len(hand.Tricks) != NumOfCardsOnePlayer-2 || hand.Claim == nil || hand.Result == nil || hand.Result.Tricks != 10 {
+		t.Errorf("牌局紀錄 %d墩 宣告 %v 結果 %v, want 11墩, 攤牌宣告, 10墩", len(hand.Tricks), hand.Claim, hand.Result)
+	}
+	for idx, cards := range hand.Hands {
+		if len(cards) != NumOfCardsOnePlayer {
+			t.Errorf("%s 發牌時 %d張, want %d", CbSeat(playerSeats[idx]), len(cards), NumOfCardsOnePlayer)
+		}
+	}
+}
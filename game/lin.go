@@ -0,0 +1,169 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// LIN: BBO handviewer 使用的牌局格式, 將牌局紀錄(HandRecord)轉成LIN字串, 玩家可以分享"看這副牌"連結
+
+// LINViewer BBO handviewer 網址, 參數lin為LIN字串
+const LINViewer = "https://www.bridgebase.com/tools/handviewer.html"
+
+// HandLin 牌局LIN字串與handviewer連結
+type HandLin struct {
+	Room  string `json:"room"`
+	Board uint32 `json:"board"`
+	LIN   string `json:"lin"`
+	URL   string `json:"url"`
+}
+
+// linSeats LIN 座位順序(南,西,北,東)的座位索引
+var linSeats = [4]uint8{1, 2, 3, 0}
+
+// linHand 一手牌的LIN表示法(黑桃,紅心,方塊,梅花), ex: SAKQHT98DCJ765432
+func linHand(cards []uint8) string {
+	var (
+		sb    strings.Builder
+		suits = strings.Split(pbnHand(cards), ".")
+	)
+	for i, ranks := range suits {
+		sb.WriteByte("SHDC"[i])
+		sb.WriteString(ranks)
+	}
+	return sb.String()
+}
+
+// linBid 叫品的LIN表示法, ex: p, d, r, 1C, 3N
+func linBid(bid CbBid) string {
+	switch s := bid.PBN(); s {
+	case "Pass":
+		return "p"
+	case "X":
+		return "d"
+	case "XX":
+		return "r"
+	default:
+		return strings.TrimSuffix(s, "T")
+	}
+}
+
+// linVulnerable 身價的LIN表示法
+func linVulnerable(v Vulnerability) string {
+	switch v {
+	case VulNS:
+		return "n"
+	case VulEW:
+		return "e"
+	case VulAll:
+		return "b"
+	}
+	return "o"
+}
+
+// LIN 牌局紀錄轉成LIN字串: 玩家,發牌者與四家手牌,牌號,身價,競叫(含警示說明),每一墩出牌,攤牌宣告
+func (h *HandRecord) LIN() string {
+	var (
+		sb    strings.Builder
+		field = func(tag, value string) {
+			sb.WriteString(tag + "|" + value + "|")
+		}
+		names = make([]string, 0, 4)
+		hands = make([]string, 0, 4)
+	)
+
+	for _, idx := range linSeats {
+		names = append(names, strings.ReplaceAll(h.Players[idx], "|", ""))
+		hands = append(hands, linHand(h.Hands[idx]))
+	}
+	//發牌者: 南1,西2,北3,東4
+	dealer := seatIndex(h.Board.Dealer)
+	if dealer == 0 {
+		dealer = 4
+	}
+
+	field("pn", strings.Join(names, ","))
+	field("st", "")
+	field("md", fmt.Sprintf("%d%s", dealer, strings.Join(hands, ",")))
+	field("rh", "")
+	field("ah", fmt.Sprintf("Board %d", h.Board.Number))
+	field("sv", linVulnerable(h.Board.Vulnerable))
+
+	for _, b := range h.Auction {
+		if !b.Alert {
+			field("mb", linBid(CbBid(b.Bid)))
+			continue
+		}
+		//警示叫品: 叫品後加!, 說明放在an
+		field("mb", linBid(CbBid(b.Bid))+"!")
+		if b.Explanation != "" {
+			field("an", strings.ReplaceAll(b.Explanation, "|", " "))
+		}
+	}
+	field("pg", "")
+
+	//出牌, 每一墩由首打座位開始順時針
+	for _, t := range h.Tricks {
+		lead := seatIndex(uint8(t.Lead))
+		for i := uint8(0); i < 4; i++ {
+			field("pc", CbCard(t.Cards[(lead+i)%4]).PBN())
+		}
+		field("pg", "")
+	}
+
+	//攤牌宣告: 莊家方總吃墩數
+	if h.Claim != nil && h.Result != nil {
+		field("mc", fmt.Sprintf("%d", h.Result.Tricks))
+	}
+	return sb.String()
+}
+
+// LINViewerURL LIN字串的 BBO handviewer 連結
+func LINViewerURL(lin string) string {
+	return LINViewer + "?lin=" + url.QueryEscape(lin)
+}
+
+// HandLin 牌局紀錄的LIN字串與handviewer連結
+func (h *HandRecord) HandLin() HandLin {
+	lin := h.LIN()
+	return HandLin{
+		Room:  h.Room,
+		Board: h.Board.Number,
+		LIN:   lin,
+		URL:   LINViewerURL(lin),
+	}
+}
+
+// LastHand 該房間最近一局結算的牌局紀錄, 尚未有結算的牌局時回傳nil
+func (g *Game) LastHand() *HandRecord {
+	return g.lastHand.Load()
+}
+
+// SendHandLin 回覆使用者(user)最近一局結算牌局的LIN字串與handviewer連結(私人)
+func (g *Game) SendHandLin(user *RoomUser) {
+	if user.NsConn == nil || user.NsConn.Conn.IsClosed() {
+		return
+	}
+
+	//尚未有結算的牌局時LIN為空字串
+	lin := HandLin{Room: g.name}
+	if hand := g.LastHand(); hand != nil {
+		if !g.HandReleased(hand.Board.Number) {
+			g.sendUserError(user, ErrHandNotReleased)
+			return
+		}
+		lin = hand.HandLin()
+	}
+
+	body, err := json.Marshal(lin)
+	if err != nil {
+		g.log.Wrn("SendHandLin", slog.String(".", err.Error()))
+		return
+	}
+	if err = g.roomManager.SendBytes(user.NsConn, ClnRoomEvents.GamePrivateHandLin, body); err != nil {
+		g.log.Wrn("SendHandLin", slog.String(".", err.Error()))
+	}
+}
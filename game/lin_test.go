@@ -0,0 +1,111 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// linFields 依序取出LIN字串中某標籤(tag)的所有值
+func linFields(lin, tag string) (values []string) {
+	parts := strings.Split(lin, "|")
+	for i := 0; i+1 < len(parts); i += 2 {
+		if parts[i] == tag {
+			values = append(values, parts[i+1])
+		}
+	}
+	return
+}
+
+// linDeal md標籤轉回PBN Deal標籤(南家開始)
+func linDeal(md string) string {
+	hands := strings.Split(md[1:], ",")
+	for i, hand := range hands {
+		hands[i] = strings.NewReplacer("S", "", "H", ".", "D", ".", "C", ".").Replace(hand)
+	}
+	return "S:" + strings.Join(hands, " ")
+}
+
+func TestHandRecordLIN(t *testing.T) {
+	const deal = "N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432"
+	hands, err := ParsePBNDeal(deal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := [4]string{"e", "s", "w", "n"}
+
+	tests := []struct {
+		name   string
+		record HandRecord
+		want   string
+	}{
+		{
+			name: "警示叫品與攤牌宣告",
+			record: HandRecord{
+				Board:   newBoard(1),
+				Hands:   hands,
+				Players: players,
+				Auction: []AuctionBid{
+					{Seat: uint8(north), Bid: uint8(NT1), Alert: true, Explanation: "15-17"},
+					{Seat: uint8(east), Bid: uint8(Pass1)},
+					{Seat: uint8(south), Bid: uint8(Pass1)},
+					{Seat: uint8(west), Bid: uint8(Pass1)},
+				},
+				Tricks: []TrickPlay{{Lead: uint8(east), Winner: uint8(east), Cards: [4]uint8{heartAce, diamond2, club2, spade2}}},
+				Claim:  &HandClaim{Seat: uint8(east), Tricks: 12, Concede: false},
+				Result: &GameResult{Declarer: uint8(north), Contract: uint8(NT1), Tricks: 0},
+			},
+			want: "pn|s,w,n,e|st||md|3SHDAKQJT98765432C,SHDCAKQJT98765432,SAKQJT98765432HDC,SHAKQJT98765432DC|rh||ah|Board 1|sv|o|" +
+				"mb|1N!|an|15-17|mb|p|mb|p|mb|p|pg||" +
+				"pc|HA|pc|D2|pc|C2|pc|S2|pg||" +
+				"mc|0|",
+		},
+		{
+			name: "西家發牌雙方有身價,賭倍與再賭倍",
+			record: HandRecord{
+				Board:   newBoard(4),
+				Hands:   hands,
+				Players: [4]string{"e|1", "s", "w", "n"},
+				Auction: []AuctionBid{
+					{Seat: uint8(west), Bid: uint8(H1)},
+					{Seat: uint8(north), Bid: uint8(Db1)},
+					{Seat: uint8(east), Bid: uint8(Db1x2)},
+				},
+			},
+			want: "pn|s,w,n,e1|st||md|2SHDAKQJT98765432C,SHDCAKQJT98765432,SAKQJT98765432HDC,SHAKQJT98765432DC|rh||ah|Board 4|sv|b|" +
+				"mb|1H|mb|d|mb|r|pg||",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.record.LIN()
+			if got != tt.want {
+				t.Errorf("LIN() = %q, want %q", got, tt.want)
+			}
+
+			//md標籤能還原四家手牌
+			md := linFields(got, "md")
+			if len(md) != 1 {
+				t.Fatalf("md = %v", md)
+			}
+			back, err := ParsePBNDeal(linDeal(md[0]))
+			if err != nil {
+				t.Fatalf("ParsePBNDeal(%q) error = %v", linDeal(md[0]), err)
+			}
+			if PBNDeal(north, back) != deal {
+				t.Errorf("md = %q, want %q", PBNDeal(north, back), deal)
+			}
+
+			//mb標籤能還原競叫
+			mb := linFields(got, "mb")
+			if len(mb) != len(tt.record.Auction) {
+				t.Fatalf("mb = %v, want %d個叫品", mb, len(tt.record.Auction))
+			}
+			for i, s := range mb {
+				bid, err := ParsePBNBid(strings.NewReplacer("!", "", "p", "Pass", "d", "X", "r", "XX").Replace(s), 1)
+				if err != nil || bid != CbBid(tt.record.Auction[i].Bid) {
+					t.Errorf("mb[%d] = %q, want %s", i, s, CbBid(tt.record.Auction[i].Bid).PBN())
+				}
+			}
+		})
+	}
+}
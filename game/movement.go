@@ -0,0 +1,149 @@
+package game
+
+import "fmt"
+
+// 配對賽移位(Movement): 依配對數產生每回合各桌的南北,東西配對與要打的牌.
+// Mitchell: 南北配對固定在同一桌, 東西配對每回合往上一桌, 牌往下一桌(偶數桌在回合過半時東西配對多跳一桌)
+// Howell: 所有配對輪流相遇(循環賽), 每回合各桌打同一組牌(線上發牌不受實體牌套數量限制)
+// 配對數是奇數時加入一組虛擬配對(0), 遇到虛擬配對的配對該回合輪空
+
+// MovementKind 移位方式
+type MovementKind uint8
+
+const (
+	MovementMitchell MovementKind = iota
+	MovementHowell
+)
+
+func (k MovementKind) String() string {
+	switch k {
+	case MovementMitchell:
+		return "Mitchell"
+	case MovementHowell:
+		return "Howell"
+	}
+	return "MovementKind(?)"
+}
+
+type (
+	// MovementTable 一回合一桌的安排, 配對0表示輪空(該桌不打)
+	MovementTable struct {
+		Table  int      `json:"table"` //桌號,從1開始
+		NS     int      `json:"ns"`    //南北配對
+		EW     int      `json:"ew"`    //東西配對
+		Boards []uint32 `json:"boards"`
+	}
+
+	// MovementRound 一回合各桌的安排
+	MovementRound struct {
+		Round  int             `json:"round"` //回合,從1開始
+		Tables []MovementTable `json:"tables"`
+	}
+
+	// Movement 移位表
+	Movement struct {
+		Kind           MovementKind    `json:"kind"`
+		Pairs          int             `json:"pairs"`
+		Tables         int             `json:"tables"`
+		BoardsPerRound int             `json:"boardsPerRound"`
+		Rounds         []MovementRound `json:"rounds"`
+	}
+)
+
+// SitOut 該桌輪空
+func (t MovementTable) SitOut() bool {
+	return t.NS == 0 || t.EW == 0
+}
+
+// movementBoards 第group組的牌號, 每組boardsPerRound副
+func movementBoards(group, boardsPerRound int) []uint32 {
+	boards := make([]uint32, 0, boardsPerRound)
+	for i := 1; i <= boardsPerRound; i++ {
+		boards = append(boards, uint32(group*boardsPerRound+i))
+	}
+	return boards
+}
+
+// NewMitchell 產生配對數(pairs)的 Mitchell 移位, 每回合每桌打boardsPerRound副牌;
+// 南北配對為1~桌數, 東西配對為桌數+1~, 奇數桌打桌數回合, 偶數桌打桌數-1回合
+func NewMitchell(pairs, boardsPerRound int) (*Movement, error) {
+	if pairs < 5 || boardsPerRound < 1 {
+		return nil, fmt.Errorf("%w: Mitchell至少5組配對(3桌),每回合至少1副牌", ErrMovement)
+	}
+	var (
+		tables = (pairs + 1) / 2
+		rounds = tables
+		m      = &Movement{Kind: MovementMitchell, Pairs: pairs, Tables: tables, BoardsPerRound: boardsPerRound}
+	)
+	if tables%2 == 0 {
+		rounds--
+	}
+
+	for r := 0; r < rounds; r++ {
+		round := MovementRound{Round: r + 1}
+		//偶數桌: 回合過半後東西配對多往上一桌
+		skip := 0
+		if tables%2 == 0 && r >= tables/2 {
+			skip = 1
+		}
+		for t := 0; t < tables; t++ {
+			ew := tables + 1 + ((t-r-skip)%tables+tables)%tables
+			if ew > pairs {
+				ew = 0
+			}
+			table := MovementTable{Table: t + 1, NS: t + 1, EW: ew}
+			if !table.SitOut() {
+				table.Boards = movementBoards((t+r)%tables, boardsPerRound)
+			}
+			round.Tables = append(round.Tables, table)
+		}
+		m.Rounds = append(m.Rounds, round)
+	}
+	return m, nil
+}
+
+// NewHowell 產生配對數(pairs)的 Howell 移位(循環賽), 每組配對和其他配對各相遇一次, 每回合每桌打boardsPerRound副牌
+func NewHowell(pairs, boardsPerRound int) (*Movement, error) {
+	if pairs < 3 || boardsPerRound < 1 {
+		return nil, fmt.Errorf("%w: Howell至少3組配對,每回合至少1副牌", ErrMovement)
+	}
+	var (
+		players = pairs + pairs%2 //加入虛擬配對後的配對數
+		tables  = players / 2
+		rounds  = players - 1
+		m       = &Movement{Kind: MovementHowell, Pairs: pairs, Tables: tables, BoardsPerRound: boardsPerRound}
+		pair    = func(p int) int {
+			if p > pairs {
+				return 0
+			}
+			return p
+		}
+	)
+
+	for r := 0; r < rounds; r++ {
+		var (
+			round  = MovementRound{Round: r + 1}
+			boards = movementBoards(r, boardsPerRound)
+		)
+		for t := 0; t < tables; t++ {
+			var a, b int
+			if t == 0 {
+				//最後一組配對固定在第一桌
+				a, b = players, r+1
+			} else {
+				a, b = (r+t)%rounds+1, ((r-t)%rounds+rounds)%rounds+1
+			}
+			//輪流坐南北,東西
+			if (r+t)%2 == 1 {
+				a, b = b, a
+			}
+			table := MovementTable{Table: t + 1, NS: pair(a), EW: pair(b)}
+			if !table.SitOut() {
+				table.Boards = boards
+			}
+			round.Tables = append(round.Tables, table)
+		}
+		m.Rounds = append(m.Rounds, round)
+	}
+	return m, nil
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// checkRounds 每回合每組配對最多出現一次, 每組配對與同一組對手最多相遇一次, 不會重打同一副牌; 回傳相遇次數
+func checkRounds(t *testing.T, m *Movement) map[[2]int]int {
+	t.Helper()
+	var (
+		met    = map[[2]int]int{}
+		played = map[int]map[uint32]bool{}
+	)
+	for _, round := range m.Rounds {
+		if len(round.Tables) != m.Tables {
+			t.Fatalf("第%d回合 %d桌, want %d", round.Round, len(round.Tables), m.Tables)
+		}
+		seated := map[int]bool{}
+		for _, table := range round.Tables {
+			if table.SitOut() {
+				if table.Boards != nil {
+					t.Errorf("第%d回合第%d桌輪空卻有牌 %v", round.Round, table.Table, table.Boards)
+				}
+				continue
+			}
+			if len(table.Boards) != m.BoardsPerRound {
+				t.Errorf("第%d回合第%d桌 %d副牌, want %d", round.Round, table.Table, len(table.Boards), m.BoardsPerRound)
+			}
+			for _, p := range []int{table.NS, table.EW} {
+				if p < 1 || p > m.Pairs {
+					t.Fatalf("第%d回合第%d桌配對 %d 不合法", round.Round, table.Table, p)
+				}
+				if seated[p] {
+					t.Errorf("第%d回合配對%d坐了兩桌", round.Round, p)
+				}
+				seated[p] = true
+				if played[p] == nil {
+					played[p] = map[uint32]bool{}
+				}
+				for _, board := range table.Boards {
+					if played[p][board] {
+						t.Errorf("第%d回合配對%d重打第%d副牌", round.Round, p, board)
+					}
+					played[p][board] = true
+				}
+			}
+			met[[2]int{min(table.NS, table.EW), max(table.NS, table.EW)}]++
+		}
+	}
+	for pair, n := range met {
+		if n > 1 {
+			t.Errorf("配對%d與%d相遇%d次", pair[0], pair[1], n)
+		}
+	}
+	return met
+}
+
+func TestNewMitchell(t *testing.T) {
+	tests := []struct {
+		pairs  int
+		tables int
+		rounds int
+	}{
+		{5, 3, 3},
+		{6, 3, 3},
+		{7, 4, 3},
+		{8, 4, 3},
+		{9, 5, 5},
+		{10, 5, 5},
+		{12, 6, 5},
+		{14, 7, 7},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d組", tt.pairs), func(t *testing.T) {
+			m, err := NewMitchell(tt.pairs, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Tables != tt.tables || len(m.Rounds) != tt.rounds {
+				t.Fatalf("NewMitchell(%d) %d桌%d回合, want %d桌%d回合", tt.pairs, m.Tables, len(m.Rounds), tt.tables, tt.rounds)
+			}
+			for _, round := range m.Rounds {
+				for _, table := range round.Tables {
+					if table.NS != table.Table {
+						t.Errorf("第%d回合第%d桌南北配對 %d, 南北配對應固定在同一桌", round.Round, table.Table, table.NS)
+					}
+					if table.EW != 0 && table.EW <= m.Tables {
+						t.Errorf("第%d回合第%d桌東西配對 %d 是南北配對", round.Round, table.Table, table.EW)
+					}
+				}
+			}
+			checkRounds(t, m)
+		})
+	}
+}
+
+func TestNewHowell(t *testing.T) {
+	for _, pairs := range []int{3, 4, 5, 6, 8, 9} {
+		t.Run(fmt.Sprintf("%d組", pairs), func(t *testing.T) {
+			m, err := NewHowell(pairs, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := pairs + pairs%2 - 1; len(m.Rounds) != want {
+				t.Fatalf("NewHowell(%d) %d回合, want %d", pairs, len(m.Rounds), want)
+			}
+			//每回合各桌打同一組牌
+			for _, round := range m.Rounds {
+				want := movementBoards(round.Round-1, 3)
+				for _, table := range round.Tables {
+					if !table.SitOut() && !slices.Equal(table.Boards, want) {
+						t.Errorf("第%d回合第%d桌 %v, want %v", round.Round, table.Table, table.Boards, want)
+					}
+				}
+			}
+			//每組配對和其他配對都相遇一次
+			met := checkRounds(t, m)
+			if want := pairs * (pairs - 1) / 2; len(met) != want {
+				t.Errorf("NewHowell(%d) %d組相遇, want %d", pairs, len(met), want)
+			}
+		})
+	}
+}
+
+func TestMovementError(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(pairs, boardsPerRound int) (*Movement, error)
+		args [2]int
+	}{
+		{"Mitchell配對太少", NewMitchell, [2]int{4, 2}},
+		{"Mitchell沒有牌", NewMitchell, [2]int{6, 0}},
+		{"Howell配對太少", NewHowell, [2]int{2, 2}},
+		{"Howell沒有牌", NewHowell, [2]int{4, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.new(tt.args[0], tt.args[1]); !errors.Is(err, ErrMovement) {
+				t.Errorf("error = %v, want %v", err, ErrMovement)
+			}
+		})
+	}
+}
+
+func TestMovementTableSitOut(t *testing.T) {
+	tests := []struct {
+		table MovementTable
+		want  bool
+	}{
+		{MovementTable{NS: 1, EW: 2}, false},
+		{MovementTable{NS: 0, EW: 2}, true},
+		{MovementTable{NS: 1, EW: 0}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.table.SitOut(); got != tt.want {
+			t.Errorf("%+v.SitOut() = %t, want %t", tt.table, got, tt.want)
+		}
+	}
+}
@@ -21,13 +21,21 @@ type (
 		NumOfUsersInRoom string `json:"numOfUsersInRoom,omitempty"` //某特定房間人數
 		NumOfUsersOnSite string `json:"numOfUsersOnSite,omitempty"` //包含大廳人數,與所有房間人數
 		ClearScene       string `json:"clearScene,omitempty"`       //Done
+
+		SessionLeaderboard string `json:"sessionLeaderboard,omitempty"` //複式賽程成績排名 (廣播)
+		SessionRanking     string `json:"sessionRanking,omitempty"`     //複式賽程結束最終排名 (廣播)
+		TournamentRound    string `json:"tournamentRound,omitempty"`    //配對賽回合開始,各配對到哪一桌坐哪一方 (廣播)
 	}
 
 	// 屬性名稱是PrivateXxxx表示是通知個人私人訊號否則是大眾廣播訊號
 	roomNamespace struct {
-		UserPrivateTableInfo string `json:"userPrivateTableInfo,omitempty"`
-		UserPrivateJoin      string `json:"userPrivateJoin,omitempty"` //Done (私人)
-		UserJoin             string `json:"userJoin,omitempty"`        //Done (廣播)
+		UserPrivateTableInfo     string `json:"userPrivateTableInfo,omitempty"`
+		UserPrivateTablePhase    string `json:"userPrivateTablePhase,omitempty"`    //遊戲桌目前階段 (私人)
+		UserPrivateTableSnapshot string `json:"userPrivateTableSnapshot,omitempty"` //遊戲桌目前狀態 (私人)
+		UserPrivateJoin          string `json:"userPrivateJoin,omitempty"`          //Done (私人)
+		UserPrivateMemberToken   string `json:"userPrivateMemberToken,omitempty"`   //房間成員通行碼, 匯出牌局(HandServicePath)時出示 (私人)
+		UserPrivateCredential    string `json:"userPrivateCredential,omitempty"`    //出示房間憑證(私人房間密碼,座位憑證,座位通行碼), 進入房間或入座前送出 (私人請求)
+		UserJoin                 string `json:"userJoin,omitempty"`                 //Done (廣播)
 
 		UserPrivateLeave string `json:"userPrivateLeave,omitempty"` //Done (私人)
 		UserLeave        string `json:"userLeave,omitempty"`        //Done (廣播)
@@ -35,9 +43,18 @@ type (
 		TablePrivateOnSeat string `json:"tablePrivateOnSeat,omitempty"` //Done (私人)
 		TableOnSeat        string `json:"tableOnSeat,omitempty"`        //Done (廣播)
 
-		TablePrivateOnLeave string `json:"tablePrivateOnLeave,omitempty"` //Done (私人)
-		TableOnLeave        string `json:"tableOnLeave,omitempty"`        //Done (廣播)
-		TableOnChat         string `json:"tableOnChat,omitempty"`         //Done (廣播)
+		TablePrivateOnLeave   string `json:"tablePrivateOnLeave,omitempty"`   //Done (私人)
+		TableOnReserve        string `json:"tableOnReserve,omitempty"`        //玩家斷線保留座位 (廣播)
+		TableOnReclaim        string `json:"tableOnReclaim,omitempty"`        //玩家重新連線取回座位 (廣播)
+		TablePhase            string `json:"tablePhase,omitempty"`            //遊戲桌階段變更, 例如複式賽程中等待排入新的牌 (廣播)
+		TablePrivateSeatToken string `json:"tablePrivateSeatToken,omitempty"` //入座或取回座位時的座位憑證, 斷線重新入座前以 UserPrivateCredential 出示 (私人)
+
+		TablePrivateAddRobot       string `json:"tablePrivateAddRobot,omitempty"`       //玩家請求機器人入座 (私人)
+		TablePrivateRemoveRobot    string `json:"tablePrivateRemoveRobot,omitempty"`    //玩家請求機器人離座 (私人)
+		TablePrivateConventionCard string `json:"tablePrivateConventionCard,omitempty"` //入座玩家登記配對的約定卡 (私人)
+		TableConventionCard        string `json:"tableConventionCard,omitempty"`        //配對的約定卡 (廣播; 進入房間時私人)
+		TableOnLeave               string `json:"tableOnLeave,omitempty"`               //Done (廣播)
+		TableOnChat                string `json:"tableOnChat,omitempty"`                //Done (廣播)
 
 		Private string `json:"private,omitempty"` //Done
 		//遊戲開始發牌事件(clientEvent Only)
@@ -91,6 +108,51 @@ type (
 		// 四家競叫流局,重新發牌前,顯示另外三家手上的按牌
 		GameCardsShowUp string `json:"gameCardsShowUp,omitempty"` //Done (廣播)
 
+		// 牌號,發牌者與身價 (廣播)
+		GameBoard string `json:"gameBoard,omitempty"`
+		// 回合結束南北/東西吃墩數 (廣播)
+		GameTrickTally string `json:"gameTrickTally,omitempty"`
+		// 該局結算結果 (廣播)
+		GameResult string `json:"gameResult,omitempty"`
+		// 該局雙明手分析,可以做成的合約與最佳合約 (廣播)
+		GameDoubleDummy string `json:"gameDoubleDummy,omitempty"`
+		// 最近一局結算牌局的LIN字串與handviewer連結 (私人)
+		GamePrivateHandLin string `json:"gamePrivateHandLin,omitempty"`
+		// 複式賽程所有遊戲桌打完一副牌後的各桌結果 (廣播)
+		GameDuplicateBoard string `json:"gameDuplicateBoard,omitempty"`
+		// 複式賽程結束最終排名 (廣播)
+		GameSessionRanking string `json:"gameSessionRanking,omitempty"`
+		// 配對賽回合開始,各配對到哪一桌坐哪一方 (廣播)
+		GameTournamentRound string `json:"gameTournamentRound,omitempty"`
+		// 隊制賽每副牌IMP與累計比數 (廣播)
+		GameTeamScore string `json:"gameTeamScore,omitempty"`
+		// 進入隊制賽房間時目前的累計比數 (私人)
+		GamePrivateTeamScore string `json:"gamePrivateTeamScore,omitempty"`
+		// 莊家宣告攤牌或防家認輸 (私人請求)
+		GamePrivateClaim string `json:"gamePrivateClaim,omitempty"`
+		// 對手同意/拒絕攤牌宣告 (私人請求)
+		GamePrivateClaimAccept string `json:"gamePrivateClaimAccept,omitempty"`
+		GamePrivateClaimReject string `json:"gamePrivateClaimReject,omitempty"`
+		// 攤牌宣告與回覆狀態 (廣播)
+		GameClaim string `json:"gameClaim,omitempty"`
+		// 攤牌宣告的雙明手檢查 (廣播)
+		GameClaimCheck string `json:"gameClaimCheck,omitempty"`
+		// 撤回最後一個叫品或出牌, 首引除外 (私人請求)
+		GamePrivateUndo string `json:"gamePrivateUndo,omitempty"`
+		// 對手同意/拒絕撤回 (私人請求)
+		GamePrivateUndoAccept string `json:"gamePrivateUndoAccept,omitempty"`
+		GamePrivateUndoReject string `json:"gamePrivateUndoReject,omitempty"`
+		// 撤回請求與回覆狀態 (廣播)
+		GameUndo string `json:"gameUndo,omitempty"`
+		// 叫者警示的叫品與說明 (私人, 叫者的對手)
+		GamePrivateAlert string `json:"gamePrivateAlert,omitempty"`
+		// 叫牌並警示該叫品, json的 AlertBid (私人請求)
+		GamePrivateAlertBid string `json:"gamePrivateAlertBid,omitempty"`
+		// 詢問對手叫品的意思 (私人請求; 私人, 轉給叫者方)
+		GamePrivateAlertAsk string `json:"gamePrivateAlertAsk,omitempty"`
+		// 叫者方回答詢問 (私人請求; 私人, 轉給詢問者)
+		GamePrivateAlertAnswer string `json:"gamePrivateAlertAnswer,omitempty"`
+
 		//接收Space時發生錯誤的回覆
 		ErrorSpace string `json:"errorSpace,omitempty"` //Done
 		//接收Room時發生錯誤的回覆
@@ -114,6 +176,10 @@ var (
 		NumOfUsersInRoom: "cnouir",
 		NumOfUsersOnSite: "cnouos",
 		ClearScene:       "cs",
+
+		SessionLeaderboard: "slb",
+		SessionRanking:     "srk",
+		TournamentRound:    "trd",
 	}
 
 	lobbySpaceEvents = map[ServerClientEnum]*lobbyNamespace{
@@ -133,28 +199,55 @@ var (
 		TablePrivateOnSeat:  "tpos", //Done
 		TableOnChat:         "toc",  //Done
 
+		UserPrivateCredential: "upcr",
+
+		TablePrivateAddRobot:    "tpar",
+		TablePrivateRemoveRobot: "tprr",
+
+		TablePrivateConventionCard: "tpcc",
+
 		GamePrivateNotyBid:       "gpnb",
+		GamePrivateAlertBid:      "gpab",
 		GamePrivateFirstLead:     "gpfl",
 		GamePrivateCardPlayClick: "gcpc",
 		GamePrivateCardHover:     "h",
+		GamePrivateHandLin:       "gphl",
+		GamePrivateClaim:         "gpcl",
+		GamePrivateClaimAccept:   "gpcla",
+		GamePrivateClaimReject:   "gpclr",
+		GamePrivateUndo:          "gpu",
+		GamePrivateUndoAccept:    "gpua",
+		GamePrivateUndoReject:    "gpur",
+		GamePrivateAlertAsk:      "gpak",
+		GamePrivateAlertAnswer:   "gpan",
 		//NamespaceCommon: "cb.common",
 		//GameBid:         "game.contract",
 		//GamePlay:        "game.play",
 		//GameRoleStore:   "game.role",
 	}
 	// server -> client
+	// pb(github.com/moszorn/pb)是本專案外的模組, 無法在這裡新增 Proto Message; 牌號(gb),墩數(gtt),結算(gr)等
+	// pb沒有對應訊息的事件以json送出, 欄位依 game 套件結構的json標籤, 改成 Proto Message 要在pb模組進行
 	clientRoomSpace = &roomNamespace{
-		UserPrivateTableInfo: "upti",
-		UserJoin:             "uj",
-		UserLeave:            "ul",
-		UserPrivateJoin:      "upj", //Done
-		UserPrivateLeave:     "upl", //Done
-		NamespaceCommon:      "cb.common",
-		TableOnLeave:         "tol",  //Done
-		TablePrivateOnLeave:  "tpol", //Done
-		TableOnSeat:          "tos",  //Done
-		TablePrivateOnSeat:   "tpos", //Done
-		TableOnChat:          "toc",  //Done
+		UserPrivateTableInfo:     "upti",
+		UserPrivateTablePhase:    "uptp",
+		UserPrivateTableSnapshot: "upts",
+		UserJoin:                 "uj",
+		UserLeave:                "ul",
+		UserPrivateJoin:          "upj", //Done
+		UserPrivateLeave:         "upl", //Done
+		UserPrivateMemberToken:   "upmt",
+		NamespaceCommon:          "cb.common",
+		TableOnLeave:             "tol",  //Done
+		TablePrivateOnLeave:      "tpol", //Done
+		TableOnReserve:           "tore",
+		TableOnReclaim:           "torc",
+		TablePhase:               "tph",
+		TablePrivateSeatToken:    "tpst",
+		TableOnSeat:              "tos",  //Done
+		TablePrivateOnSeat:       "tpos", //Done
+		TableOnChat:              "toc",  //Done
+		TableConventionCard:      "tcc",
 
 		Private:            "private", // Done
 		GamePrivateDeal:    "gpd",     //Done
@@ -163,6 +256,23 @@ var (
 		GamePrivateNotyBid: "gpnb",    //Done
 
 		GameCardsShowUp: "g3h", // Done
+		GameBoard:       "gb",
+		GameTrickTally:  "gtt",
+		GameResult:      "gr",
+		GameDoubleDummy: "gdd",
+
+		GamePrivateHandLin:     "gphl",
+		GameDuplicateBoard:     "gdb",
+		GameSessionRanking:     "gsrk",
+		GameTournamentRound:    "gtrd",
+		GameTeamScore:          "gts",
+		GamePrivateTeamScore:   "gpts",
+		GameClaim:              "gcl",
+		GameClaimCheck:         "gclc",
+		GameUndo:               "gu",
+		GamePrivateAlert:       "gpal",
+		GamePrivateAlertAsk:    "gpak",
+		GamePrivateAlertAnswer: "gpan",
 
 		DevelopPayloadTest:        "dpt",  //Done
 		DevelopPrivatePayloadTest: "dppt", //Done
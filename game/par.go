@@ -0,0 +1,115 @@
+package game
+
+// 最佳合約(Par): 雙方都知道雙明手分析結果時, 競叫到最後的合約.
+// 簡化計算: 不考慮各家叫牌順序(誰先叫), 雙方輪流以更高的合約(做成或被賭倍的犧牲叫)爭取更好的分數, 直到一方無法改善
+
+// parBid 一方(南北或東西)叫到某個合約的莊家與計分
+type parBid struct {
+	declarer uint8 //座位索引(東0,南1,西2,北3)
+	tricks   uint8
+	score    int32 //莊家方得分, 做不成時以被賭倍計分
+}
+
+// MakeableContract 雙明手分析下莊家可以做成的最高合約
+type MakeableContract struct {
+	Declarer       uint8  `json:"declarer"` //莊家(CbSeat)
+	Contract       uint8  `json:"contract"` //合約(CbBid)
+	ContractString string `json:"contractString"`
+	Tricks         uint8  `json:"tricks"`
+}
+
+// Makeable 各家做莊在各花色可以做成的最高合約, 依莊家(東,南,西,北)與花色(梅花~無王)排列
+func (t DDTable) Makeable() (contracts []MakeableContract) {
+	for idx, seat := range playerSeats {
+		for strain := uint8(0); strain < ddStrains; strain++ {
+			tricks := t.Tricks[idx][strain]
+			if tricks <= bookTricks {
+				continue
+			}
+			bid := CbBid(contractBid(tricks-bookTricks, strain))
+			contracts = append(contracts, MakeableContract{
+				Declarer:       seat,
+				Contract:       uint8(bid),
+				ContractString: bid.String(),
+				Tricks:         tricks,
+			})
+		}
+	}
+	return
+}
+
+// Par 依身價(vul)計算最佳合約的計分結果, 雙方都做不成任何合約(四家PASS)時回傳nil
+func (t DDTable) Par(vul Vulnerability) *GameResult {
+	// bids[方][合約索引], 方 0:東西 1:南北, 合約索引依叫品順序 1♣️=0 ... 7NT=34
+	var bids [2][7 * ddStrains]parBid
+	for side := range bids {
+		for k := range bids[side] {
+			level, strain := uint8(k/ddStrains)+1, uint8(k%ddStrains)
+			//同方兩家取可以吃較多墩的一家做莊
+			declarer := uint8(side)
+			if t.Tricks[declarer+2][strain] > t.Tricks[declarer][strain] {
+				declarer += 2
+			}
+			bids[side][k] = t.bidScore(declarer, level, strain, vul)
+		}
+	}
+
+	// 開始: 可以做成的合約中得分較高的一方, 叫得分最高(一樣時最低)的合約
+	var (
+		side    = -1
+		current = -1
+		best    int32
+	)
+	for s := range bids {
+		for k, b := range bids[s] {
+			if b.tricks >= uint8(k/ddStrains)+1+bookTricks && b.score > best {
+				side, current, best = s, k, b.score
+			}
+		}
+	}
+	if side < 0 {
+		return nil
+	}
+
+	// 對方以更高的合約爭取比防守更好的分數, 直到一方無法改善
+	for {
+		other := 1 - side
+		next, nextScore := -1, -bids[side][current].score
+		for k := current + 1; k < len(bids[other]); k++ {
+			if score := bids[other][k].score; score > nextScore {
+				next, nextScore = k, score
+			}
+		}
+		if next < 0 {
+			break
+		}
+		side, current = other, next
+	}
+
+	var (
+		b        = bids[side][current]
+		contract = record{dbType: ZeroSuit, contract: CbBid(contractBid(uint8(current/ddStrains)+1, uint8(current%ddStrains)))}
+	)
+	if b.tricks < biddingLine(contract.contract)+bookTricks {
+		contract.isDouble, contract.dbType = true, DOUBLE
+	}
+	seat := CbSeat(playerSeats[b.declarer])
+	return scoring(seat, contract, b.tricks, vul.IsVulnerable(seat))
+}
+
+// bidScore 座位索引(declarer)做莊叫到線位(level)花色(strain)合約的計分
+func (t DDTable) bidScore(declarer, level, strain uint8, vul Vulnerability) parBid {
+	var (
+		seat     = CbSeat(playerSeats[declarer])
+		tricks   = t.Tricks[declarer][strain]
+		contract = record{dbType: ZeroSuit, contract: CbBid(contractBid(level, strain))}
+	)
+	if tricks < level+bookTricks {
+		contract.isDouble, contract.dbType = true, DOUBLE
+	}
+	return parBid{
+		declarer: declarer,
+		tricks:   tricks,
+		score:    scoring(seat, contract, tricks, vul.IsVulnerable(seat)).Score,
+	}
+}
@@ -0,0 +1,108 @@
+package game
+
+import "testing"
+
+// sideTable 南北兩家在各花色(梅花,方塊,紅心,黑桃,無王)吃到ns墩, 東西兩家吃到其餘的墩
+func sideTable(ns [ddStrains]uint8) (table DDTable) {
+	for strain := range ns {
+		for idx := range table.Tricks {
+			if isNS(int8(idx)) {
+				table.Tricks[idx][strain] = ns[strain]
+			} else {
+				table.Tricks[idx][strain] = uint8(NumOfCardsOnePlayer) - ns[strain]
+			}
+		}
+	}
+	return
+}
+
+// suitTable 四家各持一門花色(北黑桃,東紅心,南方塊,西梅花)的雙明手結果, 無王時首引方兌現整門花色
+var suitTable = DDTable{Tricks: [4][ddStrains]uint8{{13, 0, 13, 0, 0}, {0, 13, 0, 13, 0}, {13, 0, 13, 0, 0}, {0, 13, 0, 13, 0}}}
+
+// noTable 四家在各花色都只吃到6墩
+var noTable = DDTable{Tricks: [4][ddStrains]uint8{{6, 6, 6, 6, 6}, {6, 6, 6, 6, 6}, {6, 6, 6, 6, 6}, {6, 6, 6, 6, 6}}}
+
+func TestDDTablePar(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    DDTable
+		vul      Vulnerability
+		nilPar   bool
+		contract CbBid
+		double   bool
+		ns       int32
+	}{
+		{
+			name:   "雙方都做不成任何合約",
+			table:  noTable,
+			vul:    VulNone,
+			nilPar: true,
+		},
+		{
+			name:     "南北4黑桃無身價",
+			table:    sideTable([ddStrains]uint8{6, 6, 6, 10, 6}),
+			vul:      VulNone,
+			contract: CbBid(contractBid(4, 3)),
+			ns:       420,
+		},
+		{
+			name:     "南北4黑桃有身價,東西4無王賭倍犧牲",
+			table:    sideTable([ddStrains]uint8{6, 6, 6, 10, 6}),
+			vul:      VulNS,
+			contract: CbBid(contractBid(4, ddNoTrump)),
+			double:   true,
+			ns:       500,
+		},
+		{
+			name:     "東西5紅心賭倍犧牲",
+			table:    sideTable([ddStrains]uint8{6, 6, 4, 10, 6}),
+			vul:      VulNS,
+			contract: CbBid(contractBid(5, 2)),
+			double:   true,
+			ns:       300,
+		},
+		{
+			name:     "各持一門花色南北7黑桃",
+			table:    suitTable,
+			vul:      VulNone,
+			contract: CbBid(contractBid(7, 3)),
+			ns:       1510,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			par := tt.table.Par(tt.vul)
+			if tt.nilPar {
+				if par != nil {
+					t.Fatalf("Par() = %+v, want nil", par)
+				}
+				return
+			}
+			if par == nil {
+				t.Fatal("Par() = nil")
+			}
+			if CbBid(par.Contract) != tt.contract || (par.DoubleString != "") != tt.double || par.NS != tt.ns || par.EW != -tt.ns {
+				t.Errorf("Par() = %s%s NS:%d EW:%d, want %s double:%t NS:%d", par.ContractString, par.DoubleString, par.NS, par.EW, tt.contract, tt.double, tt.ns)
+			}
+		})
+	}
+}
+
+func TestDDTableMakeable(t *testing.T) {
+	tests := []struct {
+		name  string
+		table DDTable
+		want  int
+	}{
+		{"沒有做得成的合約", noTable, 0},
+		{"南北做得成黑桃,東西做得成其他花色", sideTable([ddStrains]uint8{6, 6, 6, 10, 6}), 2*1 + 2*4},
+		{"各持一門花色", suitTable, 4 * 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table.Makeable(); len(got) != tt.want {
+				t.Errorf("len(Makeable()) = %d, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PBN(Portable Bridge Notation): 牌(CbCard),座位(CbSeat),叫品(CbBid)與 PBN 表示法互轉,
+// 匯出完成的牌局紀錄(HandRecord), 匯入 PBN 牌局讓遊戲桌依指定的牌發牌(取代洗牌)
+
+const (
+	pbnSuits = "CDHS"          //花色索引(梅花0~黑桃3)
+	pbnRanks = "23456789TJQKA" //點數索引(2為0~A為12)
+	pbnSeats = "ESWN"          //座位索引(東0,南1,西2,北3)
+)
+
+// PBN 牌的 PBN 表示法(花色+點數), ex: SA, HT, C2
+func (c CbCard) PBN() string {
+	if c < CbCard(club2) || c > CbCard(spadeAce) {
+		return "-"
+	}
+	idx := uint8(c) - 1
+	return string([]byte{pbnSuits[idx/13], pbnRanks[idx%13]})
+}
+
+// ParsePBNCard PBN 表示法(花色+點數)的牌, 點數10可以是 T 或 10
+func ParsePBNCard(s string) (CbCard, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return CbCard(BaseCover), fmt.Errorf("%w: %q", ErrPBNCard, s)
+	}
+	suit := strings.IndexByte(pbnSuits, s[0])
+	rank := pbnRank(s[1:])
+	if suit < 0 || rank < 0 {
+		return CbCard(BaseCover), fmt.Errorf("%w: %q", ErrPBNCard, s)
+	}
+	return CbCard(suit*13 + rank + 1), nil
+}
+
+// pbnRank 點數索引(2為0~A為12), 不合法回傳-1
+func pbnRank(s string) int {
+	if s == "10" {
+		return strings.IndexByte(pbnRanks, 'T')
+	}
+	if len(s) != 1 {
+		return -1
+	}
+	return strings.IndexByte(pbnRanks, s[0])
+}
+
+// PBN 座位的 PBN 表示法(N,E,S,W)
+func (s CbSeat) PBN() string {
+	switch s {
+	case east, south, west, north:
+		return string(pbnSeats[seatIndex(uint8(s))])
+	}
+	return "-"
+}
+
+// ParsePBNSeat PBN 表示法(N,E,S,W)的座位
+func ParsePBNSeat(s string) (CbSeat, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 1 || strings.IndexByte(pbnSeats, s[0]) < 0 {
+		return seatYet, fmt.Errorf("%w: %q", ErrPBNSeat, s)
+	}
+	return CbSeat(playerSeats[strings.IndexByte(pbnSeats, s[0])]), nil
+}
+
+// PBN 叫品的 PBN 表示法, ex: 1C, 3NT, Pass, X, XX
+func (b CbBid) PBN() string {
+	if b < Pass1 || b > Db7x2 {
+		return "-"
+	}
+	switch (uint8(b) - uint8(Pass1)) % 8 {
+	case 0:
+		return "Pass"
+	case 6:
+		return "X"
+	case 7:
+		return "XX"
+	}
+	level, strain, _ := bidStrain(uint8(b))
+	if strain == strainNT {
+		return fmt.Sprintf("%dNT", level)
+	}
+	return fmt.Sprintf("%d%c", level, pbnSuits[strain])
+}
+
+// ParsePBNBid PBN 表示法的叫品, 叫品(Pass,X,XX)依目前合約線位(line)決定, 尚未有合約時line為1
+func ParsePBNBid(s string, line uint8) (CbBid, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if line < 1 || line > 7 {
+		line = 1
+	}
+	base := uint8(Pass1) + (line-1)*8
+	switch s {
+	case "PASS", "P", "AP":
+		return CbBid(base), nil
+	case "X":
+		return CbBid(base + 6), nil
+	case "XX":
+		return CbBid(base + 7), nil
+	}
+	if len(s) < 2 || s[0] < '1' || s[0] > '7' {
+		return BidYet, fmt.Errorf("%w: %q", ErrPBNBid, s)
+	}
+	level := s[0] - '0'
+	switch strain := s[1:]; strain {
+	case "NT", "N":
+		return CbBid(contractBid(level, strainNT)), nil
+	default:
+		if len(strain) != 1 || strings.IndexByte(pbnSuits, strain[0]) < 0 {
+			return BidYet, fmt.Errorf("%w: %q", ErrPBNBid, s)
+		}
+		return CbBid(contractBid(level, uint8(strings.IndexByte(pbnSuits, strain[0])))), nil
+	}
+}
+
+// pbnHand 一手牌的 PBN 表示法(黑桃.紅心.方塊.梅花), ex: AKQ.T98..J765432
+func pbnHand(cards []uint8) string {
+	var held [4][13]bool
+	for _, card := range cards {
+		if card >= club2 && card <= spadeAce {
+			held[(card-1)/13][(card-1)%13] = true
+		}
+	}
+	holding := make([]string, 0, 4)
+	for suit := 3; suit >= 0; suit-- {
+		var ranks []byte
+		//由大到小
+		for rank := len(pbnRanks) - 1; rank >= 0; rank-- {
+			if held[suit][rank] {
+				ranks = append(ranks, pbnRanks[rank])
+			}
+		}
+		holding = append(holding, string(ranks))
+	}
+	return strings.Join(holding, ".")
+}
+
+// PBNDeal PBN Deal標籤, first第一手座位, hands順序固定為東,南,西,北
+func PBNDeal(first CbSeat, hands [4][]uint8) string {
+	var (
+		sb  strings.Builder
+		idx = seatIndex(uint8(first))
+	)
+	sb.WriteString(first.PBN() + ":")
+	for i := uint8(0); i < 4; i++ {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(pbnHand(hands[(idx+i)%4]))
+	}
+	return sb.String()
+}
+
+// ParsePBNDeal 解析 PBN Deal標籤, 回傳各家手牌(東,南,西,北); 只有一家是未知(-)時以剩下的牌補上
+func ParsePBNDeal(s string) (hands [4][]uint8, err error) {
+	s = strings.TrimSpace(s)
+	colon := strings.IndexByte(s, ':')
+	if colon < 0 {
+		return hands, fmt.Errorf("%w: %q", ErrPBNDeal, s)
+	}
+	first, err := ParsePBNSeat(s[:colon])
+	if err != nil {
+		return hands, err
+	}
+	fields := strings.Fields(s[colon+1:])
+	if len(fields) != 4 {
+		return hands, fmt.Errorf("%w: 需要四手牌 %q", ErrPBNDeal, s)
+	}
+
+	var (
+		held    [NumOfCardsInDeck + 1]bool
+		unknown = -1
+	)
+	for i, field := range fields {
+		idx := (int(seatIndex(uint8(first))) + i) % 4
+		if field == "-" {
+			if unknown >= 0 {
+				return hands, fmt.Errorf("%w: 超過一手牌未知 %q", ErrPBNDeal, s)
+			}
+			unknown = idx
+			continue
+		}
+		suits := strings.Split(strings.ToUpper(field), ".")
+		if len(suits) != 4 {
+			return hands, fmt.Errorf("%w: %q", ErrPBNDeal, field)
+		}
+		for i, ranks := range suits {
+			suit := 3 - i //黑桃.紅心.方塊.梅花
+			for _, r := range ranks {
+				rank := strings.IndexRune(pbnRanks, r)
+				if rank < 0 {
+					return hands, fmt.Errorf("%w: %q", ErrPBNDeal, field)
+				}
+				card := uint8(suit*13 + rank + 1)
+				if held[card] {
+					return hands, fmt.Errorf("%w: 重複的牌 %s", ErrPBNDeal, CbCard(card).PBN())
+				}
+				held[card] = true
+				hands[idx] = append(hands[idx], card)
+			}
+		}
+	}
+	if unknown >= 0 {
+		for card := club2; card <= spadeAce; card++ {
+			if !held[card] {
+				hands[unknown] = append(hands[unknown], card)
+			}
+		}
+	}
+	for idx := range hands {
+		if len(hands[idx]) != NumOfCardsOnePlayer {
+			return hands, fmt.Errorf("%w: %s家%d張牌", ErrPBNDeal, CbSeat(playerSeats[idx]).PBN(), len(hands[idx]))
+		}
+	}
+	return hands, nil
+}
+
+// pbnVulnerable PBN Vulnerable標籤值
+func pbnVulnerable(s string) (Vulnerability, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "NONE", "LOVE", "-":
+		return VulNone, nil
+	case "NS":
+		return VulNS, nil
+	case "EW":
+		return VulEW, nil
+	case "ALL", "BOTH":
+		return VulAll, nil
+	}
+	return VulNone, fmt.Errorf("%w: Vulnerable %q", ErrPBNDeal, s)
+}
+
+// pbnContract 由競叫紀錄取得最後合約與是否賭倍(X),再賭倍(XX), 四家PASS時 contract為BidYet
+func pbnContract(auction []AuctionBid) (contract CbBid, declarer CbSeat, double string) {
+	declarer = seatYet
+	for i, b := range auction {
+		_, strain, ok := bidStrain(b.Bid)
+		if !ok {
+			switch (b.Bid - uint8(Pass1)) % 8 {
+			case 6:
+				double = "X"
+			case 7:
+				double = "XX"
+			}
+			continue
+		}
+		contract, double = CbBid(b.Bid), ""
+		//同方最先叫出該花色者為莊家
+		for _, first := range auction[:i+1] {
+			if _, s, ok := bidStrain(first.Bid); ok && s == strain && seatIndex(first.Seat)%2 == seatIndex(b.Seat)%2 {
+				declarer = CbSeat(first.Seat)
+				break
+			}
+		}
+	}
+	return
+}
+
+// PBN 匯出牌局紀錄為 PBN 格式(一局), 包含 Deal,Dealer,Vulnerable,Auction,Play,Contract,Declarer,Result 標籤
+func (h *HandRecord) PBN() string {
+	var (
+		sb     strings.Builder
+		dealer = CbSeat(h.Board.Dealer)
+		tag    = func(name, value string) {
+			fmt.Fprintf(&sb, "[%s \"%s\"]\n", name, strings.ReplaceAll(value, `"`, `\"`))
+		}
+	)
+
+	tag("Event", "")
+	tag("Site", h.Room)
+	tag("Date", h.Time.Format("2006.01.02"))
+	tag("Board", strconv.FormatUint(uint64(h.Board.Number), 10))
+	tag("West", h.Players[seatIndex(uint8(west))])
+	tag("North", h.Players[seatIndex(uint8(north))])
+	tag("East", h.Players[seatIndex(uint8(east))])
+	tag("South", h.Players[seatIndex(uint8(south))])
+	tag("Dealer", dealer.PBN())
+	tag("Vulnerable", h.Board.Vulnerable.String())
+	tag("Deal", PBNDeal(dealer, h.Hands))
+
+	contract, declarer, double := pbnContract(h.Auction)
+	if contract == BidYet {
+		tag("Declarer", "")
+		tag("Contract", "Pass")
+		tag("Result", "")
+	} else {
+		tag("Declarer", declarer.PBN())
+		tag("Contract", contract.PBN()+double)
+		if h.Result != nil {
+			tag("Result", strconv.Itoa(int(h.Result.Tricks)))
+		}
+	}
+
+	//競叫, 每行四個叫品
+	first := dealer
+	if len(h.Auction) > 0 {
+		first = CbSeat(h.Auction[0].Seat)
+	}
+	tag("Auction", first.PBN())
+	for i, b := range h.Auction {
+		sb.WriteString(CbBid(b.Bid).PBN())
+		if i%4 == 3 || i == len(h.Auction)-1 {
+			sb.WriteByte('\n')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	//出牌, 每行一墩, 欄位順序由首引座位開始順時針
+	if contract != BidYet && len(h.Tricks) > 0 {
+		lead := seatIndex(uint8(h.Tricks[0].Lead))
+		tag("Play", CbSeat(playerSeats[lead]).PBN())
+		for _, t := range h.Tricks {
+			cards := make([]string, 0, 4)
+			for i := uint8(0); i < 4; i++ {
+				cards = append(cards, CbCard(t.Cards[(lead+i)%4]).PBN())
+			}
+			sb.WriteString(strings.Join(cards, " ") + "\n")
+		}
+		if h.Claim != nil || len(h.Tricks) < NumOfCardsOnePlayer {
+			sb.WriteString("*\n")
+		}
+	}
+	return sb.String()
+}
+
+// PresetDeal 匯入的牌局, Board.Number為0時依遊戲桌牌號計數決定牌號, 發牌者與身價有標籤時依標籤, 否則依牌號
+type PresetDeal struct {
+	Board Board      `json:"board"`
+	Hands [4][]uint8 `json:"hands"` //順序固定為東,南,西,北
+
+	hasDealer, hasVulnerable bool //有 Dealer, Vulnerable標籤
+}
+
+// board 以匯入牌局發牌時的牌號,發牌者與身價, counted為依遊戲桌牌號計數的牌
+func (d PresetDeal) board(counted Board) Board {
+	if d.Board.Number > 0 {
+		return d.Board
+	}
+	if d.hasDealer {
+		counted.Dealer = d.Board.Dealer
+	}
+	if d.hasVulnerable {
+		counted.Vulnerable, counted.VulnerableString = d.Board.Vulnerable, d.Board.VulnerableString
+	}
+	return counted
+}
+
+var pbnTag = regexp.MustCompile(`^\[\s*(\w+)\s+"(.*)"\s*\]`)
+
+// ParsePBN 讀取 PBN 檔案中所有有 Deal標籤的牌局, 支援 "#" 沿用前一局的標籤值
+func ParsePBN(r io.Reader) (deals []PresetDeal, err error) {
+	var (
+		scanner  = bufio.NewScanner(r)
+		tags     = map[string]string{}
+		previous = map[string]string{}
+		comment  bool
+		lineNo   int
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	flush := func() error {
+		if len(tags) == 0 {
+			return nil
+		}
+		defer func() {
+			previous, tags = tags, map[string]string{}
+		}()
+		for name, value := range tags {
+			if value == "#" {
+				tags[name] = previous[name]
+			}
+		}
+		if tags["Deal"] == "" {
+			return nil
+		}
+		deal, err := presetDeal(tags)
+		if err != nil {
+			return fmt.Errorf("第%d行: %w", lineNo, err)
+		}
+		deals = append(deals, deal)
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		// {} 註解可以跨行
+		if comment {
+			end := strings.IndexByte(line, '}')
+			if end < 0 {
+				continue
+			}
+			line, comment = line[end+1:], false
+		}
+		if start := strings.IndexByte(line, '{'); start >= 0 && !strings.HasPrefix(strings.TrimSpace(line), "[") {
+			end := strings.IndexByte(line[start:], '}')
+			if end < 0 {
+				line, comment = line[:start], true
+			} else {
+				line = line[:start] + line[start+end+1:]
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "%"), strings.HasPrefix(line, ";"):
+			continue
+		case line == "":
+			if err = flush(); err != nil {
+				return nil, err
+			}
+		case pbnTag.MatchString(line):
+			m := pbnTag.FindStringSubmatch(line)
+			tags[m[1]] = m[2]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err = flush(); err != nil {
+		return nil, err
+	}
+	return deals, nil
+}
+
+// presetDeal 由一局的標籤(Board,Dealer,Vulnerable,Deal)產生匯入牌局
+func presetDeal(tags map[string]string) (deal PresetDeal, err error) {
+	if deal.Hands, err = ParsePBNDeal(tags["Deal"]); err != nil {
+		return
+	}
+	if number, e := strconv.ParseUint(strings.TrimSpace(tags["Board"]), 10, 32); e == nil && number > 0 {
+		deal.Board = newBoard(uint32(number))
+	}
+	if s, ok := tags["Dealer"]; ok && s != "" {
+		dealer, e := ParsePBNSeat(s)
+		if e != nil {
+			return deal, e
+		}
+		deal.Board.Dealer, deal.hasDealer = uint8(dealer), true
+	}
+	if s, ok := tags["Vulnerable"]; ok && s != "" {
+		vul, e := pbnVulnerable(s)
+		if e != nil {
+			return deal, e
+		}
+		deal.Board.Vulnerable, deal.Board.VulnerableString = vul, vul.String()
+		deal.hasVulnerable = true
+	}
+	return deal, nil
+}
+
+// presetDeals 遊戲桌待發的匯入牌局, 依匯入順序發牌, 發完後恢復洗牌
+type presetDeals struct {
+	mu    sync.Mutex
+	deals []PresetDeal
+}
+
+// PresetDeals 加入匯入牌局, 之後每局依序以匯入牌局發牌(取代洗牌), 回傳待發的牌局數
+func (g *Game) PresetDeals(deals ...PresetDeal) int {
+	g.presets.mu.Lock()
+	defer g.presets.mu.Unlock()
+	g.presets.deals = append(g.presets.deals, deals...)
+	return len(g.presets.deals)
+}
+
+// ClearPresetDeals 清除待發的匯入牌局, 恢復洗牌
+func (g *Game) ClearPresetDeals() {
+	g.presets.mu.Lock()
+	defer g.presets.mu.Unlock()
+	g.presets.deals = nil
+}
+
+// nextPresetDeal 取出下一局待發的匯入牌局
+func (g *Game) nextPresetDeal() (deal PresetDeal, ok bool) {
+	g.presets.mu.Lock()
+	defer g.presets.mu.Unlock()
+	if len(g.presets.deals) == 0 {
+		return deal, false
+	}
+	deal = g.presets.deals[0]
+	g.presets.deals = g.presets.deals[1:]
+	return deal, true
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPBNDealRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		deal string
+		want string //空白表示與deal相同
+	}{
+		{
+			name: "各持一門花色",
+			deal: "N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432",
+		},
+		{
+			name: "東家開始",
+			deal: "E:AKQ.T98.J76.5432 JT9.AKQ.5432.J76 876.765.AKQ.AKQT 5432.J432.T98.98",
+		},
+		{
+			name: "小寫與一家未知",
+			deal: "s:akq.t98.j76.5432 jt9.akq.5432.j76 876.765.akq.akqt -",
+			want: "S:AKQ.T98.J76.5432 JT9.AKQ.5432.J76 876.765.AKQ.AKQT 5432.J432.T98.98",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hands, err := ParsePBNDeal(tt.deal)
+			if err != nil {
+				t.Fatalf("ParsePBNDeal() error = %v", err)
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.deal
+			}
+			first, _ := ParsePBNSeat(tt.deal[:1])
+			if got := PBNDeal(first, hands); got != want {
+				t.Errorf("PBNDeal() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParsePBNDealError(t *testing.T) {
+	tests := []struct {
+		name string
+		deal string
+	}{
+		{"沒有第一手座位", "AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432"},
+		{"只有三手牌", "N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432."},
+		{"重複的牌", "N:AKQJT98765432... A.KQJT98765432.. ..AKQJT98765432. ...AKQJT98765432"},
+		{"張數不對", "N:AKQJT9876543... .AKQJT98765432.. ..AKQJT98765432. 2..AKQJT98765432"},
+		{"兩手未知", "N:AKQJT98765432... .AKQJT98765432.. - -"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePBNDeal(tt.deal); !errors.Is(err, ErrPBNDeal) {
+				t.Errorf("ParsePBNDeal() error = %v, want %v", err, ErrPBNDeal)
+			}
+		})
+	}
+}
+
+func TestParsePBN(t *testing.T) {
+	const deal = "N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432"
+	counted := newBoard(6) //東家發牌, 東西有身價
+
+	tests := []struct {
+		name string
+		pbn  string
+		want []Board
+	}{
+		{
+			name: "Board標籤決定牌號,發牌者與身價",
+			pbn:  `[Board "3"]` + "\n" + `[Deal "` + deal + `"]` + "\n",
+			want: []Board{newBoard(3)},
+		},
+		{
+			name: "沒有Board標籤時套用Dealer,Vulnerable標籤",
+			pbn:  `[Dealer "S"]` + "\n" + `[Vulnerable "All"]` + "\n" + `[Deal "` + deal + `"]` + "\n",
+			want: []Board{{Number: counted.Number, Dealer: uint8(south), Vulnerable: VulAll, VulnerableString: VulAll.String()}},
+		},
+		{
+			name: "沒有任何標籤時依牌號計數",
+			pbn:  `[Deal "` + deal + `"]` + "\n",
+			want: []Board{counted},
+		},
+		{
+			name: "#沿用前一局標籤, 註解與沒有Deal的區塊略過",
+			pbn: strings.Join([]string{
+				`% PBN 2.1`,
+				`[Event "test"]`,
+				``,
+				`{ 註解`,
+				`  跨行 }`,
+				`[Dealer "W"]`,
+				`[Deal "` + deal + `"]`,
+				``,
+				`[Dealer "#"]`,
+				`[Deal "#"]`,
+			}, "\n"),
+			want: []Board{
+				{Number: counted.Number, Dealer: uint8(west), Vulnerable: counted.Vulnerable, VulnerableString: counted.VulnerableString},
+				{Number: counted.Number, Dealer: uint8(west), Vulnerable: counted.Vulnerable, VulnerableString: counted.VulnerableString},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deals, err := ParsePBN(strings.NewReader(tt.pbn))
+			if err != nil {
+				t.Fatalf("ParsePBN() error = %v", err)
+			}
+			if len(deals) != len(tt.want) {
+				t.Fatalf("ParsePBN() %d局, want %d", len(deals), len(tt.want))
+			}
+			for i, d := range deals {
+				if got := d.board(counted); got != tt.want[i] {
+					t.Errorf("deals[%d].board() = %+v, want %+v", i, got, tt.want[i])
+				}
+				if got := PBNDeal(north, d.Hands); got != deal {
+					t.Errorf("deals[%d].Hands = %q, want %q", i, got, deal)
+				}
+			}
+		})
+	}
+}
+
+func TestHandRecordPBNRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		deal   string
+		number uint32
+	}{
+		{"牌號1", "N:AKQ.T98.J76.5432 JT9.AKQ.5432.J76 876.765.AKQ.AKQT 5432.J432.T98.98", 1},
+		{"牌號16", "N:AKQJT98765432... .AKQJT98765432.. ..AKQJT98765432. ...AKQJT98765432", 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hands, err := ParsePBNDeal(tt.deal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := &HandRecord{
+				Room:    "room0x0",
+				Board:   newBoard(tt.number),
+				Hands:   hands,
+				Players: [4]string{"east", "south", "west", "north"},
+				Auction: []AuctionBid{{Seat: uint8(north), Bid: uint8(Pass1)}},
+			}
+
+			deals, err := ParsePBN(strings.NewReader(record.PBN()))
+			if err != nil {
+				t.Fatalf("ParsePBN() error = %v", err)
+			}
+			if len(deals) != 1 {
+				t.Fatalf("ParsePBN() %d局, want 1", len(deals))
+			}
+			if deals[0].Board != record.Board {
+				t.Errorf("Board = %+v, want %+v", deals[0].Board, record.Board)
+			}
+			for idx := range hands {
+				got, want := slices.Clone(deals[0].Hands[idx]), slices.Clone(hands[idx])
+				slices.Sort(got)
+				slices.Sort(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("Hands[%d] = %v, want %v", idx, got, want)
+				}
+			}
+		})
+	}
+}
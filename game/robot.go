@@ -0,0 +1,337 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// 機器人玩家: 沒有連線(NsConn)的玩家入座遊戲桌, 讓離峰時段不滿四人的房間也能開局.
+// 機器人與真人玩家走相同的叫牌/出牌流程(notyBid, firstLead, cardPlayClick), 輪到機器人座位時由輪次計時(startTurnTimer)觸發
+
+type (
+	// Robot 機器人叫牌/出牌策略, 依遊戲桌狀態(以機器人座位權限遮蔽)決定叫品與出牌
+	Robot interface {
+		// Bid 回傳叫品, 不合法的叫品以PASS代替
+		Bid(view *RobotView) uint8
+		// Play 回傳打出的牌, 必須是 view.Legal 其中一張, 否則打出 view.Legal 第一張
+		Play(view *RobotView) uint8
+	}
+
+	// RobotView 機器人決策時看到的遊戲桌狀態
+	RobotView struct {
+		*TableSnapshot
+		Seat     uint8     //機器人座位
+		PlaySeat uint8     //被打出牌的座位(莊家打夢家的牌時為夢家)
+		PassBid  uint8     //與目前最新叫品同線位的PASS叫品
+		Legal    []uint8   //合法的出牌(僅出牌時)
+		Follow   CardRange //本回合跟牌區間, 回合首打時為 NKings
+
+		OurCard   *ConventionCard //己方配對的約定卡, 沒有登記為nil
+		TheirCard *ConventionCard //對手配對的約定卡, 沒有登記為nil
+	}
+)
+
+// robotName 機器人在座位(seat)上的名稱
+func robotName(seat uint8) string {
+	return fmt.Sprintf("Robot(%s)", CbSeat(seat))
+}
+
+// SetRobotTakeover 設定斷線保留座位逾時後是否由機器人接手(遊戲繼續), 否則釋放座位並中斷遊戲
+func (g *Game) SetRobotTakeover(takeover bool) {
+	g.robotTakeover.Store(takeover)
+}
+
+// RobotTakeover 斷線保留座位逾時後是否由機器人接手
+func (g *Game) RobotTakeover() bool {
+	return g.robotTakeover.Load()
+}
+
+// AddRobot 入座玩家(user)請求機器人入座空位(user.PlaySeat8)
+func (g *Game) AddRobot(user *RoomUser) {
+	if viewerSeat(user.NsConn) == valueNotSet {
+		g.sendUserError(user, ErrUserNotInPlay)
+		return
+	}
+	if err := g.roomManager.AddRobot(user.PlaySeat8, NewRuleRobot()); err != nil {
+		g.log.Wrn("AddRobot", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// RemoveRobot 入座玩家(user)請求座位(user.PlaySeat8)上的機器人離座
+func (g *Game) RemoveRobot(user *RoomUser) {
+	if viewerSeat(user.NsConn) == valueNotSet {
+		g.sendUserError(user, ErrUserNotInPlay)
+		return
+	}
+	if err := g.roomManager.RemoveRobot(user.PlaySeat8); err != nil {
+		g.log.Wrn("RemoveRobot", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// sendUserError 回覆使用者(user)請求錯誤
+func (g *Game) sendUserError(user *RoomUser, err error) {
+	if user.NsConn != nil && !user.NsConn.Conn.IsClosed() {
+		user.NsConn.Emit(ClnRoomEvents.ErrorRoom, []byte(err.Error()))
+	}
+}
+
+// legalCards 座位(seat)目前可以打出的牌, 非回合首打時手上有首打花色必須跟牌
+func (g *Game) legalCards(seat uint8) (legal []uint8) {
+	var (
+		follow       []uint8
+		isRoundStart = g.countingInPlayCard%4 == 0
+	)
+	for _, c := range g.deckInPlay[seat] {
+		if c == uint8(BaseCover) {
+			continue
+		}
+		legal = append(legal, c)
+		if !isRoundStart && g.roundMin <= c && c <= g.roundMax {
+			follow = append(follow, c)
+		}
+	}
+	if len(follow) > 0 {
+		return follow
+	}
+	return legal
+}
+
+// robotView 以機器人座位(seat)權限建立決策用的遊戲桌狀態
+func (g *Game) robotView(seat, playSeat uint8) *RobotView {
+	view := &RobotView{
+		TableSnapshot: g.snapshot(seat),
+		Seat:          seat,
+		PlaySeat:      playSeat,
+		PassBid:       g.engine.passBid(),
+		Follow:        NKings,
+		OurCard:       g.ConventionCard(seat),
+		TheirCard:     g.ConventionCard(playerSeats[(seatIndex(seat)+1)%4]),
+	}
+	if g.countingInPlayCard%4 != 0 {
+		view.Follow = CardRange{g.roundMin, g.roundMax}
+	}
+	return view
+}
+
+// robotBid 機器人(seat)決定叫品, 不合法的叫品以PASS代替
+func (g *Game) robotBid(robot Robot, seat uint8) uint8 {
+	view := g.robotView(seat, seat)
+	bid := robot.Bid(view)
+	if err := g.engine.bidHistory.validBid(seat, bid); err != nil {
+		g.log.Wrn("robotBid", slog.String(".", err.Error()))
+		return view.PassBid
+	}
+	return bid
+}
+
+// robotPlay 機器人(seat)決定打出座位(playSeat)的牌, 不合法的出牌以第一張合法的牌代替
+func (g *Game) robotPlay(robot Robot, seat, playSeat uint8) uint8 {
+	view := g.robotView(seat, playSeat)
+	view.Legal = g.legalCards(playSeat)
+	if len(view.Legal) == 0 {
+		return uint8(BaseCover)
+	}
+	card := robot.Play(view)
+	if !slices.Contains(view.Legal, card) {
+		g.log.Wrn("robotPlay", slog.String(".", fmt.Sprintf("%s 機器人打出不合法的牌 %s", CbSeat(seat), CbCard(card))))
+		return view.Legal[0]
+	}
+	return card
+}
+
+/*============================================================================================*/
+
+// ruleRobot 簡單規則機器人: 依大牌點(HCP)與牌型開叫/回應, 出牌只求合法並盡量以最小的牌贏墩
+type ruleRobot struct{}
+
+// NewRuleRobot 簡單規則機器人
+func NewRuleRobot() Robot {
+	return ruleRobot{}
+}
+
+// 叫品花色(strain)索引 梅花0,方塊1,紅心2,黑桃3,無王4
+const strainNT = 4
+
+// contractBid 線位(level 1~7)與花色(strain)的合約叫品
+func contractBid(level, strain uint8) uint8 {
+	return uint8(C1) + (level-1)*8 + strain
+}
+
+// bidStrain 合約叫品的線位與花色, 不是合約叫品(PASS,Double,Redouble) ok 為false
+func bidStrain(bid uint8) (level, strain uint8, ok bool) {
+	if bid < uint8(C1) || bid > uint8(Db7x2) {
+		return 0, 0, false
+	}
+	offset := (bid - uint8(Pass1)) % 8
+	if offset == 0 || offset > strainNT+1 {
+		return 0, 0, false
+	}
+	return (bid-uint8(Pass1))/8 + 1, offset - 1, true
+}
+
+// cardSuit 牌的花色索引 梅花0,方塊1,紅心2,黑桃3
+func cardSuit(card uint8) uint8 {
+	return (card - 1) / 13
+}
+
+// handShape 大牌點(A4,K3,Q2,J1)與各花色張數
+func handShape(hand []uint8) (hcp int, lengths [4]int) {
+	for _, c := range hand {
+		if c == uint8(BaseCover) {
+			continue
+		}
+		lengths[cardSuit(c)]++
+		if rank := int((c - 1) % 13); rank >= 9 {
+			hcp += rank - 8
+		}
+	}
+	return
+}
+
+// isBalanced 平均牌型: 沒有缺門,單張,最多一個雙張
+func isBalanced(lengths [4]int) bool {
+	doubletons := 0
+	for _, l := range lengths {
+		switch {
+		case l < 2:
+			return false
+		case l == 2:
+			doubletons++
+		}
+	}
+	return doubletons <= 1
+}
+
+// longestSuit 最長的花色, 一樣長時取較高的花色
+func longestSuit(lengths [4]int) (suit uint8) {
+	for s := 3; s >= 0; s-- {
+		if lengths[s] > lengths[suit] {
+			suit = uint8(s)
+		}
+	}
+	return
+}
+
+func (ruleRobot) Bid(view *RobotView) uint8 {
+	hcp, lengths := handShape(view.Hands[view.Seat])
+
+	// 最後的合約叫品
+	var (
+		last    SnapshotBid
+		hasLast bool
+	)
+	for _, b := range view.Auction {
+		if _, _, ok := bidStrain(b.Bid); ok {
+			last, hasLast = b, true
+		}
+	}
+
+	switch {
+	case !hasLast: /*開叫*/
+		switch {
+		case 15 <= hcp && hcp <= 17 && isBalanced(lengths):
+			return contractBid(1, strainNT)
+		case hcp >= 12:
+			return contractBid(1, longestSuit(lengths))
+		}
+
+	case !isOpponent(view.Seat, last.Seat): /*回應同伴*/
+		level, strain, _ := bidStrain(last.Bid)
+		if level != 1 || hcp < 6 {
+			break
+		}
+		if strain == strainNT {
+			switch {
+			case hcp >= 10:
+				return contractBid(3, strainNT)
+			case hcp >= 8:
+				return contractBid(2, strainNT)
+			}
+			break
+		}
+		support := 4 //低花需要四張支持
+		if strain >= 2 {
+			support = 3
+		}
+		if lengths[strain] >= support {
+			if hcp >= 10 {
+				return contractBid(3, strain)
+			}
+			return contractBid(2, strain)
+		}
+		return contractBid(1, strainNT)
+	}
+	return view.PassBid
+}
+
+func (ruleRobot) Play(view *RobotView) uint8 {
+	if len(view.Legal) == 1 {
+		return view.Legal[0]
+	}
+	//依花色,點數由小到大排列, 以下"最小的牌"都以此為準
+	legal := slices.Clone(view.Legal)
+	slices.Sort(legal)
+
+	// 回合首打: 最長花色最小的牌
+	if view.Follow == NKings {
+		_, lengths := handShape(legal)
+		suit := longestSuit(lengths)
+		for _, c := range legal {
+			if cardSuit(c) == suit {
+				return c
+			}
+		}
+		return legal[0]
+	}
+
+	var (
+		leadSuit = cardSuit(view.Follow[0])
+		trump    = uint8(strainNT)
+		partner  uint8
+		winner   uint8 //目前桌面最大的牌
+		winSeat  uint8 = valueNotSet
+	)
+	if view.Contract != nil {
+		trump = view.Contract.Suit
+	}
+	partner, _ = GetPartnerByPlayerSeat(view.PlaySeat)
+
+	beats := func(c, than uint8) bool {
+		switch {
+		case than == uint8(BaseCover):
+			return true
+		case cardSuit(c) == cardSuit(than):
+			return c > than
+		default:
+			return cardSuit(c) == trump || (cardSuit(c) == leadSuit && cardSuit(than) != trump)
+		}
+	}
+	for i, c := range view.Trick {
+		if c != uint8(BaseCover) && (cardSuit(c) == leadSuit || cardSuit(c) == trump) && beats(c, winner) {
+			winner, winSeat = c, playerSeats[i]
+		}
+	}
+
+	// 以最小能贏的牌贏墩
+	if winSeat != partner {
+		for _, c := range legal {
+			if beats(c, winner) {
+				return c
+			}
+		}
+	}
+
+	// 同伴已經贏墩,或贏不了: 打出(墊出)最小的牌,王牌留到最後
+	order := func(c uint8) int {
+		if cardSuit(c) == trump {
+			return int((c-1)%13) + 13
+		}
+		return int((c - 1) % 13)
+	}
+	return slices.MinFunc(legal, func(a, b uint8) int {
+		return order(a) - order(b)
+	})
+}
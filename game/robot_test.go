@@ -0,0 +1,74 @@
+package game
+
+import "testing"
+
+func TestRuleRobotPlay(t *testing.T) {
+	tests := []struct {
+		name     string
+		playSeat CbSeat
+		follow   CardRange
+		trick    [4]uint8 //東,南,西,北
+		legal    []uint8  //故意不依大小排列
+		want     uint8
+	}{
+		{
+			name:     "首打最長花色最小的牌",
+			playSeat: south,
+			follow:   NKings,
+			legal:    []uint8{spadeK, heart3, spade9, spade2, heartAce},
+			want:     spade2,
+		},
+		{
+			name:     "以最小能贏的牌贏墩",
+			playSeat: south,
+			follow:   SKings,
+			trick:    [4]uint8{spadeQ, 0, 0, 0},
+			legal:    []uint8{spadeAce, spade3, spadeK},
+			want:     spadeK,
+		},
+		{
+			name:     "同伴已經贏墩時打最小的牌",
+			playSeat: south,
+			follow:   SKings,
+			trick:    [4]uint8{spade5, 0, 0, spadeAce},
+			legal:    []uint8{spadeK, spade3, spadeQ},
+			want:     spade3,
+		},
+		{
+			name:     "贏不了時打最小的牌",
+			playSeat: south,
+			follow:   SKings,
+			trick:    [4]uint8{spadeAce, 0, 0, 0},
+			legal:    []uint8{spadeK, spadeQ, spade4, spade10},
+			want:     spade4,
+		},
+		{
+			name:     "缺門時墊最小的牌",
+			playSeat: south,
+			follow:   SKings,
+			trick:    [4]uint8{spadeAce, 0, 0, 0},
+			legal:    []uint8{heartAce, club7, diamond2, heart3},
+			want:     diamond2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := &RobotView{
+				TableSnapshot: &TableSnapshot{Trick: tt.trick},
+				Seat:          uint8(tt.playSeat),
+				PlaySeat:      uint8(tt.playSeat),
+				Legal:         tt.legal,
+				Follow:        tt.follow,
+			}
+			legal := append([]uint8(nil), tt.legal...)
+			if got := (ruleRobot{}).Play(view); got != tt.want {
+				t.Errorf("Play() = %s, want %s", CbCard(got).PBN(), CbCard(tt.want).PBN())
+			}
+			for i := range legal {
+				if view.Legal[i] != legal[i] {
+					t.Fatalf("Play() 不能改變 view.Legal 的順序")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+
+	"github.com/moszorn/utils/skf"
+)
+
+// 房間設定(RoomConfig): 由設定檔載入或執行中變更, 叫/出牌時間與撤回設定即時生效,
+// 計分方式用於之後以該房間建立的複式賽程, 不開放觀戰與密碼在使用者進入房間時檢查
+
+// RoomConfig 房間設定, 零值表示預設
+type RoomConfig struct {
+	Name         string `json:"name"`
+	CountDown    uint32 `json:"countDown,omitempty"`    //叫/出牌時間(秒), 0為預設 GamePlayCountDown
+	Scoring      string `json:"scoring,omitempty"`      //複式賽程計分方式 Matchpoints, Butler, IMP, 空白為 Matchpoints
+	NoUndo       bool   `json:"noUndo,omitempty"`       //不允許撤回(比賽用)
+	NoSpectators bool   `json:"noSpectators,omitempty"` //不開放觀戰, 座位都有人時無法進入房間
+	Password     string `json:"password,omitempty"`     //私人房間密碼, 空白為公開房間
+}
+
+// RoomCredential 使用者出示的房間憑證, pb.PlayingUser沒有憑證欄位, 前端於進入房間前以json送出(UserPrivateCredential)暫存於連線
+type RoomCredential struct {
+	Password  string `json:"password,omitempty"`  //私人房間密碼
+	SeatToken string `json:"seatToken,omitempty"` //斷線重新入座時出示的座位憑證(TablePrivateSeatToken)
+	SeatPass  string `json:"seatPass,omitempty"`  //隊制賽,配對賽指定座位的座位通行碼(TeamMatch.Passes, Tournament.Passes)
+}
+
+// StoreCredential 暫存連線(ns)出示的房間憑證, 之後該連線的請求都帶著這份憑證
+func StoreCredential(ns *skf.NSConn, credential RoomCredential) {
+	ns.Conn.Set(KeyCredential, credential)
+}
+
+// LoadCredential 連線(ns)出示的房間憑證, 沒有出示時為零值
+func LoadCredential(ns *skf.NSConn) RoomCredential {
+	credential, _ := ns.Conn.Get(KeyCredential).(RoomCredential)
+	return credential
+}
+
+// Private 是否為需要密碼的私人房間
+func (c RoomConfig) Private() bool {
+	return c.Password != ""
+}
+
+// ParseSessionScoring 由名稱(SessionScoring.String)取得計分方式, 空白為 ScoringMatchpoints
+func ParseSessionScoring(name string) (SessionScoring, error) {
+	if name == "" {
+		return ScoringMatchpoints, nil
+	}
+	for _, s := range []SessionScoring{ScoringMatchpoints, ScoringButler, ScoringIMP} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return ScoringMatchpoints, fmt.Errorf("%w: 計分方式 %s", ErrRoomConfig, name)
+}
+
+// Configure 套用房間設定, 房間名稱不可變更
+func (g *Game) Configure(config RoomConfig) error {
+	if config.Name != g.name {
+		return fmt.Errorf("%w: 房間名稱 %s 與 %s 不符", ErrRoomConfig, config.Name, g.name)
+	}
+	if _, err := ParseSessionScoring(config.Scoring); err != nil {
+		return err
+	}
+	if config.CountDown == 0 {
+		config.CountDown = GamePlayCountDown
+	}
+
+	g.SetCountDown(config.CountDown)
+	g.SetUndo(!config.NoUndo)
+	g.config.Store(&config)
+
+	slog.Info("Configure", slog.String(g.name, fmt.Sprintf("叫/出牌%d秒 計分:%s 撤回:%t 觀戰:%t 私人:%t",
+		config.CountDown, g.Scoring(), !config.NoUndo, !config.NoSpectators, config.Private())))
+	return nil
+}
+
+// Config 目前的房間設定
+func (g *Game) Config() RoomConfig {
+	config := RoomConfig{Name: g.name}
+	if c := g.config.Load(); c != nil {
+		config = *c
+	}
+	config.CountDown = g.CountDown()
+	config.NoUndo = !g.UndoEnabled()
+	return config
+}
+
+// Scoring 以該房間建立的複式賽程計分方式
+func (g *Game) Scoring() SessionScoring {
+	scoring, _ := ParseSessionScoring(g.Config().Scoring)
+	return scoring
+}
+
+// admit 使用者(user)是否可以進入房間: 房間已關閉, 私人房間密碼(Credential.Password)不符, 不開放觀戰時座位都有人, 都不能進入
+func (g *Game) admit(user *RoomUser) error {
+	if g.closed.Load() {
+		return ErrRoomClosed
+	}
+	config := g.Config()
+	if config.Private() && subtle.ConstantTimeCompare([]byte(user.Credential.Password), []byte(config.Password)) != 1 {
+		return ErrRoomPassword
+	}
+	//四個座位都有人(含斷線保留與機器人)
+	if config.NoSpectators && g.roomManager.isGameStart() {
+		return ErrNoSpectators
+	}
+	return nil
+}
+
+// seatable 使用者(user)是否可以上座: 房間已關閉, 或該連線沒有以同一名稱經 UserJoin 通過進入檢查(admit)取得房間成員通行碼, 都不能上座
+func (g *Game) seatable(user *RoomUser) error {
+	if g.closed.Load() {
+		return ErrRoomClosed
+	}
+	var token string
+	if user.NsConn != nil {
+		token, _ = user.NsConn.Conn.Get(KeyMember).(string)
+	}
+	if name, ok := g.members.Load(token); !ok || name != user.Name {
+		return ErrNotRoomMember
+	}
+	return nil
+}
+
+// Occupied 房間內是否還有使用者(玩家或觀眾)連線
+func (g *Game) Occupied() bool {
+	return len(g.roomManager.roomConnections()) > 0
+}
+
+// admitMember 核發房間成員通行碼給進入房間的使用者(user)
+func (g *Game) admitMember(user *RoomUser) string {
+	token := newSeatToken()
+	g.members.Store(token, user.Name)
+	user.NsConn.Conn.Set(KeyMember, token)
+	return token
+}
+
+// revokeMember 撤銷離開房間的連線(ns)的房間成員通行碼, 並清除出示的房間憑證
+func (g *Game) revokeMember(ns *skf.NSConn) {
+	if token, ok := ns.Conn.Get(KeyMember).(string); ok {
+		g.members.Delete(token)
+	}
+	ns.Conn.Set(KeyMember, nil)
+	ns.Conn.Set(KeyCredential, nil)
+}
+
+// IsMember 通行碼(token)是否屬於目前在房間內的使用者
+func (g *Game) IsMember(token string) bool {
+	if token == "" {
+		return false
+	}
+	_, ok := g.members.Load(token)
+	return ok
+}
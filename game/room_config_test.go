@@ -0,0 +1,88 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/moszorn/pb"
+	utilog "github.com/moszorn/utils/log"
+)
+
+// testGame 測試用遊戲桌, 只啟動RoomManager, 測試結束時關閉
+func testGame(t *testing.T, name string) *Game {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	g := &Game{
+		log:             utilog.NewMyLog("", slog.LevelError, utilog.ConsoleLog),
+		name:            name,
+		engine:          newEngine(),
+		roomManager:     newRoomManager(ctx),
+		alertAsks:       make(map[uint8]*alertAsk, 2),
+		conventionCards: make(map[string]*ConventionCard, 4),
+	}
+	g.countDown.Store(GamePlayCountDown)
+	NewDeck(g)
+	g.Start()
+	return g
+}
+
+func TestAdmit(t *testing.T) {
+	user := func(password string) *RoomUser {
+		return &RoomUser{PlayingUser: &pb.PlayingUser{Name: "user"}, Credential: RoomCredential{Password: password}}
+	}
+
+	tests := []struct {
+		name    string
+		config  RoomConfig
+		players uint8 //已入座人數
+		closed  bool
+		user    *RoomUser
+		want    error
+	}{
+		{"公開房間", RoomConfig{}, 0, false, user(""), nil},
+		{"房間已關閉", RoomConfig{}, 0, true, user(""), ErrRoomClosed},
+		{"私人房間密碼正確", RoomConfig{Password: "secret"}, 0, false, user("secret"), nil},
+		{"私人房間密碼錯誤", RoomConfig{Password: "secret"}, 0, false, user("wrong"), ErrRoomPassword},
+		{"私人房間沒有出示密碼", RoomConfig{Password: "secret"}, 0, false, user(""), ErrRoomPassword},
+		{"不開放觀戰還有空位", RoomConfig{NoSpectators: true}, 3, false, user(""), nil},
+		{"不開放觀戰座位都有人", RoomConfig{NoSpectators: true}, 4, false, user(""), ErrNoSpectators},
+		{"開放觀戰座位都有人", RoomConfig{}, 4, false, user(""), nil},
+		{"密碼錯誤先於不開放觀戰", RoomConfig{Password: "secret", NoSpectators: true}, 4, false, user("wrong"), ErrRoomPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := testGame(t, "room")
+			tt.config.Name = g.name
+			if err := g.Configure(tt.config); err != nil {
+				t.Fatal(err)
+			}
+			g.roomManager.players = tt.players
+			g.closed.Store(tt.closed)
+			if err := g.admit(tt.user); !errors.Is(err, tt.want) {
+				t.Errorf("admit() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeatable(t *testing.T) {
+	g := testGame(t, "room")
+	user := &RoomUser{PlayingUser: &pb.PlayingUser{Name: "user"}}
+
+	//沒有經 UserJoin 取得房間成員通行碼
+	if err := g.seatable(user); !errors.Is(err, ErrNotRoomMember) {
+		t.Errorf("seatable() 沒有通行碼 = %v, want %v", err, ErrNotRoomMember)
+	}
+	//其他使用者的通行碼不能讓沒有通行碼的連線上座
+	g.members.Store("token", "user")
+	if err := g.seatable(user); !errors.Is(err, ErrNotRoomMember) {
+		t.Errorf("seatable() 沒有連線 = %v, want %v", err, ErrNotRoomMember)
+	}
+	g.closed.Store(true)
+	if err := g.seatable(user); !errors.Is(err, ErrRoomClosed) {
+		t.Errorf("seatable() 房間已關閉 = %v, want %v", err, ErrRoomClosed)
+	}
+}
@@ -3,9 +3,11 @@ package game
 import (
 	"container/ring"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -58,6 +60,8 @@ type (
 		player     *RoomUser  //
 		shiftSeat  uint8      // SeatShift  需要此參數
 		actionSeat uint8      // PlayerAction  需要此參數
+
+		reservation uint32 // _ReleaseSeat, _TakeoverSeat 需要此參數(保留序號)
 	}
 
 	// 操作或請求執行結果
@@ -83,6 +87,11 @@ type (
 
 		seat        uint8
 		isGameStart bool
+		reclaimed   bool    //入座是否為斷線重新連線,取回保留的座位
+		token       string  //入座時發給玩家的座位憑證
+		reserved    []uint8 //斷線保留中的座位
+		reservation uint32  //保留座位的序號
+		robots      []uint8 //桌上已沒有真人玩家,隨之離座的機器人座位
 
 		//表示遊戲已經幾人動作了(回合數)
 		aa uint8
@@ -104,9 +113,13 @@ type (
 
 	// tablePlayer就是Ring Item,代表四方座位的玩家,因此一經初始化後玩家入桌與離桌只會變更player屬性,不會銷毀這個ref
 	tablePlayer struct {
-		player *RoomUser
-		zone   uint8 //代表player座位(CbSeat)東南西北,每個SeatItem初始化時必須指派一個不能重覆的位置
-		value  uint8 //當前打出什麼牌(Card)
+		player   *RoomUser
+		zone     uint8  //代表player座位(CbSeat)東南西北,每個SeatItem初始化時必須指派一個不能重覆的位置
+		value    uint8  //當前打出什麼牌(Card)
+		reserved string //斷線保留座位的玩家姓名, 空字串表示座位沒有保留
+
+		token       string //入座時伺服器發給玩家的座位憑證, 斷線重新入座取回座位時必須出示
+		reservation uint32 //保留座位的序號, 每次保留加1, 避免前一次保留的逾時釋放了已取回的座位
 	}
 
 	ZoneUsers map[*skf.NSConn]*RoomUser
@@ -292,7 +305,16 @@ func (mr *RoomManager) Start() {
 				 result.seat 表示入座位置
 				 result.playerName 表示入座者姓名
 				*/
-				result.seat, result.playerName, result.isGameStart = mr.playerJoin(user, pb.SeatStatus_SitDown)
+				//斷線重新連線,優先取回保留的座位
+				if seat, token, ok := mr.reclaimSeat(user); ok {
+					result.seat, result.playerName, result.isGameStart, result.reclaimed = seat, user.Name, mr.players >= 4, true
+					result.token = token
+				} else {
+					result.seat, result.playerName, result.isGameStart = mr.playerJoin(user, pb.SeatStatus_SitDown)
+					if result.seat != valueNotSet {
+						result.token = mr.seatToken(result.seat)
+					}
+				}
 				result.isOnSeat = result.seat != valueNotSet
 				result.err = nil
 				tracking.Response <- result
@@ -305,6 +327,9 @@ func (mr *RoomManager) Start() {
 				*/
 				result := chanResult{}
 				result.seat, result.playerName, result.isGameStart = mr.playerJoin(user, pb.SeatStatus_StandUp)
+				if result.seat != valueNotSet {
+					result.robots = mr.leaveRobots()
+				}
 				//通知三位玩家
 				result.alives[0],
 					result.alives[1],
@@ -427,6 +452,7 @@ func (mr *RoomManager) Start() {
 			case _GetTablePlayers:
 				result := chanResult{}
 				result.e, result.s, result.w, result.n = mr.tablePlayers()
+				result.reserved = mr.reservedSeats()
 				result.err = nil
 				crwa.Response <- result
 			case _GetZoneUsers:
@@ -445,6 +471,116 @@ func (mr *RoomManager) Start() {
 				result.aa = mr.aa
 				result.isGameStart = mr.players >= 4
 				crwa.Response <- result
+			case _ReserveSeat:
+				/*
+				 result.seat 表示保留的座位
+				 result.playerName 表示保留給哪位玩家
+				*/
+				result := chanResult{seat: valueNotSet}
+				result.err = ErrUserNotInPlay
+				for i := 0; i < PlayersLimit; i++ {
+					seatAt := mr.Value.(*tablePlayer)
+					mr.Ring = mr.Next()
+					if seatAt.player.NsConn != nil && seatAt.player.NsConn == req.player.NsConn && seatAt.player.Name != "" {
+						//座位仍算在 players 中,避免其他人入座
+						seatAt.reserved = seatAt.player.Name
+						seatAt.reservation++
+						seatAt.player.NsConn = nil
+						result.seat, result.playerName, result.err = seatAt.zone, seatAt.reserved, nil
+						result.reservation = seatAt.reservation
+						break
+					}
+				}
+				result.isGameStart = mr.players >= 4
+				crwa.Response <- result
+			case _ReleaseSeat:
+				/*
+				 result.seat 表示釋放的座位, valueNotSet表示座位已被取回(或不存在)
+				 result.alives 表示仍在遊戲桌的玩家
+				*/
+				result := chanResult{seat: valueNotSet}
+				for i := 0; i < PlayersLimit; i++ {
+					seatAt := mr.Value.(*tablePlayer)
+					mr.Ring = mr.Next()
+					if seatAt.zone == req.player.Zone8 && seatAt.reserved != "" && seatAt.reservation == req.reservation {
+						result.seat, result.playerName = seatAt.zone, seatAt.reserved
+						seatAt.reserved = ""
+						seatAt.token = ""
+						seatAt.player.Play = uint32(valueNotSet)
+						seatAt.player.Bid = uint32(valueNotSet)
+						seatAt.player.Name = ""
+						mr.players--
+						break
+					}
+				}
+				result.alives[0], result.alives[1], result.alives[2] = mr.acquirePlayerConnectionsByExclude(req.player.Zone8)
+				result.isGameStart = mr.players >= 4
+				result.err = nil
+				crwa.Response <- result
+			case _AddRobot:
+				/*
+				 result.seat 表示機器人入座的座位
+				 result.playerName 表示機器人名稱
+				*/
+				result := chanResult{seat: valueNotSet}
+				result.err = ErrRobotSeat
+				for i := 0; i < PlayersLimit; i++ {
+					seatAt := mr.Value.(*tablePlayer)
+					mr.Ring = mr.Next()
+					//req.player.Zone8 為 valueNotSet 表示任一空位
+					if seatAt.player.NsConn == nil && seatAt.reserved == "" && seatAt.player.robot == nil &&
+						(req.player.Zone8 == valueNotSet || req.player.Zone8 == seatAt.zone) {
+						seatAt.player.robot = req.player.robot
+						seatAt.player.Name = robotName(seatAt.zone)
+						seatAt.player.TicketTime = pb.LocalTimestamp(time.Now())
+						mr.players++
+						result.seat, result.playerName, result.err = seatAt.zone, seatAt.player.Name, nil
+						break
+					}
+				}
+				result.isGameStart = mr.players >= 4
+				crwa.Response <- result
+			case _RemoveRobot:
+				/*
+				 result.seat 表示機器人離開的座位
+				 result.alives 表示仍在遊戲桌的玩家
+				*/
+				result := chanResult{seat: valueNotSet}
+				result.err = ErrRobotNotFound
+				for i := 0; i < PlayersLimit; i++ {
+					seatAt := mr.Value.(*tablePlayer)
+					mr.Ring = mr.Next()
+					if seatAt.zone == req.player.Zone8 && seatAt.player.robot != nil {
+						result.seat, result.playerName, result.err = seatAt.zone, seatAt.player.Name, nil
+						mr.clearRobot(seatAt)
+						break
+					}
+				}
+				result.alives[0], result.alives[1], result.alives[2] = mr.acquirePlayerConnectionsByExclude(req.player.Zone8)
+				result.isGameStart = mr.players >= 4
+				crwa.Response <- result
+			case _TakeoverSeat:
+				/*
+				 result.seat 表示機器人接手的座位, valueNotSet表示座位已被取回(或不存在)
+				 result.playerName 表示機器人名稱
+				*/
+				result := chanResult{seat: valueNotSet}
+				for i := 0; i < PlayersLimit; i++ {
+					seatAt := mr.Value.(*tablePlayer)
+					mr.Ring = mr.Next()
+					if seatAt.zone == req.player.Zone8 && seatAt.reserved != "" && seatAt.reservation == req.reservation {
+						//座位本來就算在 players 中
+						seatAt.reserved = ""
+						seatAt.token = ""
+						seatAt.player.robot = req.player.robot
+						seatAt.player.Name = robotName(seatAt.zone)
+						result.seat, result.playerName = seatAt.zone, seatAt.player.Name
+						break
+					}
+				}
+				result.isGameStart = mr.players >= 4
+				result.err = nil
+				crwa.Response <- result
 			} /*eofSwitch*/
 
 		case send := <-mr.broadcastMsg:
@@ -499,7 +635,13 @@ func (mr *RoomManager) KickOutBrokenConnection(ns *skf.NSConn) {
 	}
 
 	if kickInGame {
-		mr.PlayerLeave(kick)
+		//遊戲進行中,保留座位等待玩家重新連線,否則直接離桌
+		if mr.g.reserveSeat(kick) {
+			ns.Conn.Set(KeyGame, nil)
+			kick.IsSitting = false
+		} else {
+			mr.PlayerLeave(kick)
+		}
 	}
 	mr.UserLeave(kick)
 
@@ -532,7 +674,7 @@ func (mr *RoomManager) UserJoinTableInfo(user *RoomUser) {
 
 	var pp = pb.TableInfo{
 		/*底下是該桌組態設定*/
-		CountDown: GamePlayCountDown,
+		CountDown: mr.g.CountDown(),
 	}
 
 	//觀眾資訊(房間中的人):包含沒在座位上的與在座位上的
@@ -567,6 +709,23 @@ func (mr *RoomManager) UserJoinTableInfo(user *RoomUser) {
 	if err := mr.send(user.NsConn, ClnRoomEvents.UserPrivateTableInfo, payload); err != nil {
 		slog.Error("UserJoinTableInfo proto錯誤", slog.String(".", err.Error()))
 	}
+
+	//遊戲桌目前階段
+	body, err := json.Marshal(mr.g.PhaseInfo())
+	if err != nil {
+		slog.Error("UserJoinTableInfo json錯誤", slog.String(".", err.Error()))
+		return
+	}
+	if err = mr.SendBytes(user.NsConn, ClnRoomEvents.UserPrivateTablePhase, body); err != nil {
+		slog.Error("UserJoinTableInfo", slog.String(".", err.Error()))
+	}
+
+	//遊戲進行中,送出當前遊戲桌狀態
+	mr.g.SendTableSnapshot(user)
+
+	//隊制賽目前累計比數
+	mr.g.sendTeamScore(user)
+	mr.g.sendConventionCards(user)
 }
 
 // UserJoin 使用者進入房間, 必須參數RoomUser {*skf.NSConn, userName, userZone}
@@ -582,6 +741,16 @@ func (mr *RoomManager) UserJoin(user *RoomUser) {
 
 	var response chanResult
 
+	// 房間已關閉, 私人房間密碼不符, 或不開放觀戰
+	if err := mr.g.admit(user); err != nil {
+		user.Tracking = preTracking
+		slog.Debug("使用者進入房間(UserJoin)", slog.String(".", err.Error()))
+		if user.NsConn != nil && !user.NsConn.Conn.IsClosed() {
+			user.NsConn.Emit(ClnRoomEvents.ErrorRoom, []byte(err.Error()))
+		}
+		return
+	}
+
 	//Probe內部用user name查詢是否user已經入房間
 	response = mr.door.Probe(user)
 
@@ -611,7 +780,12 @@ func (mr *RoomManager) UserJoin(user *RoomUser) {
 	if err != nil {
 		slog.Error("UserJoin", slog.String("發送通知訊息失敗", response.playerName), slog.String(".", err.Error()))
 	}
-	//TODO: 將當時房間狀態送出給進入者 (想法: Game必須一併傳入當時桌面情況進來,因為room_manager只管發送與廣播)
+
+	//房間成員通行碼, 匯出牌局時出示
+	err = mr.SendBytes(user.NsConn, ClnRoomEvents.UserPrivateMemberToken, []byte(mr.g.admitMember(user)))
+	if err != nil {
+		slog.Error("UserJoin", slog.String("發送成員通行碼失敗", response.playerName), slog.String(".", err.Error()))
+	}
 }
 
 // UserLeave 使用者離開房間
@@ -645,6 +819,7 @@ func (mr *RoomManager) UserLeave(user *RoomUser) {
 
 	//正常離開, 不正常離開的處理在 service.room.go - _OnRoomLeft
 	mr.g.CounterSub(user.NsConn, mr.g.name)
+	mr.g.revokeMember(user.NsConn)
 	//告知client切換回大廳,後端只要移除Conn Store,前端會執行轉頁面到Lobby namespace
 	user.NsConn.Conn.Set(KeyRoom, nil)
 	user.NsConn.Conn.Set(KeyZone, nil)
@@ -693,12 +868,13 @@ func (mr *RoomManager) playerJoin(user *RoomUser, flag pb.SeatStatus) (zoneSeat
 
 		switch flag {
 		case pb.SeatStatus_SitDown:
-			// Ring player.NsConn == nil 表示有空位
-			if seatAt.player.NsConn == nil {
+			// Ring player.NsConn == nil 表示有空位, 斷線保留中的座位與機器人座位不能入座, 指定座位(SeatPlan)只有出示座位通行碼的指定玩家能入座
+			if seatAt.player.NsConn == nil && seatAt.reserved == "" && seatAt.player.robot == nil && mr.g.seatAllowed(seatAt.zone, user) {
 				//注意用copy的
 				seatAt.player.NsConn = user.NsConn
 				seatAt.player.TicketTime = atTime
 				seatAt.player.Name = user.Name
+				seatAt.token = newSeatToken()
 				//seatAt.player.Zone8 = user.Zone8
 				//seatAt.player.Zone = user.Zone
 
@@ -717,6 +893,7 @@ func (mr *RoomManager) playerJoin(user *RoomUser, flag pb.SeatStatus) (zoneSeat
 				zoneSeat = seatAt.zone // 離那個座
 
 				seatAt.player.NsConn = nil // 離座
+				seatAt.token = ""
 				seatAt.player.Play = uint32(valueNotSet)
 				seatAt.player.Bid = uint32(valueNotSet)
 				seatAt.player.Name = ""
@@ -734,10 +911,221 @@ func (mr *RoomManager) playerJoin(user *RoomUser, flag pb.SeatStatus) (zoneSeat
 	return zoneSeat, userName, mr.players >= 4
 }
 
+// reclaimSeat 斷線重新連線的玩家出示座位憑證(Credential.SeatToken)取回保留的座位, 座位仍算在 players 中所以不增加人數;
+// 玩家姓名以保留座位的姓名為準, 取回後換發新的座位憑證 (只限 RoomManager Loop 內使用)
+func (mr *RoomManager) reclaimSeat(user *RoomUser) (zoneSeat uint8, token string, ok bool) {
+	if user.Credential.SeatToken == "" {
+		return valueNotSet, "", false
+	}
+	for i := 0; i < PlayersLimit; i++ {
+		seatAt := mr.Value.(*tablePlayer)
+		mr.Ring = mr.Next()
+		if seatAt.reserved != "" && seatTokenEqual(seatAt.token, user.Credential.SeatToken) {
+			user.Name = seatAt.reserved
+			seatAt.reserved = ""
+			seatAt.token = newSeatToken()
+			seatAt.player.NsConn = user.NsConn
+			seatAt.player.TicketTime = pb.LocalTimestamp(time.Now())
+			user.Tracking = EnterGame
+			return seatAt.zone, seatAt.token, true
+		}
+	}
+	return valueNotSet, "", false
+}
+
+// seatToken 座位(seat)目前的座位憑證 (只限 RoomManager Loop 內使用)
+func (mr *RoomManager) seatToken(seat uint8) string {
+	for i := 0; i < PlayersLimit; i++ {
+		seatAt := mr.Value.(*tablePlayer)
+		mr.Ring = mr.Next()
+		if seatAt.zone == seat {
+			return seatAt.token
+		}
+	}
+	return ""
+}
+
+// ReserveSeat 玩家斷線,保留其座位, 回傳保留的座位,玩家姓名與保留序號
+func (mr *RoomManager) ReserveSeat(user *RoomUser) (seat uint8, playerName string, reservation uint32, err error) {
+	rep := mr.table.Probe(&tableRequest{
+		topic:  _ReserveSeat,
+		player: user,
+	})
+	return rep.seat, rep.playerName, rep.reservation, rep.err
+}
+
+// ReleaseSeat 保留座位逾時,釋放座位並通知房間有人離桌, 座位已被取回(或已再次保留)時 released 為false
+func (mr *RoomManager) ReleaseSeat(seat uint8, reservation uint32) (released bool) {
+	rep := mr.table.Probe(&tableRequest{
+		topic:       _ReleaseSeat,
+		player:      &RoomUser{Zone8: seat, PlayingUser: &pb.PlayingUser{}},
+		reservation: reservation,
+	})
+	if rep.seat == valueNotSet {
+		return false
+	}
+	mr.g.clearConventionCards()
+	mr.sendTableOnLeave(rep.seat, rep.playerName, rep.alives[:])
+	return true
+}
+
+// sendTableOnLeave 通知其它玩家清空桌面,並廣播座位(seat)上的玩家(playerName)已離桌
+func (mr *RoomManager) sendTableOnLeave(seat uint8, playerName string, alives []*skf.NSConn) {
+	//發送其它三位玩家清空桌面(因為有人離桌)
+	var signal byte = 0x7F //沒什麼,只代表發送給前端的訊號
+	mr.SendByteToPlayers(ClnRoomEvents.TablePrivateOnLeave, signal, alives)
+
+	// 廣播已經有人離桌
+	mr.SendPayloadsToZone(ClnRoomEvents.TableOnLeave, nil, payloadData{
+		ProtoData: &pb.PlayingUser{
+			Name:       playerName,
+			Zone:       uint32(seat),
+			TicketTime: pb.LocalTimestamp(time.Now()),
+		},
+		Player:      seat,
+		PayloadType: ProtobufType,
+	})
+}
+
+// sendTableOnSeat 廣播座位(seat)有玩家(playerName)入座
+func (mr *RoomManager) sendTableOnSeat(seat uint8, playerName string) {
+	mr.SendPayloadsToZone(ClnRoomEvents.TableOnSeat, nil, payloadData{
+		ProtoData: &pb.PlayingUser{
+			Name:       playerName,
+			Zone:       uint32(seat),
+			TicketTime: pb.LocalTimestamp(time.Now()),
+			IsSitting:  true,
+		},
+		Player:      seat,
+		PayloadType: ProtobufType,
+	})
+}
+
+// clearRobot 機器人離座 (只限 RoomManager Loop 內使用)
+func (mr *RoomManager) clearRobot(seatAt *tablePlayer) {
+	seatAt.player.robot = nil
+	seatAt.player.Play = uint32(valueNotSet)
+	seatAt.player.Bid = uint32(valueNotSet)
+	seatAt.player.Name = ""
+	mr.players--
+}
+
+// leaveRobots 桌上已沒有真人玩家(包含斷線保留座位)時,所有機器人隨之離座, 回傳離座的機器人座位 (只限 RoomManager Loop 內使用)
+func (mr *RoomManager) leaveRobots() (seats []uint8) {
+	var robots []*tablePlayer
+	for i := 0; i < PlayersLimit; i++ {
+		seatAt := mr.Value.(*tablePlayer)
+		mr.Ring = mr.Next()
+		if seatAt.player.NsConn != nil || seatAt.reserved != "" {
+			return nil
+		}
+		if seatAt.player.robot != nil {
+			robots = append(robots, seatAt)
+		}
+	}
+	for _, seatAt := range robots {
+		seats = append(seats, seatAt.zone)
+		mr.clearRobot(seatAt)
+	}
+	return
+}
+
+// AddRobot 機器人(robot)入座指定空位(seat), seat為valueNotSet表示任一空位, 入座後剛好滿四人則開局
+func (mr *RoomManager) AddRobot(seat uint8, robot Robot) error {
+	rep := mr.table.Probe(&tableRequest{
+		topic:  _AddRobot,
+		player: &RoomUser{Zone8: seat, robot: robot},
+	})
+	if rep.err != nil {
+		return rep.err
+	}
+	slog.Info("AddRobot", slog.String(rep.playerName, CbSeat(rep.seat).String()), slog.Bool("isGameStart", rep.isGameStart))
+
+	mr.sendTableOnSeat(rep.seat, rep.playerName)
+	if rep.isGameStart {
+		mr.SendGameStart()
+	}
+	return nil
+}
+
+// RemoveRobot 座位(seat)上的機器人離座, 遊戲進行中則中斷遊戲
+func (mr *RoomManager) RemoveRobot(seat uint8) error {
+	rep := mr.table.Probe(&tableRequest{
+		topic:  _RemoveRobot,
+		player: &RoomUser{Zone8: seat},
+	})
+	if rep.err != nil {
+		return rep.err
+	}
+	slog.Info("RemoveRobot", slog.String(rep.playerName, CbSeat(rep.seat).String()))
+
+	mr.g.mu.Lock()
+	mr.g.abort()
+	mr.g.mu.Unlock()
+	mr.g.clearConventionCards()
+	mr.sendTableOnLeave(rep.seat, rep.playerName, rep.alives[:])
+	return nil
+}
+
+// TakeoverSeat 保留座位逾時, 由機器人(robot)接手保留序號(reservation)的座位, 座位已被取回(或已再次保留)時 takeover 為false
+func (mr *RoomManager) TakeoverSeat(seat uint8, reservation uint32, robot Robot) (takeover bool) {
+	rep := mr.table.Probe(&tableRequest{
+		topic:       _TakeoverSeat,
+		player:      &RoomUser{Zone8: seat, robot: robot, PlayingUser: &pb.PlayingUser{}},
+		reservation: reservation,
+	})
+	if rep.seat == valueNotSet {
+		return false
+	}
+	mr.g.clearConventionCards()
+	mr.sendTableOnSeat(rep.seat, rep.playerName)
+	return true
+}
+
+// seatedNames 東,南,西,北座位上的玩家名稱(包含斷線保留中的座位與機器人), 空位為空字串
+func (mr *RoomManager) seatedNames() (names [4]string) {
+	rep := mr.table.Probe(&tableRequest{
+		topic: _GetTablePlayers,
+	})
+	for idx, player := range [4]*RoomUser{rep.e, rep.s, rep.w, rep.n} {
+		if player != nil {
+			names[idx] = player.Name
+		}
+	}
+	return
+}
+
+// isGameStart 四家座位是否都有玩家
+func (mr *RoomManager) isGameStart() bool {
+	return mr.table.Probe(&tableRequest{topic: IsGameStart}).isGameStart
+}
+
+// Robot 座位上的機器人, 不是機器人回傳nil
+func (mr *RoomManager) Robot(seat uint8) Robot {
+	rep := mr.table.Probe(&tableRequest{
+		topic: _GetTablePlayers,
+	})
+	for _, player := range [4]*RoomUser{rep.e, rep.s, rep.w, rep.n} {
+		if player != nil && player.Zone8 == seat {
+			return player.robot
+		}
+	}
+	return nil
+}
+
 // PlayerJoin 加入, 底層透過呼叫 playerJoin, 最後判斷使否開局,與送出發牌
 func (mr *RoomManager) PlayerJoin(user *RoomUser) {
 	slog.Info("PlayerJoin", slog.String("傳入參數", fmt.Sprintf("%s %s(%d) %s", user.Name, CbSeat(user.Zone8), user.Zone8, shortConnID(user.NsConn))))
 
+	// 房間已關閉, 或沒有通過進入房間檢查(私人房間密碼)
+	if err := mr.g.seatable(user); err != nil {
+		slog.Debug("PlayerJoin", slog.String(user.Name, err.Error()))
+		if user.NsConn != nil && !user.NsConn.Conn.IsClosed() {
+			user.NsConn.Emit(ClnRoomEvents.ErrorRoom, []byte(err.Error()))
+		}
+		return
+	}
+
 	user.Tracking = EnterGame
 
 	var response chanResult
@@ -800,29 +1188,59 @@ func (mr *RoomManager) PlayerJoin(user *RoomUser) {
 	}
 	mr.SendPayloadToPlayer(ClnRoomEvents.TablePrivateOnSeat, payload)
 
+	//座位憑證只給入座的玩家, 斷線重新入座時出示以取回座位
+	if response.isOnSeat && response.token != "" {
+		if err := mr.SendBytes(user.NsConn, ClnRoomEvents.TablePrivateSeatToken, []byte(response.token)); err != nil {
+			slog.Error("PlayerJoin", slog.String("座位憑證發送失敗", user.Name), slog.String(".", err.Error()))
+		}
+	}
+
 	//廣播(public)通知整區,ToPlayer玩家上座
 	payload.ProtoData = pbPlayers.ToPlayer
 	// Bug 底下SendPayLoadsToZone 可能會曝光.因為瀏覽器玩家多種登入的關係
 	mr.SendPayloadsToZone(ClnRoomEvents.TableOnSeat, user.NsConn, payload)
 
 	// 順利坐到位置剛好滿四人局開始
-	slog.Debug("PlayerJoin", slog.Bool("isOnSeat", response.isOnSeat), slog.Bool("isGameStart", response.isGameStart))
+	slog.Debug("PlayerJoin", slog.Bool("isOnSeat", response.isOnSeat), slog.Bool("isGameStart", response.isGameStart), slog.Bool("reclaimed", response.reclaimed))
+
+	//斷線重新連線取回座位,遊戲繼續進行,不重新開局
+	if response.reclaimed {
+		mr.g.seatReclaimed(user, response.seat)
+		return
+	}
 
 	if response.isOnSeat && response.isGameStart {
-		// g.start會洗牌,亂數取得開叫者,及禁叫品項, bidder首叫會是亂數取的
+		// g.start會洗牌,依牌號取得開叫者,及禁叫品項
 		mr.SendGameStart()
 	}
 }
 
+// SendGameStart 開始新的一局(洗牌,發牌,通知開叫), 在遊戲鎖(mu)內執行
 func (mr *RoomManager) SendGameStart() {
+	mr.g.mu.Lock()
+	defer mr.g.mu.Unlock()
+	mr.sendGameStart()
+}
+
+// sendGameStart 必須已取得遊戲鎖(mu)
+func (mr *RoomManager) sendGameStart() {
 
 	//通知(private)個人玩家Player上座了
 	payload := payloadData{
 		PayloadType: ProtobufType,
 	}
 
+	if err := mr.g.transit(PhaseDealing); err != nil {
+		slog.Warn("SendGameStart", slog.String(".", err.Error()))
+		return
+	}
+
 	// 首引, 以及初始叫品(uint8(BidYet)) BidYet CbBid = iota = 0
-	lead := mr.g.start()
+	lead, ok := mr.g.start()
+	if !ok {
+		mr.g.waitForBoards()
+		return
+	}
 
 	initZeroBid := uint8(BidYet)
 
@@ -830,6 +1248,10 @@ func (mr *RoomManager) SendGameStart() {
 
 	// 發牌
 	mr.SendDeal()
+	if err := mr.g.transit(PhaseBidding); err != nil {
+		slog.Warn("SendGameStart", slog.String(".", err.Error()))
+		return
+	}
 
 	//延遲,是因為最後進來的玩家前端render速度太慢,會導致接收到NotyBid時來不及,所以延遲幾秒
 	//time.Sleep(time.Millisecond * 700)
@@ -868,13 +1290,17 @@ func (mr *RoomManager) SendGameStart() {
 	//  step2 才能再送出Private (GamePrivateNotyBid) 給當事人
 	//mr.SendPayloadToPlayers(ClnRoomEvents.GameNotyBid, payload, pb.SceneType_game) //廣播Public
 	mr.SendPayloadToPlayers(ClnRoomEvents.GameNotyBid, &notyBid, pb.SceneType_game) //廣播Public
+	mr.g.sendBoard()                                                                //牌號,發牌者與身價
 
-	time.Sleep(time.Millisecond * 400)
-	//指定傳送給 lead 開叫
-	payload.Player = lead
-	mr.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payload) //私人Private
-
-	return
+	//延遲後指定傳送給 lead 開叫, 延遲期間不持有遊戲鎖, 遊戲中斷則不送出
+	mr.g.afterDeal(mr.g.boardNumber, time.Millisecond*400, func() {
+		if mr.g.Phase() != PhaseBidding {
+			return
+		}
+		payload.Player = lead
+		mr.SendPayloadToPlayer(ClnRoomEvents.GamePrivateNotyBid, payload) //私人Private
+		mr.g.startBidTimer(lead)
+	})
 }
 
 // PlayerLeave 加入, 底層透過呼叫 playerJoin, 進行離桌程序
@@ -902,6 +1328,12 @@ func (mr *RoomManager) PlayerLeave(user *RoomUser) {
 		return
 	}
 
+	//遊戲中有人離桌,中斷遊戲並等待玩家入座
+	mr.g.mu.Lock()
+	mr.g.abort()
+	mr.g.mu.Unlock()
+	mr.g.clearConventionCards()
+
 	//正常離開, 不正常離開處理在 service.room.go - _OnRoomLeft
 	user.NsConn.Conn.Set(KeyGame, nil)
 	user.NsConn.Conn.Set(KeyPlayRole, nil)
@@ -930,6 +1362,10 @@ func (mr *RoomManager) PlayerLeave(user *RoomUser) {
 	// 廣播已經有人離桌,前端必須處理(Disable上座功能),並顯示誰離座
 	mr.SendPayloadsToZone(ClnRoomEvents.TableOnLeave, user.NsConn, payload)
 
+	//桌上已沒有真人玩家,機器人隨之離座
+	for _, seat := range response.robots {
+		mr.sendTableOnLeave(seat, robotName(seat), nil)
+	}
 }
 
 // 儲存玩家(座位)的出牌到Ring中,因為回合比牌會從Ring中取得
@@ -959,7 +1395,7 @@ func (mr *RoomManager) findPlayer(seat uint8) (player *tablePlayer, exist bool)
 	limit := PlayersLimit - 1
 	for limit > 0 && !found {
 		limit--
-		if tp.zone == seat && tp.player.NsConn != nil && !tp.player.NsConn.Conn.IsClosed() {
+		if tp.zone == seat && (tp.player.robot != nil || tp.player.NsConn != nil && !tp.player.NsConn.Conn.IsClosed()) {
 			found = true
 			return tp, found
 		}
@@ -1070,6 +1506,16 @@ func (mr *RoomManager) zoneUsers() (users []*RoomUser, ePlayer, sPlayer, wPlayer
 	return
 }
 
+// reservedSeats 斷線保留中的座位 (只限 RoomManager Loop 內使用)
+func (mr *RoomManager) reservedSeats() (seats []uint8) {
+	mr.Do(func(i any) {
+		if v := i.(*tablePlayer); v.reserved != "" {
+			seats = append(seats, v.zone)
+		}
+	})
+	return
+}
+
 // 撈出正在遊戲桌上的四位玩家,有可能 player.NsConn 為 nil (網家斷線)
 func (mr *RoomManager) tablePlayers() (e, s, w, n *RoomUser) {
 	mr.Do(func(i any) {
@@ -1128,6 +1574,26 @@ func (mr *RoomManager) AcquirePlayerConnections() (e, s, w, n *skf.NSConn) {
 	return response.e.NsConn, response.s.NsConn, response.w.NsConn, response.n.NsConn
 }
 
+// playerConnections 遊戲中四家玩家連線(Key:座位), 機器人沒有連線, 斷線保留中的座位等待取回, 所以都不在其中;
+// broken 表示連線中斷的玩家座位(valueNotSet表示沒有)
+func (mr *RoomManager) playerConnections() (connections map[uint8]*skf.NSConn, broken uint8) {
+	connections, broken = make(map[uint8]*skf.NSConn), valueNotSet
+
+	response := mr.table.Probe(&tableRequest{
+		topic: _GetTablePlayers,
+	})
+	for _, player := range [4]*RoomUser{response.e, response.s, response.w, response.n} {
+		if player.robot != nil || slices.Contains(response.reserved, player.Zone8) {
+			continue
+		}
+		if player.NsConn == nil {
+			broken = player.Zone8
+		}
+		connections[player.Zone8] = player.NsConn
+	}
+	return
+}
+
 // 回傳以第一個空位為始點的環形陣列,order 第一個元素就是空位的seat,用於使用者進入房間的位置方位
 func (mr *RoomManager) lastLeaveOrder() (order [4]*RoomUser) {
 	//Bug
@@ -1408,6 +1874,23 @@ func (mr *RoomManager) SendDeal( /*deckInPlay *map[uint8]*[NumOfCardsOnePlayer]u
 	mr.sendDealToZone(rep.audiences.Connections())
 }
 
+// roomConnections 房間所有連線(四家玩家與觀眾), 不包含斷線的連線
+func (mr *RoomManager) roomConnections() (connections []*skf.NSConn) {
+	rep := mr.table.Probe(&tableRequest{topic: _GetZoneUsers})
+	if rep.err != nil {
+		slog.Error("roomConnections", slog.String(".", rep.err.Error()))
+		return nil
+	}
+
+	connections = rep.audiences.Connections()
+	for _, player := range []*RoomUser{rep.e, rep.s, rep.w, rep.n} {
+		if player != nil && player.NsConn != nil && !player.NsConn.Conn.IsClosed() {
+			connections = append(connections, player.NsConn)
+		}
+	}
+	return
+}
+
 // send 針對payload型態對連線發送 []byte 或 proto bytes
 func (mr *RoomManager) send(nsConn *skf.NSConn, eventName string, payload payloadData) error {
 
@@ -1487,9 +1970,6 @@ func (mr *RoomManager) SendPayloadToPlayers(eventName string, protoMessage proto
 	var (
 		err          error
 		errFmtString = "%s 玩家連線中斷"
-		connections  = make(map[uint8]*skf.NSConn)
-		e, s, w, n   = uint8(east), uint8(south), uint8(west), uint8(north)
-		seat         uint8
 
 		payload = payloadData{
 			ProtoData:   protoMessage,
@@ -1497,23 +1977,9 @@ func (mr *RoomManager) SendPayloadToPlayers(eventName string, protoMessage proto
 		}
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
-
-	if connections[e] == nil {
-		err = fmt.Errorf(errFmtString, east)
-		seat = e
-	}
-	if connections[s] == nil {
-		err = fmt.Errorf(errFmtString, south)
-		seat = s
-	}
-	if connections[w] == nil {
-		err = fmt.Errorf(errFmtString, west)
-		seat = w
-	}
-	if connections[n] == nil {
-		err = fmt.Errorf(errFmtString, north)
-		seat = n
+	connections, seat := mr.playerConnections()
+	if seat != valueNotSet {
+		err = fmt.Errorf(errFmtString, CbSeat(seat))
 	}
 
 	if err != nil {
@@ -1558,17 +2024,15 @@ func (mr *RoomManager) SendPayloadToPlayers(eventName string, protoMessage proto
 // SendPayloadToTwoPlayer 將同一個封包送向進攻方(莊,夢)
 func (mr *RoomManager) SendPayloadToTwoPlayer(eventName string, protoMessage proto.Message, player1, player2 uint8) error {
 	var (
-		err error
-		//三家connection
-		connections = make(map[uint8]*skf.NSConn)
-		e, s, w, n  = uint8(east), uint8(south), uint8(west), uint8(north)
-		payload     = payloadData{
+		err     error
+		payload = payloadData{
 			ProtoData:   protoMessage,
 			PayloadType: ProtobufType,
 		}
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
+	//機器人沒有連線,不發送
+	connections, _ := mr.playerConnections()
 	for seat, con := range connections {
 		switch seat {
 		case player1, player2:
@@ -1587,9 +2051,6 @@ func (mr *RoomManager) SendPayloadTo3PlayersByExclude(eventName string, protoMes
 	var (
 		err          error
 		errFmtString = "%s玩家連線中斷"
-		//三家connection
-		connections = make(map[uint8]*skf.NSConn)
-		e, s, w, n  = uint8(east), uint8(south), uint8(west), uint8(north)
 
 		payload = payloadData{
 			ProtoData:   protoMessage,
@@ -1597,19 +2058,9 @@ func (mr *RoomManager) SendPayloadTo3PlayersByExclude(eventName string, protoMes
 		}
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
-
-	if connections[e] == nil {
-		err = fmt.Errorf(errFmtString, "east")
-	}
-	if connections[s] == nil {
-		err = fmt.Errorf(errFmtString, "north")
-	}
-	if connections[w] == nil {
-		err = fmt.Errorf(errFmtString, "west")
-	}
-	if connections[n] == nil {
-		err = fmt.Errorf(errFmtString, "north")
+	connections, broken := mr.playerConnections()
+	if broken != valueNotSet {
+		err = fmt.Errorf(errFmtString, CbSeat(broken))
 	}
 
 	if err != nil {
@@ -1653,24 +2104,11 @@ func (mr *RoomManager) SendPayloadToOneAndPayloadToOthers(
 	var (
 		err          error
 		errFmtString = "%s玩家連線中斷"
-		//三家connection
-		connections = make(map[uint8]*skf.NSConn)
-		e, s, w, n  = uint8(east), uint8(south), uint8(west), uint8(north)
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
-
-	if connections[e] == nil {
-		err = fmt.Errorf(errFmtString, "east")
-	}
-	if connections[s] == nil {
-		err = fmt.Errorf(errFmtString, "north")
-	}
-	if connections[w] == nil {
-		err = fmt.Errorf(errFmtString, "west")
-	}
-	if connections[n] == nil {
-		err = fmt.Errorf(errFmtString, "north")
+	connections, broken := mr.playerConnections()
+	if broken != valueNotSet {
+		err = fmt.Errorf(errFmtString, CbSeat(broken))
 	}
 
 	if err != nil {
@@ -1714,9 +2152,6 @@ func (mr *RoomManager) SendPayloadToDefendersToAttacker(eventName string,
 	var (
 		err          error
 		errFmtString = "%s玩家連線中斷"
-		//三家connection
-		connections = make(map[uint8]*skf.NSConn)
-		e, s, w, n  = uint8(east), uint8(south), uint8(west), uint8(north)
 
 		defenderPayload, attackerPayload payloadData = payloadData{
 			ProtoData:   defender2,
@@ -1728,19 +2163,9 @@ func (mr *RoomManager) SendPayloadToDefendersToAttacker(eventName string,
 			}
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
-
-	if connections[e] == nil {
-		err = fmt.Errorf(errFmtString, "east")
-	}
-	if connections[s] == nil {
-		err = fmt.Errorf(errFmtString, "north")
-	}
-	if connections[w] == nil {
-		err = fmt.Errorf(errFmtString, "west")
-	}
-	if connections[n] == nil {
-		err = fmt.Errorf(errFmtString, "north")
+	connections, broken := mr.playerConnections()
+	if broken != valueNotSet {
+		err = fmt.Errorf(errFmtString, CbSeat(broken))
 	}
 
 	if err != nil {
@@ -1792,6 +2217,10 @@ func (mr *RoomManager) SendPayloadToPlayer(eventName string, payload payloadData
 		slog.Error("SendPayloadsToPlayer", slog.String(".", fmt.Sprintf("未找到%s可進行發送", name)))
 		return nil
 	}
+	//機器人沒有連線,不發送
+	if conn == nil {
+		return nil
+	}
 	err = mr.send(conn, eventName, payload)
 	if err != nil {
 		return err
@@ -1891,23 +2320,11 @@ func (mr *RoomManager) SendPayloadsToPlayers(eventName string, payloads ...paylo
 	var (
 		err          error
 		errFmtString = "%s玩家連線中斷"
-		connections  = make(map[uint8]*skf.NSConn)
-		e, s, w, n   = uint8(east), uint8(south), uint8(west), uint8(north)
 	)
 
-	connections[e], connections[s], connections[w], connections[n] = mr.AcquirePlayerConnections()
-
-	if connections[e] == nil {
-		err = fmt.Errorf(errFmtString, "east")
-	}
-	if connections[s] == nil {
-		err = fmt.Errorf(errFmtString, "north")
-	}
-	if connections[w] == nil {
-		err = fmt.Errorf(errFmtString, "west")
-	}
-	if connections[n] == nil {
-		err = fmt.Errorf(errFmtString, "north")
+	connections, broken := mr.playerConnections()
+	if broken != valueNotSet {
+		err = fmt.Errorf(errFmtString, CbSeat(broken))
 	}
 
 	if err != nil {
@@ -1921,6 +2338,10 @@ func (mr *RoomManager) SendPayloadsToPlayers(eventName string, payloads ...paylo
 
 	} else {
 		for i := range payloads {
+			//機器人沒有連線,不發送
+			if _, ok := connections[payloads[i].Player]; !ok {
+				continue
+			}
 			err = mr.send(connections[payloads[i].Player], eventName, payloads[i])
 			if err != nil {
 				slog.Error("payload發送失敗(SendPayloadsToPlayers)", slog.String(",", err.Error()))
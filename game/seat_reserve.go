@@ -0,0 +1,134 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// 斷線保留座位: 遊戲進行中玩家不正常斷線時,不立即離桌中斷遊戲, 而是保留座位並暫停輪次計時,
+// 玩家在保留時間內出示入座時取得的座位憑證(TablePrivateSeatToken)重新入座(PlayerJoin)即可取回座位與手牌繼續遊戲, 逾時才釋放座位並中斷遊戲
+
+// SeatReserve 座位保留/取回通知
+type SeatReserve struct {
+	Seat  uint8  `json:"seat"`
+	Name  string `json:"name"`
+	Grace uint32 `json:"grace"` //保留時間(秒), 取回座位時為0
+}
+
+// newSeatToken 產生座位憑證
+func newSeatToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// seatTokenEqual 座位憑證(token)與玩家出示的憑證(presented)是否相同
+func seatTokenEqual(token, presented string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(presented)) == 1
+}
+
+// SetReconnectGrace 設定該房間斷線保留座位時間(秒), 0表示不保留(斷線立即離桌)
+func (g *Game) SetReconnectGrace(seconds uint32) {
+	g.reconnectGrace.Store(seconds)
+}
+
+// ReconnectGrace 該房間斷線保留座位時間(秒)
+func (g *Game) ReconnectGrace() uint32 {
+	return g.reconnectGrace.Load()
+}
+
+// reserveSeat 遊戲進行中玩家斷線,保留座位並暫停輪次計時, 無法保留(未設定保留時間,遊戲未進行)回傳false
+func (g *Game) reserveSeat(user *RoomUser) bool {
+	grace := g.ReconnectGrace()
+	if grace == 0 {
+		return false
+	}
+	switch g.Phase() {
+	case PhaseDealing, PhaseBidding, PhaseOpeningLead, PhasePlaying, PhaseSettling:
+	default:
+		return false
+	}
+
+	seat, name, reservation, err := g.roomManager.ReserveSeat(user)
+	if err != nil {
+		g.log.Wrn("reserveSeat", slog.String(".", err.Error()))
+		return false
+	}
+
+	g.mu.Lock()
+	g.pauseTurnTimer()
+	g.mu.Unlock()
+
+	slog.Info("reserveSeat", slog.String(name, CbSeat(seat).String()), slog.Uint64("grace", uint64(grace)))
+	g.sendSeatReserve(ClnRoomEvents.TableOnReserve, SeatReserve{Seat: seat, Name: name, Grace: grace})
+
+	time.AfterFunc(time.Duration(grace)*time.Second, func() {
+		g.releaseSeat(seat, name, reservation)
+	})
+	return true
+}
+
+// releaseSeat 保留時間到,玩家尚未取回座位(保留序號reservation仍有效)則由機器人接手, 或釋放座位並中斷遊戲
+func (g *Game) releaseSeat(seat uint8, name string, reservation uint32) {
+	if g.RobotTakeover() {
+		if g.roomManager.TakeoverSeat(seat, reservation, NewRuleRobot()) {
+			slog.Info("releaseSeat", slog.String(name, fmt.Sprintf("%s 由機器人接手", CbSeat(seat))))
+			g.mu.Lock()
+			g.resumeTurnTimer()
+			g.mu.Unlock()
+		}
+		return
+	}
+
+	if !g.roomManager.ReleaseSeat(seat, reservation) {
+		//已取回座位, 或已再次保留
+		return
+	}
+	slog.Info("releaseSeat", slog.String(name, CbSeat(seat).String()))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paused.Add(-1)
+	g.abort()
+}
+
+// seatReclaimed 斷線玩家重新入座取回座位, 重送手牌與遊戲桌狀態並恢復輪次計時
+func (g *Game) seatReclaimed(user *RoomUser, seat uint8) {
+	slog.Info("seatReclaimed", slog.String(user.Name, CbSeat(seat).String()))
+
+	g.mu.Lock()
+	var hand []uint8
+	if cards, ok := g.deckInPlay[seat]; ok {
+		hand = append(hand, cards[:]...)
+	}
+	g.mu.Unlock()
+
+	if hand != nil {
+		if err := g.roomManager.SendBytes(user.NsConn, ClnRoomEvents.GamePrivateDeal, hand); err != nil {
+			g.log.Wrn("seatReclaimed", slog.String(".", err.Error()))
+		}
+	}
+	g.SendTableSnapshot(user)
+	g.sendSeatReserve(ClnRoomEvents.TableOnReclaim, SeatReserve{Seat: seat, Name: user.Name})
+
+	g.mu.Lock()
+	g.resumeTurnTimer()
+	g.mu.Unlock()
+}
+
+// sendSeatReserve 廣播座位保留/取回
+func (g *Game) sendSeatReserve(eventName string, reserve SeatReserve) {
+	body, err := json.Marshal(reserve)
+	if err != nil {
+		g.log.Wrn("sendSeatReserve", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, eventName, g.name, body)
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moszorn/pb"
+)
+
+// reserveForTest 座位(seat)保留給斷線的玩家(name), 回傳座位憑證
+func reserveForTest(mr *RoomManager, seat CbSeat, name string) (token string) {
+	mr.Do(func(i any) {
+		if tp := i.(*tablePlayer); tp.zone == uint8(seat) {
+			tp.player.Name = name
+			tp.reserved = name
+			tp.token = newSeatToken()
+			token = tp.token
+		}
+	})
+	mr.players++
+	return
+}
+
+func TestReclaimSeat(t *testing.T) {
+	mr := newRoomManager(context.Background())
+	token := reserveForTest(mr, south, "south")
+	user := func(name, seatToken string) *RoomUser {
+		return &RoomUser{PlayingUser: &pb.PlayingUser{Name: name}, Credential: RoomCredential{SeatToken: seatToken}}
+	}
+
+	for _, u := range []*RoomUser{user("south", ""), user("south", "wrong")} {
+		if _, _, ok := mr.reclaimSeat(u); ok {
+			t.Errorf("reclaimSeat(%q) 取回座位, want 失敗", u.Credential.SeatToken)
+		}
+	}
+
+	//姓名以保留座位為準, 取回後換發新的座位憑證
+	u := user("other", token)
+	seat, next, ok := mr.reclaimSeat(u)
+	if !ok || seat != uint8(south) || u.Name != "south" {
+		t.Fatalf("reclaimSeat() = %s, %t, %s, want %s, true, south", CbSeat(seat), ok, u.Name, south)
+	}
+	if next == "" || next == token {
+		t.Errorf("reclaimSeat() 座位憑證 %q 沒有換發", next)
+	}
+	if _, _, ok := mr.reclaimSeat(user("south", token)); ok {
+		t.Error("已取回的座位以舊的座位憑證再次取回")
+	}
+}
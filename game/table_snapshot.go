@@ -0,0 +1,177 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"github.com/moszorn/utils/skf"
+)
+
+type (
+	// SnapshotBid 競叫紀錄中的一個叫品
+	SnapshotBid struct {
+		Seat      uint8  `json:"seat"`
+		Bid       uint8  `json:"bid"`
+		BidString string `json:"bidString"`
+
+		Alert       bool   `json:"alert,omitempty"`       //叫者警示, 只有叫者的對手看得到
+		Explanation string `json:"explanation,omitempty"` //警示說明
+	}
+
+	// SnapshotContract 合約資訊, 合約確定後(OpeningLead之後)才有
+	SnapshotContract struct {
+		Declarer       uint8  `json:"declarer"`
+		Dummy          uint8  `json:"dummy"`
+		Lead           uint8  `json:"lead"`
+		Suit           uint8  `json:"suit"`
+		Contract       uint8  `json:"contract"`
+		ContractString string `json:"contractString"`
+		DoubleString   string `json:"doubleString"`
+		Vulnerable     bool   `json:"vulnerable"` //莊家方是否有身價
+	}
+
+	// TableSnapshot 遊戲桌目前狀態, 給中途進入房間(或重新連線)的使用者重建遊戲畫面
+	// 各家持牌依觀看者(Viewer)權限遮蔽, 看不到的牌以 BaseCover 表示(只透露張數)
+	TableSnapshot struct {
+		Viewer   uint8             `json:"viewer"` //觀看者座位, valueNotSet表示觀眾
+		Phase    PhaseInfo         `json:"phase"`
+		Board    Board             `json:"board"`
+		Turn     uint8             `json:"turn"` //目前輪到的座位(叫牌或被打出牌的座位)
+		Auction  []SnapshotBid     `json:"auction"`
+		Contract *SnapshotContract `json:"contract,omitempty"`
+		Hands    map[uint8][]uint8 `json:"hands"`
+		Trick    [4]uint8          `json:"trick"`  //本回合桌面上的牌,順序固定為東,南,西,北
+		Played   uint8             `json:"played"` //該局已出牌數
+		Tally    TrickTally        `json:"tally"`
+	}
+)
+
+// canSeeHand 觀看者(viewer)是否可以看到座位(seat)的持牌
+//
+//	自己的牌永遠可見; 首引打出後所有人都可以看到夢家的牌; 合約確定後夢家可以看到莊家的牌
+func (g *Game) canSeeHand(viewer, seat uint8, phase GamePhase) bool {
+	if viewer == seat {
+		return true
+	}
+	switch phase {
+	case PhasePlaying, PhaseSettling:
+		if seat == uint8(g.Dummy) {
+			return true
+		}
+		fallthrough
+	case PhaseOpeningLead:
+		return viewer == uint8(g.Dummy) && seat == uint8(g.Declarer)
+	}
+	return false
+}
+
+// snapshot 以觀看者(viewer)權限建立遊戲桌狀態
+func (g *Game) snapshot(viewer uint8) *TableSnapshot {
+	var (
+		phase = g.Phase()
+		snap  = &TableSnapshot{
+			Viewer: viewer,
+			Phase:  g.PhaseInfo(),
+			Board:  g.engine.board,
+			Turn:   g.engine.currentPlay,
+			Trick:  [4]uint8{g.eastCard, g.southCard, g.westCard, g.northCard},
+			Played: g.countingInPlayCard,
+			Tally:  g.engine.TrickTally(),
+			Hands:  make(map[uint8][]uint8, len(playerSeats)),
+		}
+	)
+
+	switch phase {
+	case PhaseWaitingForPlayers, PhaseDealing, PhaseAborted:
+		//尚未發牌,或遊戲中斷,沒有牌局資訊
+		return snap
+	}
+
+	for _, b := range g.engine.Auction() {
+		bid := SnapshotBid{
+			Seat:      b.who(),
+			Bid:       b.bid(),
+			BidString: b.value.String(),
+		}
+		if b.alert && alertVisible(viewer, b.who()) {
+			bid.Alert, bid.Explanation = true, b.explanation
+		}
+		snap.Auction = append(snap.Auction, bid)
+	}
+
+	if phase != PhaseBidding {
+		contract := g.engine.contract
+		snap.Contract = &SnapshotContract{
+			Declarer:       uint8(g.Declarer),
+			Dummy:          uint8(g.Dummy),
+			Lead:           uint8(g.Lead),
+			Suit:           uint8(g.KingSuit),
+			Contract:       uint8(contract.contract),
+			ContractString: contract.contract.String(),
+			DoubleString:   contract.dbType.String(),
+			Vulnerable:     contract.vulnerable,
+		}
+	}
+
+	for _, seat := range playerSeats {
+		hand, ok := g.deckInPlay[seat]
+		if !ok {
+			continue
+		}
+		cards := make([]uint8, 0, NumOfCardsOnePlayer)
+		visible := g.canSeeHand(viewer, seat, phase)
+		for _, c := range hand {
+			if c == uint8(BaseCover) {
+				continue
+			}
+			if !visible {
+				c = uint8(BaseCover)
+			}
+			cards = append(cards, c)
+		}
+		snap.Hands[seat] = cards
+	}
+	return snap
+}
+
+// viewerSeat 連線在遊戲中的座位(PlayerJoin設定KeyGame), 不在遊戲中回傳valueNotSet(觀眾)
+func viewerSeat(nsConn *skf.NSConn) uint8 {
+	if seat, ok := nsConn.Conn.Get(KeyGame).(uint8); ok {
+		return seat
+	}
+	return valueNotSet
+}
+
+// SendTableSnapshot 送出遊戲桌目前狀態給使用者(進入房間,重新入座或重新連線)
+func (g *Game) SendTableSnapshot(user *RoomUser) {
+	if user.NsConn == nil || user.NsConn.Conn.IsClosed() {
+		return
+	}
+
+	g.mu.Lock()
+	snap := g.snapshot(viewerSeat(user.NsConn))
+	g.mu.Unlock()
+
+	body, err := json.Marshal(snap)
+	if err != nil {
+		g.log.Wrn("SendTableSnapshot", slog.String(".", err.Error()))
+		return
+	}
+	if err = g.roomManager.SendBytes(user.NsConn, ClnRoomEvents.UserPrivateTableSnapshot, body); err != nil {
+		g.log.Wrn("SendTableSnapshot", slog.String(".", err.Error()))
+	}
+}
+
+// refreshTable 撤回等改變遊戲桌狀態後, 依各連線觀看權限送出遊戲桌目前狀態給房間所有人, 必須在遊戲鎖(mu)內呼叫
+func (g *Game) refreshTable() {
+	for _, conn := range g.roomManager.roomConnections() {
+		body, err := json.Marshal(g.snapshot(viewerSeat(conn)))
+		if err != nil {
+			g.log.Wrn("refreshTable", slog.String(".", err.Error()))
+			return
+		}
+		if err = g.roomManager.SendBytes(conn, ClnRoomEvents.UserPrivateTableSnapshot, body); err != nil {
+			g.log.Wrn("refreshTable", slog.String(".", err.Error()))
+		}
+	}
+}
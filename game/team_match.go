@@ -0,0 +1,254 @@
+package game
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// 隊制賽(TeamMatch): 開室(open)與閉室(closed)兩個遊戲桌打相同的牌, 主隊在開室坐南北,在閉室坐東西, 客隊相反;
+// 每副牌兩桌都打完後以兩桌南北得分差換算IMP, 每副牌IMP與累計比數廣播給兩桌玩家與觀眾;
+// 建立時為每位玩家核發座位通行碼(Passes)交給主辦者, 玩家上座前以房間憑證(UserPrivateCredential)出示, 姓名由前端傳入不能作為身分
+
+type (
+	// SeatPlan 指定座位的玩家與座位通行碼, 順序固定為東,南,西,北, 空字串表示任何人都可以入座
+	SeatPlan struct {
+		Names  [4]string
+		Passes [4]string
+	}
+
+	// MatchTeam 參賽隊伍
+	MatchTeam struct {
+		Name   string    `json:"name"`
+		Open   [2]string `json:"open"`   //開室配對
+		Closed [2]string `json:"closed"` //閉室配對
+	}
+
+	// TeamBoard 隊制賽一副牌兩桌的南北得分與主隊得到的IMP
+	TeamBoard struct {
+		Board  uint32 `json:"board"`
+		Open   int32  `json:"open"`   //開室南北得分
+		Closed int32  `json:"closed"` //閉室南北得分
+		IMP    int32  `json:"imp"`    //主隊IMP, 負數表示客隊得分
+	}
+
+	// TeamMatchScore 隊制賽累計比數
+	TeamMatchScore struct {
+		Match  string      `json:"match"`
+		Teams  [2]string   `json:"teams"` //主隊,客隊
+		Boards []TeamBoard `json:"boards"`
+		Score  [2]int32    `json:"score"` //主隊,客隊累計IMP
+		Final  bool        `json:"final"`
+	}
+
+	// TeamMatch 隊制賽
+	TeamMatch struct {
+		name    string
+		teams   [2]MatchTeam //主隊,客隊
+		open    *Game
+		closed  *Game
+		session *DuplicateSession
+		passes  map[string]string //玩家名稱:座位通行碼
+
+		mu    sync.Mutex
+		score TeamMatchScore
+	}
+)
+
+// NewTeamMatch 建立隊制賽, 開室(open)與閉室(closed)以種子(seed)依序打牌號1~boards, 主隊(home)在開室坐南北, 客隊(away)在開室坐東西
+func NewTeamMatch(name string, seed uint64, boards uint32, open, closed *Game, home, away MatchTeam) (*TeamMatch, error) {
+	if open == closed {
+		return nil, fmt.Errorf("%w: 開室與閉室必須是不同的遊戲桌", ErrSession)
+	}
+	players := make(map[string]bool, 8)
+	for _, team := range []MatchTeam{home, away} {
+		for _, player := range append(team.Open[:], team.Closed[:]...) {
+			if player == "" || players[player] {
+				return nil, fmt.Errorf("%w: %s隊玩家名稱空白或重複", ErrSession, team.Name)
+			}
+			players[player] = true
+		}
+	}
+
+	session, err := NewDuplicateSession(name, seed, boards, open, closed)
+	if err != nil {
+		return nil, err
+	}
+	session.SetScoring(ScoringIMP)
+
+	m := &TeamMatch{
+		name:    name,
+		teams:   [2]MatchTeam{home, away},
+		open:    open,
+		closed:  closed,
+		session: session,
+		passes:  make(map[string]string, len(players)),
+		score: TeamMatchScore{
+			Match:  name,
+			Teams:  [2]string{home.Name, away.Name},
+			Boards: make([]TeamBoard, 0, boards),
+		},
+	}
+	session.mu.Lock()
+	session.onBoard = m.scoreBoard
+	session.mu.Unlock()
+
+	var openPlan, closedPlan SeatPlan
+	openPlan.Names[seatIndex(uint8(north))], openPlan.Names[seatIndex(uint8(south))] = home.Open[0], home.Open[1]
+	openPlan.Names[seatIndex(uint8(east))], openPlan.Names[seatIndex(uint8(west))] = away.Open[0], away.Open[1]
+	closedPlan.Names[seatIndex(uint8(north))], closedPlan.Names[seatIndex(uint8(south))] = away.Closed[0], away.Closed[1]
+	closedPlan.Names[seatIndex(uint8(east))], closedPlan.Names[seatIndex(uint8(west))] = home.Closed[0], home.Closed[1]
+	for _, plan := range []*SeatPlan{&openPlan, &closedPlan} {
+		for idx, player := range plan.Names {
+			plan.Passes[idx] = newSeatToken()
+			m.passes[player] = plan.Passes[idx]
+		}
+	}
+	open.SetSeatPlan(&openPlan)
+	closed.SetSeatPlan(&closedPlan)
+	open.teamMatch.Store(m)
+	closed.teamMatch.Store(m)
+
+	go m.watch()
+
+	slog.Info("TeamMatch", slog.String("隊制賽", name), slog.String("開室", open.name), slog.String("閉室", closed.name),
+		slog.String("主隊", home.Name), slog.String("客隊", away.Name), slog.Uint64("牌數", uint64(boards)))
+	return m, nil
+}
+
+// Session 隊制賽的複式賽程
+func (m *TeamMatch) Session() *DuplicateSession {
+	return m.session
+}
+
+// Passes 每位玩家的座位通行碼(玩家名稱:通行碼), 由主辦者交給玩家, 上座前以房間憑證(Credential.SeatPass)出示
+func (m *TeamMatch) Passes() map[string]string {
+	passes := make(map[string]string, len(m.passes))
+	for player, pass := range m.passes {
+		passes[player] = pass
+	}
+	return passes
+}
+
+// Score 目前累計比數
+func (m *TeamMatch) Score() TeamMatchScore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	score := m.score
+	score.Boards = append([]TeamBoard(nil), m.score.Boards...)
+	return score
+}
+
+// Close 結束隊制賽, 尚未打完的牌不計分
+func (m *TeamMatch) Close() {
+	m.session.Close()
+}
+
+// scoreBoard 兩桌都打完(或一桌取消)一副牌, 計算主隊IMP並廣播累計比數
+func (m *TeamMatch) scoreBoard(board DuplicateBoard) {
+	tb := TeamBoard{Board: board.Board.Number}
+	var played int
+	for _, r := range board.Results {
+		switch r.Room {
+		case m.open.name:
+			tb.Open = r.NS
+			played++
+		case m.closed.name:
+			tb.Closed = r.NS
+			played++
+		}
+	}
+	//主隊得分 = 開室南北 + 閉室東西 = 開室南北 - 閉室南北
+	if played == 2 {
+		tb.IMP = IMP(tb.Open - tb.Closed)
+	}
+
+	m.mu.Lock()
+	m.score.Boards = append(m.score.Boards, tb)
+	if tb.IMP > 0 {
+		m.score.Score[0] += tb.IMP
+	} else {
+		m.score.Score[1] -= tb.IMP
+	}
+	m.mu.Unlock()
+
+	m.broadcast(m.Score())
+}
+
+// watch 賽程結束時廣播最終比數, 遊戲桌恢復一般入座
+func (m *TeamMatch) watch() {
+	<-m.session.Done()
+
+	for _, g := range []*Game{m.open, m.closed} {
+		g.teamMatch.CompareAndSwap(m, nil)
+		g.SetSeatPlan(nil)
+	}
+
+	m.mu.Lock()
+	m.score.Final = true
+	m.mu.Unlock()
+
+	score := m.Score()
+	slog.Info("TeamMatch", slog.String("隊制賽", m.name), slog.String(".", "隊制賽結束"),
+		slog.String(score.Teams[0], fmt.Sprint(score.Score[0])), slog.String(score.Teams[1], fmt.Sprint(score.Score[1])))
+	m.broadcast(score)
+}
+
+// broadcast 廣播累計比數給兩桌玩家與觀眾
+func (m *TeamMatch) broadcast(score TeamMatchScore) {
+	body, err := json.Marshal(score)
+	if err != nil {
+		slog.Warn("TeamMatch", slog.String(".", err.Error()))
+		return
+	}
+	for _, g := range []*Game{m.open, m.closed} {
+		g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameTeamScore, g.name, body)
+	}
+}
+
+// TeamMatch 該房間所在的隊制賽, 不在隊制賽中回傳nil
+func (g *Game) TeamMatch() *TeamMatch {
+	return g.teamMatch.Load()
+}
+
+// sendTeamScore 隊制賽中送出目前累計比數給進入房間的使用者
+func (g *Game) sendTeamScore(user *RoomUser) {
+	m := g.TeamMatch()
+	if m == nil || user.NsConn == nil || user.NsConn.Conn.IsClosed() {
+		return
+	}
+
+	body, err := json.Marshal(m.Score())
+	if err != nil {
+		g.log.Wrn("sendTeamScore", slog.String(".", err.Error()))
+		return
+	}
+	if err = g.roomManager.SendBytes(user.NsConn, ClnRoomEvents.GamePrivateTeamScore, body); err != nil {
+		g.log.Wrn("sendTeamScore", slog.String(".", err.Error()))
+	}
+}
+
+// SetSeatPlan 指定座位的玩家, nil表示任何人都可以入座任何空位; 已入座的玩家不受影響
+func (g *Game) SetSeatPlan(plan *SeatPlan) {
+	g.seatPlan.Store(plan)
+}
+
+// seatAllowed 使用者(user)可以入座座位(seat): 指定座位只有出示該座位通行碼(Credential.SeatPass)的指定玩家能入座, 有指定座位的玩家只能入座自己的座位
+func (g *Game) seatAllowed(seat uint8, user *RoomUser) bool {
+	plan := g.seatPlan.Load()
+	if plan == nil {
+		return true
+	}
+	idx := seatIndex(seat)
+	if planned := plan.Names[idx]; planned != "" {
+		return planned == user.Name && subtle.ConstantTimeCompare([]byte(user.Credential.SeatPass), []byte(plan.Passes[idx])) == 1
+	}
+	for _, planned := range plan.Names {
+		if planned == user.Name {
+			return false
+		}
+	}
+	return true
+}
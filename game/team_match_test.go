@@ -0,0 +1,77 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/moszorn/pb"
+	"github.com/moszorn/utils/skf"
+)
+
+func TestSeatAllowed(t *testing.T) {
+	plan := &SeatPlan{
+		Names:  [4]string{"east", "south", "", "north"},
+		Passes: [4]string{"e-pass", "s-pass", "", "n-pass"},
+	}
+	user := func(name, pass string) *RoomUser {
+		return &RoomUser{PlayingUser: &pb.PlayingUser{Name: name}, Credential: RoomCredential{SeatPass: pass}}
+	}
+
+	tests := []struct {
+		name string
+		plan *SeatPlan
+		seat CbSeat
+		user *RoomUser
+		want bool
+	}{
+		{"沒有指定座位", nil, east, user("anyone", ""), true},
+		{"指定玩家出示通行碼", plan, east, user("east", "e-pass"), true},
+		{"指定玩家沒有通行碼", plan, east, user("east", ""), false},
+		{"冒用指定玩家名稱", plan, south, user("south", "e-pass"), false},
+		{"通行碼正確但名稱不符", plan, north, user("other", "n-pass"), false},
+		{"未指定的座位任何人都可以入座", plan, west, user("anyone", ""), true},
+		{"有指定座位的玩家不能坐其他座位", plan, west, user("north", "n-pass"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{}
+			g.SetSeatPlan(tt.plan)
+			if got := g.seatAllowed(uint8(tt.seat), tt.user); got != tt.want {
+				t.Errorf("seatAllowed(%s, %s) = %t, want %t", tt.seat, tt.user.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeatPlanReclaim(t *testing.T) {
+	g := testGame(t, "open")
+	g.SetSeatPlan(&SeatPlan{
+		Names:  [4]string{"east", "", "", ""},
+		Passes: [4]string{"e-pass", "", "", ""},
+	})
+	join := func(name string, credential RoomCredential) (*RoomUser, chanResult) {
+		u := &RoomUser{NsConn: &skf.NSConn{}, PlayingUser: &pb.PlayingUser{Name: name}, Tracking: EnterGame, Credential: credential}
+		return u, g.roomManager.door.Probe(u)
+	}
+
+	if _, rep := join("east", RoomCredential{}); rep.seat != valueNotSet {
+		t.Fatalf("沒有通行碼的指定玩家入座 %s", CbSeat(rep.seat))
+	}
+	player, rep := join("east", RoomCredential{SeatPass: "e-pass"})
+	if rep.seat != uint8(east) || rep.token == "" {
+		t.Fatalf("指定玩家入座 %s, want %s", CbSeat(rep.seat), east)
+	}
+
+	//斷線保留座位, 其他人不能入座保留的座位
+	if seat, _, _, err := g.roomManager.ReserveSeat(player); err != nil || seat != uint8(east) {
+		t.Fatalf("ReserveSeat() = %s, %v", CbSeat(seat), err)
+	}
+	if _, other := join("other", RoomCredential{}); other.seat == uint8(east) {
+		t.Fatal("其他玩家入座保留給指定玩家的座位")
+	}
+
+	//重新連線出示座位通行碼與座位憑證取回座位
+	_, back := join("east", RoomCredential{SeatPass: "e-pass", SeatToken: rep.token})
+	if !back.reclaimed || back.seat != uint8(east) {
+		t.Errorf("指定玩家取回座位 %s reclaimed:%t, want %s", CbSeat(back.seat), back.reclaimed, east)
+	}
+}
@@ -0,0 +1,282 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// 配對賽(Tournament): 依移位表(Movement)一回合一回合進行, 每回合開始時排入各桌要打的牌並指定各桌座位(SeatPlan),
+// 並通知每組配對(大廳與各遊戲桌)下一回合到哪一桌坐哪一方; 所有遊戲桌打完或回合時間到時進入下一回合,
+// 回合時間到尚未打完的牌取消不計分.
+// 建立時為每位玩家核發座位通行碼(Passes), 每回合只有排定在該桌的配對出示通行碼才能入座; 已入座的玩家不受影響, 回合結束後依通知離座換桌
+
+type (
+	// TournamentPair 參賽配對
+	TournamentPair struct {
+		Number  int       `json:"number"`  //配對號碼,從1開始
+		Players [2]string `json:"players"` //兩位玩家名稱
+	}
+
+	// PairAssignment 配對在一回合的安排
+	PairAssignment struct {
+		Pair      int       `json:"pair"`
+		Players   [2]string `json:"players"`
+		Room      string    `json:"room,omitempty"`      //輪空時為空字串
+		Direction string    `json:"direction,omitempty"` //NS 或 EW
+		Opponent  int       `json:"opponent,omitempty"`
+		Boards    []uint32  `json:"boards,omitempty"`
+		SitOut    bool      `json:"sitOut"`
+	}
+
+	// TournamentRound 回合開始通知
+	TournamentRound struct {
+		Tournament  string           `json:"tournament"`
+		Round       int              `json:"round"`
+		Rounds      int              `json:"rounds"`
+		Deadline    time.Time        `json:"deadline"` //回合結束時間
+		Assignments []PairAssignment `json:"assignments"`
+	}
+
+	// Tournament 配對賽
+	Tournament struct {
+		name      string
+		movement  *Movement
+		rooms     []*Game //桌號1的遊戲桌為rooms[0]
+		pairs     map[int]TournamentPair
+		roundTime time.Duration
+		session   *DuplicateSession
+		notifier  LobbyNotifier
+		passes    map[string]string //玩家名稱:座位通行碼
+		stop      chan struct{}
+	}
+)
+
+// NewTournament 建立配對賽, 移位表(movement)的桌號依序對應遊戲桌(rooms), 每回合時間roundTime
+func NewTournament(name string, seed uint64, movement *Movement, rooms []*Game, pairs []TournamentPair, roundTime time.Duration) (*Tournament, error) {
+	if len(rooms) < movement.Tables {
+		return nil, fmt.Errorf("%w: 需要%d桌,只有%d桌", ErrMovement, movement.Tables, len(rooms))
+	}
+	if len(pairs) != movement.Pairs {
+		return nil, fmt.Errorf("%w: 需要%d組配對,只有%d組", ErrMovement, movement.Pairs, len(pairs))
+	}
+	if roundTime <= 0 {
+		return nil, fmt.Errorf("%w: 回合時間必須大於0", ErrMovement)
+	}
+
+	t := &Tournament{
+		name:      name,
+		movement:  movement,
+		rooms:     rooms[:movement.Tables],
+		pairs:     make(map[int]TournamentPair, len(pairs)),
+		roundTime: roundTime,
+		passes:    make(map[string]string, 2*len(pairs)),
+		stop:      make(chan struct{}),
+	}
+	for _, pair := range pairs {
+		if pair.Number < 1 || pair.Number > movement.Pairs {
+			return nil, fmt.Errorf("%w: 配對號碼%d", ErrMovement, pair.Number)
+		}
+		for _, player := range pair.Players {
+			if _, ok := t.passes[player]; player == "" || ok {
+				return nil, fmt.Errorf("%w: 配對%d玩家名稱空白或重複", ErrMovement, pair.Number)
+			}
+			t.passes[player] = newSeatToken()
+		}
+		t.pairs[pair.Number] = pair
+	}
+	if len(t.pairs) != movement.Pairs {
+		return nil, fmt.Errorf("%w: 配對號碼重複", ErrMovement)
+	}
+
+	session, err := newDuplicateSession(name, seed, t.rooms)
+	if err != nil {
+		return nil, err
+	}
+	session.idle = make(chan *Game, len(t.rooms))
+	t.session = session
+
+	expected := make(map[uint32]int)
+	for _, round := range movement.Rounds {
+		for _, table := range round.Tables {
+			for _, number := range table.Boards {
+				expected[number]++
+			}
+		}
+	}
+	session.plan(expected)
+	return t, nil
+}
+
+// Session 配對賽的複式賽程(計分,成績排名)
+func (t *Tournament) Session() *DuplicateSession {
+	return t.session
+}
+
+// Passes 每位玩家的座位通行碼(玩家名稱:通行碼), 由主辦者交給玩家, 上座前以房間憑證(Credential.SeatPass)出示
+func (t *Tournament) Passes() map[string]string {
+	passes := make(map[string]string, len(t.passes))
+	for player, pass := range t.passes {
+		passes[player] = pass
+	}
+	return passes
+}
+
+// SetLobbyNotifier 設定回合通知與成績排名推送到大廳
+func (t *Tournament) SetLobbyNotifier(notifier LobbyNotifier) {
+	t.notifier = notifier
+	t.session.SetLobbyNotifier(notifier)
+}
+
+// Start 開始配對賽, 第一回合開始
+func (t *Tournament) Start() {
+	slog.Info("Tournament", slog.String("賽程", t.name), slog.String("移位", t.movement.Kind.String()),
+		slog.Int("配對", t.movement.Pairs), slog.Int("桌數", t.movement.Tables), slog.Int("回合", len(t.movement.Rounds)))
+	go t.run()
+}
+
+// Stop 中止配對賽, 尚未打完的牌取消, 推送最終排名
+func (t *Tournament) Stop() {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+}
+
+func (t *Tournament) run() {
+	played := 0
+	defer func() {
+		//中止時取消還沒打的回合, 其他遊戲桌都打完的牌仍計分
+		for _, room := range t.rooms {
+			t.session.cancel(room)
+		}
+		for _, round := range t.movement.Rounds[played:] {
+			for _, table := range round.Tables {
+				t.session.withdraw(table.Boards)
+			}
+		}
+		t.session.seal()
+		t.session.Close()
+		//遊戲桌恢復一般入座
+		for _, room := range t.rooms {
+			room.SetSeatPlan(nil)
+		}
+	}()
+
+	for _, round := range t.movement.Rounds {
+		played++
+		deadline := time.Now().Add(t.roundTime)
+		for _, table := range round.Tables {
+			t.rooms[table.Table-1].SetSeatPlan(t.seatPlan(table))
+			if !table.SitOut() {
+				t.session.assign(t.rooms[table.Table-1], table.Boards)
+			}
+		}
+		t.notifyRound(round, deadline)
+		for _, table := range round.Tables {
+			if !table.SitOut() {
+				t.rooms[table.Table-1].resume()
+			}
+		}
+
+		if !t.waitRound(round, deadline) {
+			return
+		}
+	}
+}
+
+// seatPlan 該回合遊戲桌(table)的指定座位: 南北配對坐北,南, 東西配對坐東,西; 輪空的一方任何人都可以入座
+func (t *Tournament) seatPlan(table MovementTable) *SeatPlan {
+	var plan SeatPlan
+	for _, side := range [2]struct {
+		pair  int
+		seats [2]CbSeat
+	}{{table.NS, [2]CbSeat{north, south}}, {table.EW, [2]CbSeat{east, west}}} {
+		pair, ok := t.pairs[side.pair]
+		if !ok {
+			continue
+		}
+		for i, seat := range side.seats {
+			idx := seatIndex(uint8(seat))
+			plan.Names[idx], plan.Passes[idx] = pair.Players[i], t.passes[pair.Players[i]]
+		}
+	}
+	return &plan
+}
+
+// waitRound 等待該回合所有遊戲桌打完或回合時間到, 時間到時取消尚未打完的牌; 配對賽中止時回傳false
+func (t *Tournament) waitRound(round MovementRound, deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		if !t.playing(round) {
+			return true
+		}
+		select {
+		case <-t.session.idle:
+		case <-timer.C:
+			for _, table := range round.Tables {
+				if canceled := t.session.cancel(t.rooms[table.Table-1]); canceled > 0 {
+					slog.Info("Tournament", slog.String("賽程", t.name), slog.Int("回合", round.Round), slog.Int("桌號", table.Table), slog.Int("取消牌數", canceled))
+				}
+			}
+			return true
+		case <-t.stop:
+			return false
+		case <-t.session.Done():
+			return false
+		}
+	}
+}
+
+// playing 該回合還有遊戲桌沒打完
+func (t *Tournament) playing(round MovementRound) bool {
+	for _, table := range round.Tables {
+		if !table.SitOut() && t.session.pending(t.rooms[table.Table-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyRound 通知每組配對該回合到哪一桌坐哪一方(大廳與各遊戲桌)
+func (t *Tournament) notifyRound(round MovementRound, deadline time.Time) {
+	notice := TournamentRound{
+		Tournament: t.name,
+		Round:      round.Round,
+		Rounds:     len(t.movement.Rounds),
+		Deadline:   deadline,
+	}
+	seated := make(map[int]bool, len(t.pairs))
+	for _, table := range round.Tables {
+		if table.SitOut() {
+			continue
+		}
+		room := t.rooms[table.Table-1].name
+		notice.Assignments = append(notice.Assignments,
+			PairAssignment{Pair: table.NS, Players: t.pairs[table.NS].Players, Room: room, Direction: "NS", Opponent: table.EW, Boards: table.Boards},
+			PairAssignment{Pair: table.EW, Players: t.pairs[table.EW].Players, Room: room, Direction: "EW", Opponent: table.NS, Boards: table.Boards},
+		)
+		seated[table.NS], seated[table.EW] = true, true
+	}
+	for number := 1; number <= t.movement.Pairs; number++ {
+		if !seated[number] {
+			notice.Assignments = append(notice.Assignments, PairAssignment{Pair: number, Players: t.pairs[number].Players, SitOut: true})
+		}
+	}
+
+	body, err := json.Marshal(notice)
+	if err != nil {
+		slog.Warn("Tournament", slog.String(".", err.Error()))
+		return
+	}
+	for _, room := range t.rooms {
+		room.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameTournamentRound, room.name, body)
+	}
+	if t.notifier != nil {
+		t.notifier.NotifyLobby(ClnLobbyEvents.TournamentRound, body)
+	}
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/moszorn/pb"
+)
+
+// testTournament 5組配對(3桌)的 Mitchell 配對賽, 配對n的玩家為 pn-1, pn-2
+func testTournament(t *testing.T) *Tournament {
+	t.Helper()
+	m, err := NewMitchell(5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms := make([]*Game, 0, m.Tables)
+	for i := 0; i < m.Tables; i++ {
+		rooms = append(rooms, testGame(t, fmt.Sprintf("room%d", i+1)))
+	}
+	pairs := make([]TournamentPair, 0, m.Pairs)
+	for n := 1; n <= m.Pairs; n++ {
+		pairs = append(pairs, TournamentPair{Number: n, Players: [2]string{fmt.Sprintf("p%d-1", n), fmt.Sprintf("p%d-2", n)}})
+	}
+	tm, err := NewTournament("pairs", 1, m, rooms, pairs, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tm.Stop)
+	return tm
+}
+
+func TestTournamentSeatPlan(t *testing.T) {
+	tm := testTournament(t)
+	passes := tm.Passes()
+	if len(passes) != 2*tm.movement.Pairs {
+		t.Fatalf("Passes() %d位, want %d", len(passes), 2*tm.movement.Pairs)
+	}
+	user := func(name, pass string) *RoomUser {
+		return &RoomUser{PlayingUser: &pb.PlayingUser{Name: name}, Credential: RoomCredential{SeatPass: pass}}
+	}
+
+	for _, round := range tm.movement.Rounds {
+		for _, table := range round.Tables {
+			g := tm.rooms[table.Table-1]
+			g.SetSeatPlan(tm.seatPlan(table))
+			for _, side := range []struct {
+				pair  int
+				seats [2]CbSeat
+			}{{table.NS, [2]CbSeat{north, south}}, {table.EW, [2]CbSeat{east, west}}} {
+				for i, seat := range side.seats {
+					if side.pair == 0 {
+						//輪空的一方任何人都可以入座
+						if !g.seatAllowed(uint8(seat), user("guest", "")) {
+							t.Errorf("第%d回合第%d桌輪空的 %s 不能入座", round.Round, table.Table, seat)
+						}
+						continue
+					}
+					name := tm.pairs[side.pair].Players[i]
+					if !g.seatAllowed(uint8(seat), user(name, passes[name])) {
+						t.Errorf("第%d回合第%d桌 %s 不能入座 %s", round.Round, table.Table, name, seat)
+					}
+					if g.seatAllowed(uint8(seat), user(name, "")) {
+						t.Errorf("第%d回合第%d桌 %s 沒有通行碼入座 %s", round.Round, table.Table, name, seat)
+					}
+					//其他桌的配對不能入座
+					for n, pair := range tm.pairs {
+						if n != table.NS && n != table.EW && g.seatAllowed(uint8(seat), user(pair.Players[0], passes[pair.Players[0]])) {
+							t.Errorf("第%d回合第%d桌 配對%d入座 %s", round.Round, table.Table, n, seat)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTournamentRoundSeatPlan(t *testing.T) {
+	tm := testTournament(t)
+	tm.Start()
+
+	//回合開始時指定各桌座位, 配對賽結束後恢復一般入座
+	wait := func(assigned bool) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+			done := true
+			for _, room := range tm.rooms {
+				done = done && (room.seatPlan.Load() != nil) == assigned
+			}
+			if done {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("遊戲桌指定座位 want %t", assigned)
+			}
+		}
+	}
+	wait(true)
+	first := tm.movement.Rounds[0].Tables[0]
+	if plan := tm.rooms[0].seatPlan.Load(); plan.Names[seatIndex(uint8(north))] != tm.pairs[first.NS].Players[0] {
+		t.Errorf("第1桌北家 %q, want %q", plan.Names[seatIndex(uint8(north))], tm.pairs[first.NS].Players[0])
+	}
+
+	tm.Stop()
+	wait(false)
+}
+
+func TestTournamentPairNames(t *testing.T) {
+	m, err := NewMitchell(5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms := []*Game{testGame(t, "room1"), testGame(t, "room2"), testGame(t, "room3")}
+	pairs := []TournamentPair{
+		{Number: 1, Players: [2]string{"a", "b"}},
+		{Number: 2, Players: [2]string{"c", "d"}},
+		{Number: 3, Players: [2]string{"e", "a"}},
+		{Number: 4, Players: [2]string{"g", "h"}},
+		{Number: 5, Players: [2]string{"i", ""}},
+	}
+	if _, err := NewTournament("pairs", 1, m, rooms, pairs, time.Minute); !errors.Is(err, ErrMovement) {
+		t.Errorf("玩家名稱重複 NewTournament() error = %v, want %v", err, ErrMovement)
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+type (
+	// trick 一墩(回合)的出牌紀錄
+	trick struct {
+		lead   CbSeat   //該墩首打
+		winner CbSeat   //該墩贏家
+		cards  [4]uint8 //該墩四家打出的牌,順序固定為東,南,西,北
+	}
+
+	// trickLedger 一局遊戲的吃墩帳本,記錄每一墩的首打,贏家,四家出牌,以及各家吃墩數
+	trickLedger struct {
+		tricks []trick
+		seats  [4]uint8 //各家吃墩數,順序固定為東,南,西,北
+	}
+
+	// TrickTally 南北/東西目前吃墩數
+	TrickTally struct {
+		NS     uint8 `json:"ns"`     //南北吃墩數
+		EW     uint8 `json:"ew"`     //東西吃墩數
+		Tricks uint8 `json:"tricks"` //已完成墩數
+		Winner uint8 `json:"winner"` //最後一墩贏家
+	}
+)
+
+// seatIndex 座位(CbSeat)轉成 東0,南1,西2,北3 索引
+func seatIndex(seat uint8) uint8 {
+	return seat >> 6
+}
+
+func newTrickLedger() *trickLedger {
+	return &trickLedger{
+		tricks: make([]trick, 0, NumOfCardsOnePlayer),
+	}
+}
+
+// record 記錄一墩的結果, lead首打, winner贏家, 傳入的牌順序為東,南,西,北
+func (l *trickLedger) record(lead, winner, eastCard, southCard, westCard, northCard uint8) {
+	l.tricks = append(l.tricks, trick{
+		lead:   CbSeat(lead),
+		winner: CbSeat(winner),
+		cards:  [4]uint8{eastCard, southCard, westCard, northCard},
+	})
+	l.seats[seatIndex(winner)]++
+}
+
+// seatTricks 指定座位吃到的墩數
+func (l *trickLedger) seatTricks(seat uint8) uint8 {
+	return l.seats[seatIndex(seat)]
+}
+
+// sideTricks 南北,東西各吃到的墩數
+func (l *trickLedger) sideTricks() (ns, ew uint8) {
+	ns = l.seatTricks(uint8(south)) + l.seatTricks(uint8(north))
+	ew = l.seatTricks(uint8(east)) + l.seatTricks(uint8(west))
+	return
+}
+
+// played 已完成的墩數
+func (l *trickLedger) played() uint8 {
+	return uint8(len(l.tricks))
+}
+
+// last 最後完成的一墩, 尚未有完成的墩回傳nil
+func (l *trickLedger) last() *trick {
+	if len(l.tricks) == 0 {
+		return nil
+	}
+	return &l.tricks[len(l.tricks)-1]
+}
+
+// undo 撤回最後完成的一墩(撤回該墩最後一張出牌), 回傳撤回的墩, 尚未有完成的墩回傳nil
+func (l *trickLedger) undo() *trick {
+	last := l.last()
+	if last == nil {
+		return nil
+	}
+	t := *last
+	l.tricks = l.tricks[:len(l.tricks)-1]
+	l.seats[seatIndex(uint8(t.winner))]--
+	return &t
+}
+
+// clear 清空帳本,但記憶體仍保留
+func (l *trickLedger) clear() {
+	l.tricks = l.tricks[:0]
+	l.seats = [4]uint8{}
+}
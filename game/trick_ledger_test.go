@@ -0,0 +1,68 @@
+package game
+
+import "testing"
+
+func TestTrickLedger(t *testing.T) {
+	l := newTrickLedger()
+	if l.last() != nil || l.undo() != nil {
+		t.Fatal("空帳本 last(), undo() 不是nil")
+	}
+
+	//南家首打南家贏, 西家首打北家贏, 北家首打東家贏
+	l.record(uint8(south), uint8(south), spade2, spadeAce, spade3, spade4)
+	l.record(uint8(west), uint8(north), heart2, heart3, heart4, heartAce)
+	l.record(uint8(north), uint8(east), clubAce, club2, club3, club4)
+
+	if ns, ew := l.sideTricks(); ns != 2 || ew != 1 || l.played() != 3 {
+		t.Errorf("sideTricks() = %d, %d, played() = %d, want 2, 1, 3", ns, ew, l.played())
+	}
+	if l.seatTricks(uint8(north)) != 1 || l.seatTricks(uint8(west)) != 0 {
+		t.Errorf("北 %d 西 %d, want 1, 0", l.seatTricks(uint8(north)), l.seatTricks(uint8(west)))
+	}
+	if last := l.last(); last.lead != north || last.winner != east || last.cards != [4]uint8{clubAce, club2, club3, club4} {
+		t.Errorf("last() = %+v", *last)
+	}
+
+	//撤回最後一墩, 贏家少一墩
+	undone := l.undo()
+	if undone == nil || undone.winner != east || undone.cards[0] != clubAce {
+		t.Fatalf("undo() = %v, want 東家贏的一墩", undone)
+	}
+	if ns, ew := l.sideTricks(); ns != 2 || ew != 0 || l.played() != 2 || l.last().winner != north {
+		t.Errorf("撤回後 sideTricks() = %d, %d, played() = %d, want 2, 0, 2", ns, ew, l.played())
+	}
+
+	l.clear()
+	if ns, ew := l.sideTricks(); ns != 0 || ew != 0 || l.played() != 0 || l.last() != nil {
+		t.Errorf("清空後 sideTricks() = %d, %d, played() = %d", ns, ew, l.played())
+	}
+}
+
+func TestEngineTrickTally(t *testing.T) {
+	egn := newEngine()
+	egn.declarer, egn.dummy = south, north
+	if tally := egn.TrickTally(); tally != (TrickTally{Winner: valueNotSet}) {
+		t.Errorf("尚未打完一墩 TrickTally() = %+v", tally)
+	}
+
+	//西家最後出牌, 該墩由北家首打, 無王南家 ♥A 贏
+	egn.currentPlay = uint8(west)
+	if winner := egn.GetPlayResult(heart2, heartAce, heart3, heartK, TRUMP); winner != uint8(south) {
+		t.Fatalf("GetPlayResult() = %s, want %s", CbSeat(winner), south)
+	}
+	if last := egn.ledger.last(); last.lead != north {
+		t.Errorf("首打 %s, want %s", last.lead, north)
+	}
+
+	//東家最後出牌, 該墩由南家首打, 西家 ♣A 贏
+	egn.currentPlay = uint8(east)
+	egn.GetPlayResult(club3, club2, clubAce, club4, TRUMP)
+
+	want := TrickTally{NS: 1, EW: 1, Tricks: 2, Winner: uint8(west)}
+	if tally := egn.TrickTally(); tally != want {
+		t.Errorf("TrickTally() = %+v, want %+v", tally, want)
+	}
+	if egn.declarerTricks() != 1 {
+		t.Errorf("declarerTricks() = %d, want 1", egn.declarerTricks())
+	}
+}
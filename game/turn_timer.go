@@ -0,0 +1,167 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/moszorn/pb"
+	"github.com/moszorn/pb/cb"
+)
+
+// 伺服器端輪次計時: 每次通知玩家叫牌/出牌(Notice)時開始計時, 玩家合法動作後取消,
+// 時間到時競叫自動PASS, 出牌自動打出 TimeoutCardValue. 避免玩家斷線或前端gauge失效造成牌桌卡住
+
+const (
+	// turnGrace 伺服器計時比前端gauge多等待的時間,讓前端gauge時間到自動出牌的封包優先抵達
+	turnGrace = 2 * time.Second
+
+	// robotThinking 輪到機器人時,延遲多久才動作,讓前端有時間呈現
+	robotThinking = 1200 * time.Millisecond
+)
+
+// pendingTurn 目前計時中的輪次, 暫停計時(斷線保留座位)後恢復時重新計時
+type pendingTurn struct {
+	seat     uint8
+	onExpire func()
+	onRobot  func(robot Robot) //輪到機器人座位時,由機器人決定動作
+}
+
+// SetCountDown 設定該房間玩家叫/出牌時間(秒)
+func (g *Game) SetCountDown(seconds uint32) {
+	g.countDown.Store(seconds)
+}
+
+// CountDown 該房間玩家叫/出牌時間(秒)
+func (g *Game) CountDown() uint32 {
+	return g.countDown.Load()
+}
+
+// startTurnTimer 開始新的輪次計時, 前一個輪次計時自動失效, 時間到時在遊戲鎖(mu)內執行 onExpire
+// 輪到機器人座位時,另外在 robotThinking 後由機器人動作
+func (g *Game) startTurnTimer(turn *pendingTurn) {
+	seq := g.turnSeq.Add(1)
+	g.lastTurn.Store(turn)
+	g.afterTurn(seq, time.Duration(g.CountDown())*time.Second+turnGrace, func() {
+		slog.Debug("turnTimer", slog.String("FYI", fmt.Sprintf("%s 時間到,伺服器自動執行", CbSeat(turn.seat))))
+		turn.onExpire()
+	})
+
+	if robot := g.roomManager.Robot(turn.seat); robot != nil && turn.onRobot != nil {
+		g.afterTurn(seq, robotThinking, func() {
+			turn.onRobot(robot)
+		})
+	}
+}
+
+// afterTurn 經過d後在遊戲鎖(mu)內執行 f, 輪次(seq)已經改變(玩家已動作或已開始新的計時), 或暫停中(恢復時會重新計時)則不執行
+func (g *Game) afterTurn(seq uint64, d time.Duration, f func()) {
+	time.AfterFunc(d, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if seq != g.turnSeq.Load() || g.paused.Load() > 0 {
+			return
+		}
+		f()
+	})
+}
+
+// afterDeal 經過d後在遊戲鎖(mu)內執行 f, 等待期間已開始下一局(牌號計數dealt已改變)則不執行; 用於結算與發牌的延遲, 延遲期間不持有遊戲鎖
+func (g *Game) afterDeal(dealt uint32, d time.Duration, f func()) {
+	time.AfterFunc(d, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if dealt != g.boardNumber {
+			return
+		}
+		f()
+	})
+}
+
+// stopTurnTimer 取消目前輪次計時
+func (g *Game) stopTurnTimer() {
+	g.turnSeq.Add(1)
+	g.lastTurn.Store(nil)
+}
+
+// pauseTurnTimer 暫停輪次計時(有玩家斷線保留座位), 可重複呼叫,每次暫停需對應一次 resumeTurnTimer
+func (g *Game) pauseTurnTimer() {
+	g.paused.Add(1)
+	g.turnSeq.Add(1)
+}
+
+// resumeTurnTimer 恢復輪次計時, 所有暫停都恢復後,目前輪次重新開始計時
+func (g *Game) resumeTurnTimer() {
+	if g.paused.Add(-1) > 0 {
+		return
+	}
+	if turn := g.lastTurn.Load(); turn != nil {
+		g.startTurnTimer(turn)
+	}
+}
+
+// autoUser 時間到時,代替座位(seat)玩家執行動作的 RoomUser
+func (g *Game) autoUser(seat uint8) *RoomUser {
+	_, name, _, _, _ := g.roomManager.FindPlayer(seat)
+	return &RoomUser{
+		auto:  true,
+		Zone8: seat,
+		PlayingUser: &pb.PlayingUser{
+			Name: name,
+			Zone: uint32(seat),
+		},
+	}
+}
+
+// startBidTimer 競叫計時, 時間到 bidder 自動PASS
+func (g *Game) startBidTimer(bidder uint8) {
+	bid := func(bid8 uint8) {
+		u := g.autoUser(bidder)
+		u.Bid8, u.Bid = bid8, uint32(bid8)
+		g.notyBid(u)
+	}
+	g.startTurnTimer(&pendingTurn{
+		seat: bidder,
+		onExpire: func() {
+			bid(g.engine.passBid())
+		},
+		onRobot: func(robot Robot) {
+			bid(g.robotBid(robot, bidder))
+		},
+	})
+}
+
+// startPlayTimer 出牌計時(依出牌通知 notice), 時間到自動打出 notice.TimeoutCardValue
+// 注意: notice.Seat 為實際出牌者(莊打夢時為莊家), 被打出牌的座位是 engine 目前的出牌者
+func (g *Game) startPlayTimer(notice *cb.PlayNotice) {
+	var (
+		playSeat = g.engine.currentPlay
+		realSeat = uint8(notice.Seat)
+		card     = uint8(notice.TimeoutCardValue)
+		hitting  = notice.NumOfCardPlayHitting
+	)
+	//注意: 自動出牌的點擊數只供比對,回合位置以伺服器計數(countingInPlayCard)為準
+	play := func(card uint8) {
+		u := g.autoUser(realSeat)
+		u.PlaySeat8, u.Play8 = playSeat, card
+		u.PlaySeat, u.Play = uint32(playSeat), uint32(card)
+		u.NumOfCardPlayHitting = hitting
+
+		if g.Phase() == PhaseOpeningLead { /*首引*/
+			g.firstLead(u)
+			return
+		}
+		g.cardPlayClick(u)
+	}
+	g.startTurnTimer(&pendingTurn{
+		seat: realSeat,
+		onExpire: func() {
+			play(card)
+		},
+		onRobot: func(robot Robot) {
+			play(g.robotPlay(robot, realSeat, playSeat))
+		},
+	})
+}
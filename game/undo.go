@@ -0,0 +1,373 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/moszorn/pb"
+	"github.com/moszorn/pb/cb"
+)
+
+// 撤回(undo): 玩家在下一家動作前請求撤回自己最後一個叫品, 或最後打出的牌(莊家可撤回夢家的牌),
+// 請求後暫停叫/出牌與計時, 等待對手回覆(出牌中夢家不回覆), 機器人對手一律同意;
+// 對手都同意時還原競叫紀錄或桌面,持牌與吃墩, 重新送出各連線的遊戲桌狀態與輪次通知, 任一對手拒絕時繼續.
+// 首引不能撤回: 首引後夢家已向所有人攤牌, 遊戲階段也不會從出牌回到首引(PhaseOpeningLead).
+// 房間可以關閉撤回(比賽用)
+
+// UndoKind 撤回的動作
+type UndoKind uint8
+
+const (
+	UndoBid  UndoKind = iota //撤回叫品
+	UndoPlay                 //撤回出牌
+)
+
+func (k UndoKind) String() string {
+	switch k {
+	case UndoBid:
+		return "Bid"
+	case UndoPlay:
+		return "Play"
+	}
+	return fmt.Sprintf("UndoKind(%d)", uint8(k))
+}
+
+// UndoStatus 撤回請求狀態
+type UndoStatus uint8
+
+const (
+	UndoPending  UndoStatus = iota //等待對手回覆
+	UndoAccepted                   //對手都同意,已撤回
+	UndoRejected                   //對手拒絕,繼續
+)
+
+func (s UndoStatus) String() string {
+	switch s {
+	case UndoPending:
+		return "Pending"
+	case UndoAccepted:
+		return "Accepted"
+	case UndoRejected:
+		return "Rejected"
+	}
+	return fmt.Sprintf("UndoStatus(%d)", uint8(s))
+}
+
+type (
+	// UndoAction 可以撤回的最後動作
+	UndoAction struct {
+		Kind        UndoKind `json:"kind"`
+		KindString  string   `json:"kindString"`
+		Seat        uint8    `json:"seat"`   //叫牌或被打出牌的座位
+		Player      uint8    `json:"player"` //可以請求撤回的玩家座位(莊打夢時為莊家)
+		Value       uint8    `json:"value"`  //撤回的叫品或牌
+		ValueString string   `json:"valueString"`
+	}
+
+	// UndoNotice 撤回請求與回覆狀態
+	UndoNotice struct {
+		UndoAction
+		Status       UndoStatus `json:"status"`
+		StatusString string     `json:"statusString"`
+		Awaiting     []uint8    `json:"awaiting"` //尚未同意的對手座位(CbSeat)
+		By           uint8      `json:"by"`       //這次動作的座位(請求,同意或拒絕)
+	}
+
+	// pendingUndo 待回覆的撤回請求
+	pendingUndo struct {
+		UndoAction
+		replies map[uint8]bool //須回覆的對手座位->是否已同意
+	}
+)
+
+// SetUndo 設定該房間是否允許撤回, 預設允許
+func (g *Game) SetUndo(enabled bool) {
+	g.undoDisabled.Store(!enabled)
+}
+
+// UndoEnabled 該房間是否允許撤回
+func (g *Game) UndoEnabled() bool {
+	return !g.undoDisabled.Load()
+}
+
+// GamePrivateUndo 玩家請求撤回自己最後一個叫品或出牌, 首引不能撤回
+func (g *Game) GamePrivateUndo(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.requestUndo(viewerSeat(user.NsConn)); err != nil {
+		g.log.Wrn("GamePrivateUndo", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// requestUndo 座位(seat)請求撤回, 必須在遊戲鎖(mu)內呼叫
+func (g *Game) requestUndo(seat uint8) error {
+	if !g.UndoEnabled() {
+		return ErrUndoDisabled
+	}
+	if seat == valueNotSet {
+		return ErrUserNotInPlay
+	}
+	if g.undo.Load() != nil {
+		return ErrUndoPending
+	}
+	if g.claim.Load() != nil {
+		return ErrClaimPending
+	}
+
+	action, err := g.lastAction()
+	if err != nil {
+		return err
+	}
+	if action.Player != seat {
+		return fmt.Errorf("%w: 最後的動作是 %s %s", ErrUndoNone, CbSeat(action.Seat), action.ValueString)
+	}
+
+	undo := &pendingUndo{
+		UndoAction: action,
+		replies:    make(map[uint8]bool, 2),
+	}
+	//對手回覆, 出牌中夢家不回覆
+	for _, opponent := range playerSeats {
+		if !isOpponent(seat, opponent) || (action.Kind == UndoPlay && opponent == uint8(g.Dummy)) {
+			continue
+		}
+		undo.replies[opponent] = false
+	}
+
+	g.undo.Store(undo)
+	g.pauseTurnTimer()
+	slog.Info("GamePrivateUndo", slog.String(g.name, fmt.Sprintf("%s 請求撤回 %s %s", CbSeat(seat), CbSeat(action.Seat), action.ValueString)))
+	g.sendUndoNotice(undo, UndoPending, seat)
+
+	//機器人對手一律同意
+	for opponent := range undo.replies {
+		if g.roomManager.Robot(opponent) == nil {
+			continue
+		}
+		if err = g.replyUndo(opponent, true); err != nil || g.undo.Load() != undo {
+			break
+		}
+	}
+	return nil
+}
+
+// GamePrivateUndoAccept 對手同意撤回
+func (g *Game) GamePrivateUndoAccept(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.replyUndo(viewerSeat(user.NsConn), true); err != nil {
+		g.log.Wrn("GamePrivateUndoAccept", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// GamePrivateUndoReject 對手拒絕撤回, 繼續叫/出牌
+func (g *Game) GamePrivateUndoReject(user *RoomUser) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.replyUndo(viewerSeat(user.NsConn), false); err != nil {
+		g.log.Wrn("GamePrivateUndoReject", slog.String(".", err.Error()))
+		g.sendUserError(user, err)
+	}
+}
+
+// replyUndo 對手(seat)回覆撤回請求, 必須在遊戲鎖(mu)內呼叫
+func (g *Game) replyUndo(seat uint8, accept bool) error {
+	undo := g.undo.Load()
+	if undo == nil {
+		return ErrUndoNone
+	}
+	if _, ok := undo.replies[seat]; !ok {
+		return ErrUndoReply
+	}
+
+	if !accept {
+		g.undo.Store(nil)
+		g.sendUndoNotice(undo, UndoRejected, seat)
+		g.resumeTurnTimer()
+		return nil
+	}
+
+	undo.replies[seat] = true
+	for _, accepted := range undo.replies {
+		if !accepted {
+			g.sendUndoNotice(undo, UndoPending, seat)
+			return nil
+		}
+	}
+
+	//對手都同意, 取消目前輪次並結束暫停後撤回, 撤回的座位重新開始計時
+	g.undo.Store(nil)
+	g.stopTurnTimer()
+	g.paused.Add(-1)
+	g.sendUndoNotice(undo, UndoAccepted, seat)
+
+	//一被同意,就停止四家正在執行的gauge
+	if err := g.roomManager.SendPayloadToPlayers(ClnRoomEvents.GameOP, &pb.OP{Type: pb.SceneType_game_gauge_stop}, pb.SceneType_game); err != nil {
+		g.log.Wrn("斷線", slog.String(".", err.Error()))
+	}
+
+	switch undo.Kind {
+	case UndoBid:
+		g.undoBid()
+	case UndoPlay:
+		g.undoPlay(undo.Seat, undo.Value)
+	}
+	return nil
+}
+
+// lastAction 目前可以撤回的最後動作: 競叫中為最後一個叫品, 出牌中為最後打出的牌(首引除外, 回傳 ErrUndoOpeningLead)
+func (g *Game) lastAction() (action UndoAction, err error) {
+	switch g.Phase() {
+	case PhaseBidding:
+		history := g.engine.bidHistory.h
+		if len(history) == 0 {
+			return action, ErrUndoNone
+		}
+		last := history[len(history)-1]
+		action = UndoAction{
+			Kind:        UndoBid,
+			Seat:        last.who(),
+			Player:      last.who(),
+			Value:       last.bid(),
+			ValueString: last.value.String(),
+		}
+
+	case PhasePlaying:
+		if g.countingInPlayCard < 2 {
+			return action, ErrUndoOpeningLead
+		}
+		action.Kind = UndoPlay
+		if g.countingInPlayCard%4 == 0 {
+			//最後一張牌完成了一墩, 是該墩首打的上一家
+			t := g.engine.ledger.last()
+			action.Seat = playerSeats[(seatIndex(uint8(t.lead))+3)%4]
+			action.Value = t.cards[seatIndex(action.Seat)]
+		} else {
+			//最後出牌者是目前出牌者的上一家
+			action.Seat = playerSeats[(seatIndex(g.engine.currentPlay)+3)%4]
+			action.Value = g.playCardRecord(action.Seat)
+		}
+		action.Player, _ = g.playTurn(action.Seat)
+		action.ValueString = CbCard(action.Value).String()
+
+	default:
+		return action, fmt.Errorf("%w: %s", ErrUndoNone, g.Phase())
+	}
+	action.KindString = action.Kind.String()
+	return action, nil
+}
+
+// undoBid 撤回最後一個叫品, 叫者重新叫牌
+func (g *Game) undoBid() {
+	undone, history, nextLimitBidding, db1, db2 := g.engine.UndoBid()
+	if undone == nil {
+		return
+	}
+	bidder := undone.who()
+	g.SeatShift(bidder)
+	g.refreshTable()
+
+	notyBid := cb.NotyBid{
+		Bidder:  uint32(bidder),
+		Double1: uint32(db1.value),
+		Double2: uint32(db2.value),
+	}
+	if len(history) == 0 {
+		//撤回首叫, 與競叫開始相同 (參考 SendGameStart)
+		notyBid.BidOrder = &cb.BidOrder{Headers: g.GetBidOrder()}
+		notyBid.BidStart = uint32(BidYet)
+		notyBid.Double1, notyBid.Double2 = uint32(Db1), uint32(Db2)
+	} else {
+		last := history[len(history)-1]
+		_, name, _, _, _ := g.roomManager.FindPlayer(last.who())
+		notyBid.BidStart = uint32(nextLimitBidding)
+		notyBid.LastBidderName = fmt.Sprintf("%s-%s", CbSeat(last.who()), name)
+		notyBid.LastBid = last.value.String()
+	}
+
+	switch {
+	case db1.isOn:
+		notyBid.Btn = cb.NotyBid_db
+	case db2.isOn:
+		notyBid.Btn = cb.NotyBid_dbx2
+	default:
+		notyBid.Btn = cb.NotyBid_disable_all
+	}
+
+	g.sendNotyBid(&notyBid, history, bidder)
+}
+
+// undoPlay 撤回座位(seat)最後打出的牌(card), 該座位重新出牌
+func (g *Game) undoPlay(seat, card uint8) {
+	trickUndone := g.countingInPlayCard%4 == 0
+	if trickUndone {
+		//撤回一墩的最後一張牌, 還原該墩桌面上的牌與回合出牌範圍
+		t := g.engine.ledger.undo()
+		g.eastCard, g.southCard, g.westCard, g.northCard = t.cards[0], t.cards[1], t.cards[2], t.cards[3]
+		g.SetRoundAvailableRange(t.cards[seatIndex(uint8(t.lead))])
+	}
+	g.savePlayCardRecord(seat, uint8(BaseCover))
+	g.countingInPlayCard--
+
+	//牌放回手上原來的位置(持牌與發牌時的排序一致)
+	hand := g.deckInPlay[seat]
+	for i, c := range g.Deck[&playerSeats[seatIndex(seat)]] {
+		if *c == card {
+			hand[i] = card
+			break
+		}
+	}
+
+	g.SeatShift(seat)
+	g.setEnginePlayer(seat)
+	g.refreshTable()
+	if trickUndone {
+		g.sendTrickTally()
+	}
+
+	notice := &cb.PlayNotice{
+		NumOfCardPlayHitting: uint32(g.countingInPlayCard) + uint32(1),
+		Dummy:                uint32(g.Dummy),
+	}
+	realSeat, isAgent := g.playTurn(seat)
+	notice.IsPlayAgent = isAgent
+	notice.Seat = uint32(realSeat)
+	notice.CardMinValue, notice.CardMaxValue, notice.TimeoutCardValue, _ = g.AvailablePlayerPlayRange(seat, g.countingInPlayCard%4 == 0)
+	g.nextPlayNotification(notice, realSeat)
+}
+
+// clearUndo 新的一局開始時取消尚未回覆的撤回請求與其暫停計時
+func (g *Game) clearUndo() {
+	if g.undo.Swap(nil) != nil {
+		g.paused.Add(-1)
+	}
+}
+
+// sendUndoNotice 廣播撤回請求狀態給房間所有人(玩家,觀眾)
+func (g *Game) sendUndoNotice(undo *pendingUndo, status UndoStatus, by uint8) {
+	notice := UndoNotice{
+		UndoAction:   undo.UndoAction,
+		Status:       status,
+		StatusString: status.String(),
+		Awaiting:     make([]uint8, 0, len(undo.replies)),
+		By:           by,
+	}
+	for _, seat := range playerSeats {
+		if accepted, ok := undo.replies[seat]; ok && !accepted && status == UndoPending {
+			notice.Awaiting = append(notice.Awaiting, seat)
+		}
+	}
+
+	body, err := json.Marshal(notice)
+	if err != nil {
+		g.log.Wrn("sendUndoNotice", slog.String(".", err.Error()))
+		return
+	}
+	g.roomManager.BroadcastBytes(nil, ClnRoomEvents.GameUndo, g.name, body)
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// biddingGame 南家首叫(bid)後輪到西家的競叫
+func biddingGame(t *testing.T, bid CbBid) *Game {
+	t.Helper()
+	g := testGame(t, "room")
+	g.phase.Store(uint32(PhaseBidding))
+	if _, err := g.engine.bidHistory.Bid(uint8(south), uint8(bid)); err != nil {
+		t.Fatal(err)
+	}
+	g.engine.currentPlay = uint8(west)
+	return g
+}
+
+func TestUndoBidAccepted(t *testing.T) {
+	g := biddingGame(t, H1)
+	if err := g.requestUndo(uint8(south)); err != nil {
+		t.Fatal(err)
+	}
+	if g.undo.Load() == nil || g.paused.Load() != 1 {
+		t.Fatalf("請求撤回後 undo %v 暫停 %d, want 等待回覆, 1", g.undo.Load(), g.paused.Load())
+	}
+
+	//夥伴不能回覆, 一位對手同意仍等待另一位
+	if err := g.replyUndo(uint8(north), true); !errors.Is(err, ErrUndoReply) {
+		t.Errorf("夥伴回覆 error = %v, want %v", err, ErrUndoReply)
+	}
+	if err := g.replyUndo(uint8(west), true); err != nil || g.undo.Load() == nil {
+		t.Fatalf("西家同意後 error = %v, undo %v, want 等待東家", err, g.undo.Load())
+	}
+
+	if err := g.replyUndo(uint8(east), true); err != nil {
+		t.Fatal(err)
+	}
+	if g.undo.Load() != nil || g.paused.Load() != 0 {
+		t.Errorf("撤回後 undo %v 暫停 %d, want nil, 0", g.undo.Load(), g.paused.Load())
+	}
+	if len(g.engine.bidHistory.h) != 0 || g.engine.currentPlay != uint8(south) {
+		t.Errorf("撤回後叫牌紀錄 %d 輪到 %s, want 0, %s", len(g.engine.bidHistory.h), CbSeat(g.engine.currentPlay), south)
+	}
+}
+
+func TestUndoBidRejected(t *testing.T) {
+	g := biddingGame(t, H1)
+	if err := g.requestUndo(uint8(south)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.requestUndo(uint8(south)); !errors.Is(err, ErrUndoPending) {
+		t.Errorf("重複請求 error = %v, want %v", err, ErrUndoPending)
+	}
+	if err := g.replyUndo(uint8(west), true); err != nil {
+		t.Fatal(err)
+	}
+
+	//任一對手拒絕就繼續, 叫牌紀錄不變
+	if err := g.replyUndo(uint8(east), false); err != nil {
+		t.Fatal(err)
+	}
+	if g.undo.Load() != nil || g.paused.Load() != 0 {
+		t.Errorf("拒絕後 undo %v 暫停 %d, want nil, 0", g.undo.Load(), g.paused.Load())
+	}
+	if len(g.engine.bidHistory.h) != 1 || g.engine.currentPlay != uint8(west) {
+		t.Errorf("拒絕後叫牌紀錄 %d 輪到 %s, want 1, %s", len(g.engine.bidHistory.h), CbSeat(g.engine.currentPlay), west)
+	}
+	if err := g.replyUndo(uint8(east), true); !errors.Is(err, ErrUndoNone) {
+		t.Errorf("沒有請求時回覆 error = %v, want %v", err, ErrUndoNone)
+	}
+}
+
+func TestUndoRequestRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(g *Game)
+		seat  CbSeat
+		want  error
+	}{
+		{"房間不允許撤回", func(g *Game) { g.SetUndo(false) }, south, ErrUndoDisabled},
+		{"不在座位上", func(g *Game) {}, CbSeat(valueNotSet), ErrUserNotInPlay},
+		{"撤回別人的叫品", func(g *Game) {}, west, ErrUndoNone},
+		{"攤牌宣告等待回覆中", func(g *Game) { g.claim.Store(&pendingClaim{}) }, south, ErrClaimPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := biddingGame(t, H1)
+			tt.setup(g)
+			if err := g.requestUndo(uint8(tt.seat)); !errors.Is(err, tt.want) {
+				t.Errorf("requestUndo(%s) error = %v, want %v", tt.seat, err, tt.want)
+			}
+			if g.undo.Load() != nil || g.paused.Load() != 0 {
+				t.Errorf("請求失敗後 undo %v 暫停 %d, want nil, 0", g.undo.Load(), g.paused.Load())
+			}
+		})
+	}
+}
+
+func TestUndoRobotAccept(t *testing.T) {
+	//一位對手是機器人時, 仍等待真人對手回覆
+	g := biddingGame(t, H1)
+	if err := g.roomManager.AddRobot(uint8(west), NewRuleRobot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.requestUndo(uint8(south)); err != nil {
+		t.Fatal(err)
+	}
+	undo := g.undo.Load()
+	if undo == nil || !undo.replies[uint8(west)] || undo.replies[uint8(east)] {
+		t.Fatalf("機器人西家回覆 %v, want 西家同意,等待東家", undo)
+	}
+
+	//對手都是機器人, 立即撤回
+	g = biddingGame(t, H1)
+	for _, seat := range []CbSeat{west, east} {
+		if err := g.roomManager.AddRobot(uint8(seat), NewRuleRobot()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.requestUndo(uint8(south)); err != nil {
+		t.Fatal(err)
+	}
+	if g.undo.Load() != nil || len(g.engine.bidHistory.h) != 0 || g.paused.Load() != 0 {
+		t.Errorf("機器人同意後 undo %v 叫牌紀錄 %d 暫停 %d, want nil, 0, 0", g.undo.Load(), len(g.engine.bidHistory.h), g.paused.Load())
+	}
+}
+
+func TestUndoPlay(t *testing.T) {
+	g := playingGame(t)
+	if err := g.cardPlayClick(autoPlay(west, west, heart5)); err != nil {
+		t.Fatal(err)
+	}
+	//首引不能撤回
+	if err := g.requestUndo(uint8(west)); !errors.Is(err, ErrUndoOpeningLead) {
+		t.Errorf("撤回首引 error = %v, want %v", err, ErrUndoOpeningLead)
+	}
+
+	//莊家撤回夢家的牌, 由兩位防家回覆
+	if err := g.cardPlayClick(autoPlay(south, north, heartAce)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.requestUndo(uint8(north)); !errors.Is(err, ErrUndoNone) {
+		t.Errorf("夢家請求撤回 error = %v, want %v", err, ErrUndoNone)
+	}
+	if err := g.requestUndo(uint8(south)); err != nil {
+		t.Fatal(err)
+	}
+	for _, seat := range []CbSeat{west, east} {
+		if err := g.replyUndo(uint8(seat), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.countingInPlayCard != 1 || g.engine.currentPlay != uint8(north) {
+		t.Errorf("撤回後出牌數 %d 輪到 %s, want 1, %s", g.countingInPlayCard, CbSeat(g.engine.currentPlay), north)
+	}
+	if !slices.Contains(g.deckInPlay[uint8(north)][:], heartAce) {
+		t.Errorf("撤回的 %s 沒有回到夢家手上 %v", CbCard(heartAce), g.deckInPlay[uint8(north)])
+	}
+}
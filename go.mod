@@ -2,16 +2,19 @@ module project
 
 go 1.21.3
 
+require (
+	github.com/kataras/neffos v0.0.22
+	google.golang.org/protobuf v1.33.0
+)
+
 require (
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
 	github.com/gobwas/ws v1.3.0 // indirect
 	github.com/iris-contrib/go.uuid v2.0.0+incompatible // indirect
-	github.com/kataras/neffos v0.0.22 // indirect
 	github.com/lmittmann/tint v1.0.2 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/moszorn/pb v0.0.0-20240312112202-7026807382e7 // indirect
 	github.com/moszorn/utils v0.0.0-20240121150744-db0c1c34b6c2 // indirect
 	golang.org/x/sys v0.6.0 // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
 )
@@ -3,6 +3,8 @@ package project
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"os"
 
 	"github.com/moszorn/pb/cb"
 	llg "github.com/moszorn/utils/log"
@@ -36,6 +38,8 @@ type (
 		LobbySub(*skf.NSConn)
 		RoomAdd(conn *skf.NSConn, roomName string)
 		RoomSub(nsConn *skf.NSConn, roomName string)
+		RoomOpen(roomName string)
+		RoomClose(roomName string)
 	}
 
 	// LobbyService 代表 Lobby Space , request的入口介面
@@ -50,15 +54,29 @@ type (
 
 	// RoomService 代表 Room Space, request的入口介面
 	RoomService interface {
+		Credential(*skf.NSConn, skf.Message) error
 		UserJoin(*skf.NSConn, skf.Message) error
 		UserLeave(*skf.NSConn, skf.Message) error
 		PlayerJoin(*skf.NSConn, skf.Message) error
 		PlayerLeave(*skf.NSConn, skf.Message) error
+		AddRobot(*skf.NSConn, skf.Message) error
+		RemoveRobot(*skf.NSConn, skf.Message) error
+		ConventionCard(*skf.NSConn, skf.Message) error
 		Chat(*skf.NSConn, skf.Message) error
 
 		GamePrivateNotyBid(*skf.NSConn, skf.Message) error
+		GamePrivateAlertBid(*skf.NSConn, skf.Message) error
 		GamePrivateCardPlayClick(*skf.NSConn, skf.Message) error
 		GamePrivateCardHover(*skf.NSConn, skf.Message) error
+		GamePrivateHandLin(*skf.NSConn, skf.Message) error
+		GamePrivateClaim(*skf.NSConn, skf.Message) error
+		GamePrivateClaimAccept(*skf.NSConn, skf.Message) error
+		GamePrivateClaimReject(*skf.NSConn, skf.Message) error
+		GamePrivateUndo(*skf.NSConn, skf.Message) error
+		GamePrivateUndoAccept(*skf.NSConn, skf.Message) error
+		GamePrivateUndoReject(*skf.NSConn, skf.Message) error
+		GamePrivateAlertAsk(*skf.NSConn, skf.Message) error
+		GamePrivateAlertAnswer(*skf.NSConn, skf.Message) error
 		GamePrivateFirstLead(*skf.NSConn, skf.Message) error
 
 		_OnNamespaceConnected(*skf.NSConn, skf.Message) error
@@ -76,13 +94,13 @@ func (spaceHandlers SpaceManager) spaceHandler(spaceName string) map[string]skf.
 }
 
 var (
-	//未來 房間名稱改撈db
+	// 預設房間, 沒有房間設定檔(RoomConfigPath)時使用
 	cbGameRooms = []string{
 		"room0x0", "room0x1", "room0x2", "room0x3", "room0x4", "room0x5", "room0x6", "room0x7",
 		"room1x0", "room1x1", "room1x2", "room1x3", "room1x4", "room1x5", "room1x6", "room1x7",
 		"room2x0", "room2x1", "room2x2", "room2x3", "room2x4", "room2x5", "room2x6", "room2x7",
 		"room3x0", "room3x1", "room3x2", "room3x3", "room3x4", "room3x5", "room3x6", "room3x7",
-		"room4x0", "room3x1", "room4x2", "room4x3", "room4x4", "room4x5", "room4x6", "room4x7",
+		"room4x0", "room4x1", "room4x2", "room4x3", "room4x4", "room4x5", "room4x6", "room4x7",
 		"room5x0", "room5x1", "room5x2", "room5x3", "room5x4", "room5x5", "room5x6", "room5x7",
 		"room6x0", "room6x1", "room6x2", "room6x3", "room6x4", "room6x5", "room6x6", "room6x7",
 	}
@@ -92,20 +110,30 @@ var (
 	lobbySpaceService LobbyService   // 大廳
 	spaceManager      SpaceHandler   // 代表可取得eventsHandler
 	Namespace         skf.Namespaces // 全域Namespace用於 skf初始
+	HandService       http.Handler   // 牌局紀錄匯出(HTTP), 由main掛載於 HandServicePath
+	AdminService      http.Handler   // 管理服務(HTTP), 由main掛載於 AdminServicePath
+	Rooms             *RoomRegistry  // 執行中建立,設定與關閉房間, 由管理服務(AdminService)操作
 )
 
 // initNamespace 初始化Namespace (全域變數)
 func initNamespace(pid context.Context) {
 
+	// 房間設定
+	configs, err := loadRoomConfigs(RoomConfigPath)
+	if err != nil {
+		slog.Error("initNamespace", slog.String("房間設定檔錯誤,使用預設房間", err.Error()))
+		configs = defaultRoomConfigs()
+	}
+
 	// 房間與遊戲桌
 	rooms := make(map[string]*game.Game)
 
 	// key:桌名
 	tables := make(map[string]*cb.LobbyTable)
 	// 設定桌名為鍵
-	for idx := range cbGameRooms {
-		rooms[cbGameRooms[idx]] = nil
-		tables[cbGameRooms[idx]] = nil
+	for idx := range configs {
+		rooms[configs[idx].Name] = nil
+		tables[configs[idx].Name] = nil
 	}
 
 	mylog := llg.NewMyLog("app.log", slog.LevelDebug, llg.FileLog)
@@ -114,6 +142,34 @@ func initNamespace(pid context.Context) {
 
 	roomSpaceService = NewRoomSpaceService(pid, &rooms, counterService, mylog)
 
+	for _, config := range configs {
+		if err = rooms[config.Name].Configure(config); err != nil {
+			slog.Error("initNamespace", slog.String(config.Name, err.Error()))
+		}
+	}
+
+	// 牌局紀錄, 本地以檔案儲存
+	var handStore game.HandStore
+	if store, err := game.NewFileHandStore("hands"); err != nil {
+		slog.Warn("initNamespace", slog.String("牌局紀錄無法儲存", err.Error()))
+	} else {
+		handStore = store
+		for _, room := range rooms {
+			room.SetHandStore(handStore)
+		}
+	}
+
+	Rooms = newRoomRegistry(pid, rooms, counterService, mylog, handStore)
+
+	// 牌局紀錄匯出(HTTP)
+	HandService = newHandService(rooms)
+
+	// 管理服務(HTTP), 管理憑證由環境變數設定
+	if os.Getenv(AdminTokenEnv) == "" {
+		slog.Warn("initNamespace", slog.String("管理服務未開放", AdminTokenEnv+" 未設定"))
+	}
+	AdminService = newAdminService(Rooms, os.Getenv(AdminTokenEnv))
+
 	lobbySpaceService = NewLobbySpaceService()
 
 	spaceManager = newSpaceManager(roomSpaceService, lobbySpaceService)
@@ -142,10 +198,27 @@ func newSpaceManager(rooms RoomService, lobby LobbyService) SpaceManager {
 		game.SrvRoomEvents.TablePrivateOnLeave: rooms.PlayerLeave,
 		game.SrvRoomEvents.TableOnChat:         rooms.Chat,
 
+		game.SrvRoomEvents.UserPrivateCredential: rooms.Credential,
+
+		game.SrvRoomEvents.TablePrivateAddRobot:    rooms.AddRobot,
+		game.SrvRoomEvents.TablePrivateRemoveRobot: rooms.RemoveRobot,
+
+		game.SrvRoomEvents.TablePrivateConventionCard: rooms.ConventionCard,
+
 		game.SrvRoomEvents.GamePrivateNotyBid:       rooms.GamePrivateNotyBid,
+		game.SrvRoomEvents.GamePrivateAlertBid:      rooms.GamePrivateAlertBid,
 		game.SrvRoomEvents.GamePrivateFirstLead:     rooms.GamePrivateFirstLead,
 		game.SrvRoomEvents.GamePrivateCardPlayClick: rooms.GamePrivateCardPlayClick,
 		game.SrvRoomEvents.GamePrivateCardHover:     rooms.GamePrivateCardHover,
+		game.SrvRoomEvents.GamePrivateHandLin:       rooms.GamePrivateHandLin,
+		game.SrvRoomEvents.GamePrivateClaim:         rooms.GamePrivateClaim,
+		game.SrvRoomEvents.GamePrivateClaimAccept:   rooms.GamePrivateClaimAccept,
+		game.SrvRoomEvents.GamePrivateClaimReject:   rooms.GamePrivateClaimReject,
+		game.SrvRoomEvents.GamePrivateUndo:          rooms.GamePrivateUndo,
+		game.SrvRoomEvents.GamePrivateUndoAccept:    rooms.GamePrivateUndoAccept,
+		game.SrvRoomEvents.GamePrivateUndoReject:    rooms.GamePrivateUndoReject,
+		game.SrvRoomEvents.GamePrivateAlertAsk:      rooms.GamePrivateAlertAsk,
+		game.SrvRoomEvents.GamePrivateAlertAnswer:   rooms.GamePrivateAlertAnswer,
 
 		//game.SrvRoomEvents.GameBid:       rooms.competitiveBidding,
 		//game.SrvRoomEvents.GamePlay:      rooms.competitivePlaying,
@@ -0,0 +1,157 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+
+	utilog "github.com/moszorn/utils/log"
+
+	"project/game"
+)
+
+// RoomConfigPath 房間設定檔(json陣列, 每個元素為 game.RoomConfig), 啟動時載入; 檔案不存在時使用預設房間 cbGameRooms
+const RoomConfigPath = "rooms.json"
+
+// roomsMu 保護 AllRoom, 執行中由 RoomRegistry 新增或關閉房間
+var roomsMu sync.RWMutex
+
+// RoomRegistry 執行中建立,設定與關閉房間, 房間變動即時反映到大廳房間資訊(cb.LobbyNumOfs)
+type RoomRegistry struct {
+	pid     context.Context
+	rooms   AllRoom
+	counter CounterService
+	log     *utilog.MyLog
+	store   game.HandStore //新房間的牌局紀錄儲存, nil表示不儲存
+	nextId  int32          //新房間的Id
+}
+
+func newRoomRegistry(pid context.Context, rooms AllRoom, counter CounterService, lg *utilog.MyLog, store game.HandStore) *RoomRegistry {
+	return &RoomRegistry{
+		pid:     pid,
+		rooms:   rooms,
+		counter: counter,
+		log:     lg,
+		store:   store,
+		nextId:  int32(len(rooms)) + 1,
+	}
+}
+
+// loadRoomConfigs 載入房間設定檔, 檔案不存在時回傳預設房間設定
+func loadRoomConfigs(path string) ([]game.RoomConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultRoomConfigs(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []game.RoomConfig
+	if err = json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("%w: %s", game.ErrRoomConfig, err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("%w: %s 沒有任何房間", game.ErrRoomConfig, path)
+	}
+	names := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if config.Name == "" || names[config.Name] {
+			return nil, fmt.Errorf("%w: 房間名稱空白或重複(%s)", game.ErrRoomConfig, config.Name)
+		}
+		if _, err = game.ParseSessionScoring(config.Scoring); err != nil {
+			return nil, err
+		}
+		names[config.Name] = true
+	}
+	return configs, nil
+}
+
+// defaultRoomConfigs 預設房間(cbGameRooms)的預設設定
+func defaultRoomConfigs() []game.RoomConfig {
+	configs := make([]game.RoomConfig, 0, len(cbGameRooms))
+	for _, name := range cbGameRooms {
+		configs = append(configs, game.RoomConfig{Name: name})
+	}
+	return configs
+}
+
+// Create 依設定(config)建立新房間
+func (r *RoomRegistry) Create(config game.RoomConfig) (*game.Game, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("%w: 房間名稱空白", game.ErrRoomConfig)
+	}
+	if _, err := game.ParseSessionScoring(config.Scoring); err != nil {
+		return nil, err
+	}
+
+	roomsMu.Lock()
+	if _, ok := r.rooms[config.Name]; ok {
+		roomsMu.Unlock()
+		return nil, fmt.Errorf("%w: %s", game.ErrRoomExists, config.Name)
+	}
+	g := game.CreateCBGame(r.log, r.pid, r.counter, config.Name, r.nextId)
+	r.nextId++
+	if err := g.Configure(config); err != nil {
+		roomsMu.Unlock()
+		g.Close()
+		return nil, err
+	}
+	if r.store != nil {
+		g.SetHandStore(r.store)
+	}
+	r.rooms[config.Name] = g
+	roomsMu.Unlock()
+
+	r.counter.RoomOpen(config.Name)
+	slog.Info("RoomRegistry", slog.String("新增房間", config.Name))
+	return g, nil
+}
+
+// Configure 變更房間設定, 房間以 config.Name 指定
+func (r *RoomRegistry) Configure(config game.RoomConfig) error {
+	g, err := r.rooms.room(config.Name)
+	if err != nil {
+		return err
+	}
+	return g.Configure(config)
+}
+
+// Close 關閉房間(name), 房間內還有使用者或在複式賽程,隊制賽中時無法關閉
+func (r *RoomRegistry) Close(name string) error {
+	roomsMu.Lock()
+	g, ok := r.rooms[name]
+	if !ok {
+		roomsMu.Unlock()
+		return BackendError(GeneralCode, "無此房間", nil)
+	}
+	if g.Occupied() || g.Session() != nil || g.TeamMatch() != nil {
+		roomsMu.Unlock()
+		return fmt.Errorf("%w: %s", game.ErrRoomBusy, name)
+	}
+	delete(r.rooms, name)
+	roomsMu.Unlock()
+
+	g.Close()
+	r.counter.RoomClose(name)
+	slog.Info("RoomRegistry", slog.String("關閉房間", name))
+	return nil
+}
+
+// Configs 所有房間目前的設定, 依房間名稱排序
+func (r *RoomRegistry) Configs() []game.RoomConfig {
+	roomsMu.RLock()
+	configs := make([]game.RoomConfig, 0, len(r.rooms))
+	for _, g := range r.rooms {
+		configs = append(configs, g.Config())
+	}
+	roomsMu.RUnlock()
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs
+}
@@ -0,0 +1,219 @@
+package project
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"project/game"
+)
+
+// AdminServicePath 管理服務路徑(HTTP), 請求必須帶 Authorization: Bearer {管理憑證}
+//
+//	GET    /admin/rooms              所有房間設定
+//	POST   /admin/rooms              建立房間(本文為 game.RoomConfig)
+//	GET    /admin/rooms/{房間}       房間設定
+//	PUT    /admin/rooms/{房間}       變更房間設定(本文為 game.RoomConfig, 名稱可省略)
+//	DELETE /admin/rooms/{房間}       關閉房間, 房間內還有使用者或在賽程中時回覆409
+//	POST   /admin/rooms/{房間}/deals 匯入PBN牌局(本文為PBN檔案), 之後依序以匯入牌局發牌
+//	DELETE /admin/rooms/{房間}/deals 清除待發的匯入牌局, 恢復洗牌
+//	POST   /admin/teammatches        建立隊制賽(本文為 TeamMatchCreate), 回覆每位玩家的座位通行碼
+const AdminServicePath = "/admin/"
+
+// AdminTokenEnv 管理憑證的環境變數, 未設定時管理服務拒絕所有請求
+const AdminTokenEnv = "CB_ADMIN_TOKEN"
+
+// adminService 管理員建立,設定,關閉房間, 匯入牌局與建立隊制賽
+type adminService struct {
+	registry *RoomRegistry
+	token    string
+}
+
+func newAdminService(registry *RoomRegistry, token string) http.Handler {
+	return &adminService{registry: registry, token: token}
+}
+
+// DealsImport 匯入牌局的結果
+type DealsImport struct {
+	Room     string `json:"room"`
+	Imported int    `json:"imported"` //這次匯入的牌局數
+	Pending  int    `json:"pending"`  //待發的匯入牌局數
+}
+
+// TeamMatchCreate 建立隊制賽的請求, 開室(Open)與閉室(Closed)為房間名稱
+type TeamMatchCreate struct {
+	Name   string         `json:"name"`
+	Seed   uint64         `json:"seed"`
+	Boards uint32         `json:"boards"`
+	Open   string         `json:"open"`
+	Closed string         `json:"closed"`
+	Home   game.MatchTeam `json:"home"`
+	Away   game.MatchTeam `json:"away"`
+}
+
+// TeamMatchPasses 建立隊制賽的結果, 座位通行碼由主辦者交給各玩家, 玩家上座前以房間憑證(UserPrivateCredential)出示
+type TeamMatchPasses struct {
+	Match  string            `json:"match"`
+	Passes map[string]string `json:"passes"` //玩家名稱:座位通行碼
+}
+
+func (s *adminService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, AdminServicePath), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "rooms":
+		s.roomList(w, r)
+	case len(parts) == 2 && parts[0] == "rooms":
+		s.room(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "rooms" && parts[2] == "deals":
+		s.deals(w, r, parts[1])
+	case len(parts) == 1 && parts[0] == "teammatches":
+		s.teamMatch(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorized 請求是否帶有管理憑證
+func (s *adminService) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// roomList 列出所有房間設定, 或建立房間
+func (s *adminService) roomList(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.registry.Configs())
+	case http.MethodPost:
+		var config game.RoomConfig
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		g, err := s.registry.Create(config)
+		if err != nil {
+			registryError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, g.Config())
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// room 房間(room)設定查詢,變更或關閉房間
+func (s *adminService) room(w http.ResponseWriter, r *http.Request, room string) {
+	g, err := s.registry.rooms.room(room)
+	if err != nil {
+		http.Error(w, "無此房間", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, g.Config())
+	case http.MethodPut:
+		var config game.RoomConfig
+		if err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if config.Name == "" {
+			config.Name = room
+		}
+		if config.Name != room {
+			http.Error(w, "房間名稱不可變更", http.StatusBadRequest)
+			return
+		}
+		if err = s.registry.Configure(config); err != nil {
+			registryError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, g.Config())
+	case http.MethodDelete:
+		if err = s.registry.Close(room); err != nil {
+			registryError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// registryError 房間建立,設定,關閉失敗的回覆: 房間已存在或忙碌中409, 其餘(設定錯誤)400
+func registryError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, game.ErrRoomExists) || errors.Is(err, game.ErrRoomBusy) {
+		status = http.StatusConflict
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// deals 房間(room)匯入或清除PBN牌局
+func (s *adminService) deals(w http.ResponseWriter, r *http.Request, room string) {
+	g, err := s.registry.rooms.room(room)
+	if err != nil {
+		http.Error(w, "無此房間", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		deals, err := game.ParsePBN(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(deals) == 0 {
+			http.Error(w, "PBN檔案沒有任何牌局(Deal標籤)", http.StatusBadRequest)
+			return
+		}
+		pending := g.PresetDeals(deals...)
+		slog.Info("adminService", slog.String(room, "匯入牌局"), slog.Int("匯入", len(deals)), slog.Int("待發", pending))
+		writeJSON(w, http.StatusOK, DealsImport{Room: room, Imported: len(deals), Pending: pending})
+	case http.MethodDelete:
+		g.ClearPresetDeals()
+		slog.Info("adminService", slog.String(room, "清除匯入牌局"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// teamMatch 建立隊制賽, 回覆座位通行碼
+func (s *adminService) teamMatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	var req TeamMatchCreate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m, err := s.registry.rooms.NewTeamMatch(req.Name, req.Seed, req.Boards, req.Open, req.Closed, req.Home, req.Away)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.Info("adminService", slog.String("建立隊制賽", req.Name), slog.String("開室", req.Open), slog.String("閉室", req.Closed))
+	writeJSON(w, http.StatusCreated, TeamMatchPasses{Match: req.Name, Passes: m.Passes()})
+}
+
+// writeJSON 以json回覆, status為HTTP狀態碼
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("writeJSON", slog.String(".", err.Error()))
+	}
+}
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project/game"
+)
+
+// HandServicePath 牌局紀錄匯出路徑, GET /hands/{房間}/{牌號}?format=lin|pbn|view&token={成員通行碼}
+//
+//	lin(預設): LIN字串, pbn: PBN格式, view: 轉址到 BBO handviewer
+//	通行碼為進入房間時私人送出的成員通行碼(UserPrivateMemberToken), 也可以放在 HandTokenHeader
+//	複式賽程(隊制賽,配對賽)中, 所有遊戲桌都打完該副牌後才能匯出
+const HandServicePath = "/hands/"
+
+// HandTokenHeader 出示房間成員通行碼的 HTTP Header
+const HandTokenHeader = "X-Room-Token"
+
+// handService 以房間與牌號從房間的牌局紀錄(HandStore)匯出牌局, 房間成員可以開啟"看這副牌"連結
+type handService struct {
+	rooms AllRoom
+}
+
+func newHandService(rooms AllRoom) http.Handler {
+	return &handService{rooms: rooms}
+}
+
+func (s *handService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, HandServicePath), "/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "路徑必須是 /hands/{房間}/{牌號}", http.StatusBadRequest)
+		return
+	}
+	board, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		http.Error(w, "牌號不合法", http.StatusBadRequest)
+		return
+	}
+
+	hand, err := s.hand(r, parts[0], uint32(board))
+	if err != nil {
+		switch {
+		case errors.Is(err, game.ErrHandNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, game.ErrNotRoomMember), errors.Is(err, game.ErrHandNotReleased):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			slog.Error("handService", slog.String("room", parts[0]), slog.Uint64("board", board), slog.String(".", err.Error()))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "lin":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(hand.LIN()))
+	case "pbn":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(hand.PBN()))
+	case "view":
+		http.Redirect(w, r, game.LINViewerURL(hand.LIN()), http.StatusFound)
+	default:
+		http.Error(w, "format必須是 lin, pbn 或 view", http.StatusBadRequest)
+	}
+}
+
+// hand 房間(room)牌號(board)的牌局紀錄, 房間未設定牌局紀錄儲存時只能取得最近一局
+func (s *handService) hand(r *http.Request, room string, board uint32) (*game.HandRecord, error) {
+	g, err := s.rooms.room(room)
+	if err != nil {
+		return nil, game.ErrHandNotFound
+	}
+	if !g.IsMember(handToken(r)) {
+		return nil, game.ErrNotRoomMember
+	}
+	if !g.HandReleased(board) {
+		return nil, game.ErrHandNotReleased
+	}
+	if store := g.HandStore(); store != nil {
+		return store.LoadHand(r.Context(), room, board)
+	}
+	if hand := g.LastHand(); hand != nil && hand.Board.Number == board {
+		return hand, nil
+	}
+	return nil, game.ErrHandNotFound
+}
+
+// handToken 請求出示的房間成員通行碼, Header優先
+func handToken(r *http.Request) string {
+	if token := r.Header.Get(HandTokenHeader); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
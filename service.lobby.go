@@ -24,6 +24,9 @@ type (
 		//進出大廳人數計數委派LobbyRooms負責,在chanLoop中監聽是否人數異動並廣播
 		counter *Counter
 
+		//推送給大廳所有人的訊息(複式賽程成績排名...), 在chanLoop中廣播
+		notify chan skf.Message
+
 		IsStart bool
 	}
 )
@@ -46,6 +49,7 @@ func NewLobbySpaceService() *BridgeGameLobby {
 		one:     newOnce(0),
 		server:  nil,
 		counter: counterService.(*Counter),
+		notify:  make(chan skf.Message, 16),
 	}
 	go appLobby.chanLoop()
 	appLobby.IsStart = true
@@ -90,10 +94,44 @@ func (app *BridgeGameLobby) chanLoop() {
 			//送出 cb.LobbyNumOfs
 			msg.Body, _ = pb.Marshal(arg.lobbyNumOfs)
 			app.server.Broadcast(arg.nsConn, msg)
+
+		case arg := <-app.counter.BroadcastRooms:
+			//尚未有人進入大廳
+			if app.server == nil {
+				continue
+			}
+
+			slog.Debug("廣播大廳房間", slog.Int("房間數", len(arg.lobbyNumOfs.Tables)))
+
+			msg := skf.Message{
+				Namespace: game.LobbySpaceName,
+				SetBinary: true,
+			}
+			msg.Event = game.ClnLobbyEvents.NumOfRooms
+			//送出 cb.LobbyNumOfs
+			msg.Body, _ = pb.Marshal(arg.lobbyNumOfs)
+			app.server.Broadcast(nil, msg)
+
+		case msg := <-app.notify:
+			//尚未有人進入大廳
+			if app.server == nil {
+				continue
+			}
+			app.server.Broadcast(nil, msg)
 		}
 	}
 }
 
+// NotifyLobby 推送訊息(body)給大廳所有人, 實作 game.LobbyNotifier
+func (app *BridgeGameLobby) NotifyLobby(eventName string, body []byte) {
+	app.notify <- skf.Message{
+		Namespace: game.LobbySpaceName,
+		Event:     eventName,
+		Body:      body,
+		SetBinary: true,
+	}
+}
+
 func (app *BridgeGameLobby) connectServer(c *skf.NSConn) {
 	// 從第一個連線Conn中取得Server,以方便後續Lobby對所有Namespace的廣播
 	app.one.touch(func() { app.server = c.Conn.Server() })
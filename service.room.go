@@ -2,9 +2,11 @@ package project
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/moszorn/pb"
 	"github.com/moszorn/pb/cb"
@@ -45,6 +47,61 @@ func NewRoomSpaceService(pid context.Context, rooms *map[string]*game.Game, coun
 // AllRoom Key: 房間名稱/Id , Value: 房間服務, AllRoom 實作 RoomService
 type AllRoom map[string]*game.Game // interface should be Game
 
+// NewDuplicateSession 以房間名稱(roomNames)的遊戲桌建立複式賽程, 各桌以種子(seed)依序打boards副牌
+func (rooms AllRoom) NewDuplicateSession(name string, seed uint64, boards uint32, roomNames ...string) (*game.DuplicateSession, error) {
+	tables := make([]*game.Game, 0, len(roomNames))
+	for _, roomName := range roomNames {
+		g, err := rooms.room(roomName)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, g)
+	}
+	session, err := game.NewDuplicateSession(name, seed, boards, tables...)
+	if err != nil {
+		return nil, err
+	}
+	//成績排名推送到大廳
+	if lobby, ok := lobbySpaceService.(*BridgeGameLobby); ok {
+		session.SetLobbyNotifier(lobby)
+	}
+	return session, nil
+}
+
+// NewTournament 以房間名稱(roomNames)依序作為移位表(movement)的第1,2,...桌建立配對賽, 每回合時間roundTime
+func (rooms AllRoom) NewTournament(name string, seed uint64, movement *game.Movement, pairs []game.TournamentPair, roundTime time.Duration, roomNames ...string) (*game.Tournament, error) {
+	tables := make([]*game.Game, 0, len(roomNames))
+	for _, roomName := range roomNames {
+		g, err := rooms.room(roomName)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, g)
+	}
+	tournament, err := game.NewTournament(name, seed, movement, tables, pairs, roundTime)
+	if err != nil {
+		return nil, err
+	}
+	//回合通知與成績排名推送到大廳
+	if lobby, ok := lobbySpaceService.(*BridgeGameLobby); ok {
+		tournament.SetLobbyNotifier(lobby)
+	}
+	return tournament, nil
+}
+
+// NewTeamMatch 以房間名稱openRoom(開室),closedRoom(閉室)建立隊制賽, 主隊(home)在開室坐南北, 以種子(seed)依序打boards副牌
+func (rooms AllRoom) NewTeamMatch(name string, seed uint64, boards uint32, openRoom, closedRoom string, home, away game.MatchTeam) (*game.TeamMatch, error) {
+	open, err := rooms.room(openRoom)
+	if err != nil {
+		return nil, err
+	}
+	closed, err := rooms.room(closedRoom)
+	if err != nil {
+		return nil, err
+	}
+	return game.NewTeamMatch(name, seed, boards, open, closed, home, away)
+}
+
 func (rooms AllRoom) room(roomName string) (roomGame *game.Game, err error) {
 	var ok bool
 
@@ -52,7 +109,10 @@ func (rooms AllRoom) room(roomName string) (roomGame *game.Game, err error) {
 		return nil, BackendError(GeneralCode, "參數不合法", nil)
 	}
 
-	if roomGame, ok = rooms[roomName]; ok {
+	roomsMu.RLock()
+	roomGame, ok = rooms[roomName]
+	roomsMu.RUnlock()
+	if ok {
 		return roomGame, nil
 	}
 	return nil, BackendError(GeneralCode, "無此房間", nil)
@@ -88,24 +148,63 @@ func (rooms AllRoom) enterProcess(ns *skf.NSConn, m skf.Message) (g *game.Game,
 	}()
 
 	//game.CbBid(u.Bid)
-	u = &game.RoomUser{
+	u = newRoomUser(ns, PB)
+
+	g, err = rooms.room(m.Room)
+	if err != nil {
+		// TBC return是不是就是會斷線
+		return nil, nil, err
+	}
+	return
+}
+
+// enterJSON 與 enterProcess 相同, 但 Body 為 json 的請求(v), 使用者欄位取自請求內嵌的 game.RoomRequest
+func (rooms AllRoom) enterJSON(ns *skf.NSConn, m skf.Message, v interface{ PlayingUser() *pb.PlayingUser }) (g *game.Game, u *game.RoomUser, err error) {
+	if err = json.Unmarshal(m.Body, v); err != nil {
+		return nil, nil, err
+	}
+	u = newRoomUser(ns, v.PlayingUser())
+
+	g, err = rooms.room(m.Room)
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// newRoomUser 以請求的使用者欄位(PB)建立 RoomUser, 並帶入連線出示的房間憑證
+func newRoomUser(ns *skf.NSConn, PB *pb.PlayingUser) *game.RoomUser {
+	return &game.RoomUser{
 		NsConn:      ns,
 		PlayingUser: PB,
 		Zone8:       uint8(PB.Zone), /*使用Zone8是因為可方便取用 */
 		Bid8:        uint8(PB.Bid),
 		Play8:       uint8(PB.Play),
 		PlaySeat8:   uint8(PB.PlaySeat),
+		Credential:  game.LoadCredential(ns),
 	}
+}
 
-	g, err = rooms.room(m.Room)
-	if err != nil {
-		// TBC return是不是就是會斷線
-		return nil, nil, err
+// Credential 使用者出示房間憑證, Body 為 json 的 game.RoomCredential (pb.PlayingUser沒有憑證欄位), 暫存於連線供之後的請求使用
+func (rooms AllRoom) Credential(ns *skf.NSConn, m skf.Message) error {
+	if _, er := rooms.room(m.Room); er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room))
+		}
+		return er
 	}
-	return
+
+	credential := game.RoomCredential{}
+	if err := json.Unmarshal(m.Body, &credential); err != nil {
+		slog.Error("房間憑證格式錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room))
+		return err
+	}
+	game.StoreCredential(ns, credential)
+	return nil
 }
 
-// UserJoin 必要參數使用者姓名, 區域
+// UserJoin 必要參數使用者姓名, 區域, 私人房間的密碼先以 Credential(UserPrivateCredential)出示
 func (rooms AllRoom) UserJoin(ns *skf.NSConn, m skf.Message) (er error) {
 	//roomLog(ns, m)
 	g, u, er := rooms.enterProcess(ns, m)
@@ -135,7 +234,7 @@ func (rooms AllRoom) UserLeave(ns *skf.NSConn, m skf.Message) (er error) {
 	return nil
 }
 
-// PlayerJoin 必要參數使用者姓名, 區域
+// PlayerJoin 必要參數使用者姓名, 區域, 斷線重新入座時先以 Credential(UserPrivateCredential)出示座位憑證(TablePrivateSeatToken)
 func (rooms AllRoom) PlayerJoin(ns *skf.NSConn, m skf.Message) (er error) {
 	//roomLog(ns, m)
 	g, u, er := rooms.enterProcess(ns, m)
@@ -165,6 +264,53 @@ func (rooms AllRoom) PlayerLeave(ns *skf.NSConn, m skf.Message) error {
 	return nil
 }
 
+// AddRobot 入座玩家請求機器人入座空位, 必要參數 PlaySeat(機器人入座的座位)
+func (rooms AllRoom) AddRobot(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(AddRobot)", slog.String("FYI", fmt.Sprintf("%s(%s) 請求機器人入座 %s", u.Name, game.CbSeat(u.Zone8), game.CbSeat(u.PlaySeat8))))
+
+	go g.AddRobot(u)
+	return nil
+}
+
+// RemoveRobot 入座玩家請求機器人離座, 必要參數 PlaySeat(機器人的座位)
+func (rooms AllRoom) RemoveRobot(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(RemoveRobot)", slog.String("FYI", fmt.Sprintf("%s(%s) 請求 %s 機器人離座", u.Name, game.CbSeat(u.Zone8), game.CbSeat(u.PlaySeat8))))
+
+	go g.RemoveRobot(u)
+	return nil
+}
+
+// ConventionCard 入座玩家登記配對的約定卡, Body 為 json 的 game.ConventionCardRequest (pb.PlayingUser沒有約定卡欄位)
+func (rooms AllRoom) ConventionCard(ns *skf.NSConn, m skf.Message) error {
+	req := new(game.ConventionCardRequest)
+	g, u, er := rooms.enterJSON(ns, m, req)
+	if er != nil {
+		slog.Error("房間錯誤", slog.String("msg", er.Error()), slog.String("room", m.Room))
+		return er
+	}
+	u.ConventionCard = &req.ConventionCard
+	slog.Info("入口(ConventionCard)", slog.String("FYI", fmt.Sprintf("%s(%s) 登記約定卡 %s", u.Name, game.CbSeat(u.Zone8), req.System)))
+
+	go g.RegisterConventionCard(u)
+	return nil
+}
+
 // GamePrivateNotyBid 玩家叫牌
 func (rooms AllRoom) GamePrivateNotyBid(ns *skf.NSConn, m skf.Message) error {
 	g, u, er := rooms.enterProcess(ns, m)
@@ -252,6 +398,166 @@ func (rooms AllRoom) GamePrivateCardHover(ns *skf.NSConn, m skf.Message) error {
 	return nil
 }
 
+// GamePrivateHandLin 使用者請求最近一局結算牌局的LIN字串與handviewer連結
+func (rooms AllRoom) GamePrivateHandLin(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateHandLin)", slog.String("FYI", fmt.Sprintf("%s(%s) 請求牌局LIN", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.SendHandLin(u)
+	return nil
+}
+
+// GamePrivateClaim 莊家宣告攤牌或防家認輸, 必要參數 Play(宣告者方在剩餘墩中吃到的墩數)
+func (rooms AllRoom) GamePrivateClaim(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateClaim)", slog.String("FYI", fmt.Sprintf("%s(%s) 宣告剩餘墩中吃%d墩", u.Name, game.CbSeat(u.Zone8), u.Play8)))
+
+	go g.GamePrivateClaim(u)
+	return nil
+}
+
+// GamePrivateClaimAccept 對手同意攤牌宣告
+func (rooms AllRoom) GamePrivateClaimAccept(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateClaimAccept)", slog.String("FYI", fmt.Sprintf("%s(%s) 同意攤牌宣告", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.GamePrivateClaimAccept(u)
+	return nil
+}
+
+// GamePrivateClaimReject 對手拒絕攤牌宣告
+func (rooms AllRoom) GamePrivateClaimReject(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateClaimReject)", slog.String("FYI", fmt.Sprintf("%s(%s) 拒絕攤牌宣告", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.GamePrivateClaimReject(u)
+	return nil
+}
+
+// GamePrivateUndo 玩家請求撤回自己最後一個叫品或出牌, 首引不能撤回(首引後夢家已攤牌)
+func (rooms AllRoom) GamePrivateUndo(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateUndo)", slog.String("FYI", fmt.Sprintf("%s(%s) 請求撤回", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.GamePrivateUndo(u)
+	return nil
+}
+
+// GamePrivateUndoAccept 對手同意撤回
+func (rooms AllRoom) GamePrivateUndoAccept(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateUndoAccept)", slog.String("FYI", fmt.Sprintf("%s(%s) 同意撤回", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.GamePrivateUndoAccept(u)
+	return nil
+}
+
+// GamePrivateUndoReject 對手拒絕撤回
+func (rooms AllRoom) GamePrivateUndoReject(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateUndoReject)", slog.String("FYI", fmt.Sprintf("%s(%s) 拒絕撤回", u.Name, game.CbSeat(u.Zone8))))
+
+	go g.GamePrivateUndoReject(u)
+	return nil
+}
+
+// GamePrivateAlertBid 玩家叫牌並警示該叫品, Body 為 json 的 game.AlertBid (pb.PlayingUser沒有警示欄位)
+func (rooms AllRoom) GamePrivateAlertBid(ns *skf.NSConn, m skf.Message) error {
+	req := new(game.AlertBid)
+	g, u, er := rooms.enterJSON(ns, m, req)
+	if er != nil {
+		slog.Error("房間錯誤", slog.String("msg", er.Error()), slog.String("room", m.Room))
+		return er
+	}
+	u.Bid, u.Bid8 = uint32(req.Bid), req.Bid
+	u.Alert, u.Explanation = true, req.Explanation
+	slog.Info("入口(GamePrivateAlertBid)", slog.String("FYI", fmt.Sprintf("叫者:%s(%s) 警示叫品:%s 說明:%s", u.Name, game.CbSeat(u.Zone8), game.CbBid(u.Bid8), u.Explanation)))
+
+	go g.GamePrivateNotyBid(u)
+	return nil
+}
+
+// GamePrivateAlertAsk 對手詢問叫品的意思, 必要參數 PlaySeat(叫者座位), Bid(叫品)
+func (rooms AllRoom) GamePrivateAlertAsk(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateAlertAsk)", slog.String("FYI", fmt.Sprintf("%s(%s) 詢問 %s %s", u.Name, game.CbSeat(u.Zone8), game.CbSeat(u.PlaySeat8), game.CbBid(u.Bid8))))
+
+	go g.GamePrivateAlertAsk(u)
+	return nil
+}
+
+// GamePrivateAlertAnswer 叫者方回答詢問, 必要參數 PlaySeat(詢問者座位), Chat(回答)
+func (rooms AllRoom) GamePrivateAlertAnswer(ns *skf.NSConn, m skf.Message) error {
+	g, u, er := rooms.enterProcess(ns, m)
+	if er != nil {
+		var err *BackendErr
+		if errors.As(er, &err) {
+			slog.Error("房間錯誤", slog.String("msg", err.Error()), slog.String("room", m.Room), slog.String("zone", fmt.Sprintf("%s", game.CbSeat(u.Zone8))))
+		}
+		return er
+	}
+	slog.Info("入口(GamePrivateAlertAnswer)", slog.String("FYI", fmt.Sprintf("%s(%s) 回答 %s 的詢問", u.Name, game.CbSeat(u.Zone8), game.CbSeat(u.PlaySeat8))))
+
+	go g.GamePrivateAlertAnswer(u)
+	return nil
+}
+
 func (rooms AllRoom) Chat(ns *skf.NSConn, m skf.Message) error {
 	g, u, er := rooms.enterProcess(ns, m)
 	if er != nil {